@@ -0,0 +1,226 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+// Package atlas packs images into texture atlas pages.
+//
+// Pixel coordinates and texture coordinates have their origin at the top left corner
+// of a page (first row of pixels), like gfx.Rect.
+package atlas
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Options configures Build.
+type Options struct {
+	// size of a page in pixels
+	Width, Height int
+	// transparent pixels between images
+	Padding int
+	// number of times the border pixels of an image are repeated around it, to avoid
+	// bleeding of neighbouring images with linear filtering
+	Extrude int
+	Method  Method
+}
+
+// Image is an image to be packed.
+type Image struct {
+	Name  string
+	Image image.Image
+}
+
+// Rect is a rectangle in texture coordinates (0 to 1).
+type Rect struct {
+	X      float32 `json:"x"`
+	Y      float32 `json:"y"`
+	Width  float32 `json:"width"`
+	Height float32 `json:"height"`
+}
+
+// Region is the location of a packed image.
+type Region struct {
+	Page int `json:"page"`
+	// position and size in pixels (without extrusion)
+	X      int  `json:"x"`
+	Y      int  `json:"y"`
+	Width  int  `json:"width"`
+	Height int  `json:"height"`
+	UV     Rect `json:"uv"`
+}
+
+// Atlas is a set of pages with the regions of the packed images.
+type Atlas struct {
+	Pages   []*image.RGBA
+	Regions map[string]Region
+}
+
+// file is the JSON representation of an atlas
+type file struct {
+	Pages   []string          `json:"pages"`
+	Width   int               `json:"width"`
+	Height  int               `json:"height"`
+	Regions map[string]Region `json:"regions"`
+}
+
+// DefaultOptions returns pages of 1024 x 1024 pixels, padding 2, extrusion 1 and
+// the MaxRects method.
+func DefaultOptions() Options {
+	return Options{Width: 1024, Height: 1024, Padding: 2, Extrude: 1, Method: MaxRects}
+}
+
+// Build packs images into as many pages as needed. Images are inserted from large to
+// small, a new page is added, when an image doesn't fit on any existing page.
+func Build(images []Image, options Options) (*Atlas, error) {
+	atlas := &Atlas{Regions: make(map[string]Region)}
+	var packers []packer
+	sorted := append([]Image(nil), images...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].Image.Bounds(), sorted[j].Image.Bounds()
+		return max(a.Dx(), a.Dy()) > max(b.Dx(), b.Dy())
+	})
+	for _, img := range sorted {
+		if _, ok := atlas.Regions[img.Name]; ok {
+			return nil, errors.New("atlas: duplicate image name " + img.Name)
+		}
+		bounds := img.Image.Bounds()
+		border := options.Extrude
+		width := bounds.Dx() + 2*border + options.Padding
+		height := bounds.Dy() + 2*border + options.Padding
+		if width-options.Padding > options.Width || height-options.Padding > options.Height {
+			return nil, fmt.Errorf("atlas: image %s (%dx%d) is larger than page", img.Name, bounds.Dx(), bounds.Dy())
+		}
+		// the padding at the right and bottom page border is not needed
+		width, height = min(width, options.Width), min(height, options.Height)
+		page, x, y := -1, 0, 0
+		for i, packer := range packers {
+			var ok bool
+			if x, y, ok = packer.insert(width, height); ok {
+				page = i
+				break
+			}
+		}
+		if page < 0 {
+			packer := newPacker(options.Method, options.Width, options.Height)
+			x, y, _ = packer.insert(width, height)
+			packers = append(packers, packer)
+			atlas.Pages = append(atlas.Pages, image.NewRGBA(image.Rect(0, 0, options.Width, options.Height)))
+			page = len(packers) - 1
+		}
+		region := Region{Page: page, X: x + border, Y: y + border, Width: bounds.Dx(), Height: bounds.Dy()}
+		region.UV = Rect{
+			X:      float32(region.X) / float32(options.Width),
+			Y:      float32(region.Y) / float32(options.Height),
+			Width:  float32(region.Width) / float32(options.Width),
+			Height: float32(region.Height) / float32(options.Height)}
+		atlas.Regions[img.Name] = region
+		copyImage(atlas.Pages[page], img.Image, region.X, region.Y, border)
+	}
+	return atlas, nil
+}
+
+// Region returns the region of the image with name.
+func (atlas *Atlas) Region(name string) (Region, bool) {
+	region, ok := atlas.Regions[name]
+	return region, ok
+}
+
+// Save writes the pages as PNG files and the regions as JSON file to path. The
+// pages are named like path without extension and the page number appended
+// (e.g. sprites_0.png for sprites.json).
+func (atlas *Atlas) Save(path string) error {
+	var err error
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	data := file{Regions: atlas.Regions}
+	for i := 0; i < len(atlas.Pages) && err == nil; i++ {
+		pagePath := fmt.Sprintf("%s_%d.png", base, i)
+		data.Pages = append(data.Pages, filepath.Base(pagePath))
+		data.Width, data.Height = atlas.Pages[i].Rect.Dx(), atlas.Pages[i].Rect.Dy()
+		err = writePNG(pagePath, atlas.Pages[i])
+	}
+	if err == nil {
+		var bytes []byte
+		bytes, err = json.MarshalIndent(&data, "", "\t")
+		if err == nil {
+			err = ioutil.WriteFile(path, bytes, 0644)
+		}
+	}
+	return err
+}
+
+// Load reads an atlas written by Save.
+func Load(path string) (*Atlas, error) {
+	var data file
+	bytes, err := ioutil.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(bytes, &data)
+		if err == nil {
+			atlas := &Atlas{Regions: data.Regions}
+			for _, page := range data.Pages {
+				var img image.Image
+				img, err = readPNG(filepath.Join(filepath.Dir(path), page))
+				if err != nil {
+					return nil, err
+				}
+				rgba := image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+				draw.Draw(rgba, rgba.Rect, img, img.Bounds().Min, draw.Src)
+				atlas.Pages = append(atlas.Pages, rgba)
+			}
+			return atlas, nil
+		}
+	}
+	return nil, err
+}
+
+// copyImage draws img at x, y and repeats its border pixels extrude times.
+func copyImage(page *image.RGBA, img image.Image, x, y, extrude int) {
+	bounds := img.Bounds()
+	target := image.Rect(x, y, x+bounds.Dx(), y+bounds.Dy())
+	draw.Draw(page, target, img, bounds.Min, draw.Src)
+	for i := 1; i <= extrude; i++ {
+		for px := target.Min.X - i; px < target.Max.X+i; px++ {
+			page.Set(px, target.Min.Y-i, page.At(clamp(px, target.Min.X, target.Max.X-1), target.Min.Y))
+			page.Set(px, target.Max.Y-1+i, page.At(clamp(px, target.Min.X, target.Max.X-1), target.Max.Y-1))
+		}
+		for py := target.Min.Y - i; py < target.Max.Y+i; py++ {
+			page.Set(target.Min.X-i, py, page.At(target.Min.X, clamp(py, target.Min.Y, target.Max.Y-1)))
+			page.Set(target.Max.X-1+i, py, page.At(target.Max.X-1, clamp(py, target.Min.Y, target.Max.Y-1)))
+		}
+	}
+}
+
+func clamp(value, low, high int) int {
+	return max(low, min(value, high))
+}
+
+func writePNG(path string, img image.Image) error {
+	file, err := os.Create(path)
+	if err == nil {
+		err = png.Encode(file, img)
+		closeErr := file.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+func readPNG(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err == nil {
+		defer file.Close()
+		return png.Decode(file)
+	}
+	return nil, err
+}
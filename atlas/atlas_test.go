@@ -0,0 +1,161 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package atlas
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testImages returns images of random size filled with a color derived from index.
+func testImages(count, maxSize int) []Image {
+	random := rand.New(rand.NewSource(1))
+	images := make([]Image, count)
+	for i := range images {
+		img := image.NewRGBA(image.Rect(0, 0, 1+random.Intn(maxSize), 1+random.Intn(maxSize)))
+		for p := 0; p < len(img.Pix); p += 4 {
+			img.Pix[p], img.Pix[p+1], img.Pix[p+2], img.Pix[p+3] = uint8(i), uint8(i>>8), 100, 255
+		}
+		images[i] = Image{fmt.Sprintf("image%d", i), img}
+	}
+	return images
+}
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+	}{
+		{"maxrects", Options{Width: 256, Height: 256, Padding: 2, Extrude: 1, Method: MaxRects}},
+		{"skyline", Options{Width: 256, Height: 256, Padding: 2, Extrude: 1, Method: Skyline}},
+		{"no padding", Options{Width: 128, Height: 256, Method: MaxRects}},
+	}
+	images := testImages(200, 40)
+	for _, test := range tests {
+		atlas, err := Build(images, test.options)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if len(atlas.Pages) < 2 {
+			t.Errorf("%s: %d pages, want more than one", test.name, len(atlas.Pages))
+		}
+		border := test.options.Extrude + (test.options.Padding+1)/2
+		for i, a := range images {
+			region := atlas.Regions[a.Name]
+			bounds := image.Rect(region.X, region.Y, region.X+region.Width, region.Y+region.Height)
+			if !bounds.In(atlas.Pages[region.Page].Rect) {
+				t.Fatalf("%s: %s %v outside of page", test.name, a.Name, bounds)
+			}
+			// images including extrusion and half the padding don't overlap
+			for _, b := range images[i+1:] {
+				other := atlas.Regions[b.Name]
+				otherBounds := image.Rect(other.X, other.Y, other.X+other.Width, other.Y+other.Height)
+				if region.Page == other.Page && bounds.Inset(-border).Overlaps(otherBounds.Inset(-border)) {
+					t.Fatalf("%s: %s %v overlaps %s %v", test.name, a.Name, bounds, b.Name, otherBounds)
+				}
+			}
+			if got, want := atlas.Pages[region.Page].At(region.X, region.Y), a.Image.At(0, 0); got != want {
+				t.Fatalf("%s: %s: pixel %v, want %v", test.name, a.Name, got, want)
+			}
+		}
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	big := Image{"big", image.NewRGBA(image.Rect(0, 0, 300, 10))}
+	if _, err := Build([]Image{big}, Options{Width: 256, Height: 256}); err == nil {
+		t.Errorf("image larger than page accepted")
+	}
+	small := Image{"small", image.NewRGBA(image.Rect(0, 0, 4, 4))}
+	if _, err := Build([]Image{small, small}, DefaultOptions()); err == nil {
+		t.Errorf("duplicate name accepted")
+	}
+	// exactly the page size fits without padding at the page border
+	exact := Image{"exact", image.NewRGBA(image.Rect(0, 0, 256, 256))}
+	if _, err := Build([]Image{exact}, Options{Width: 256, Height: 256, Padding: 2}); err != nil {
+		t.Errorf("image of page size: %v", err)
+	}
+}
+
+func TestExtrude(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.RGBA{255, 0, 0, 255})
+	img.Set(1, 1, color.RGBA{0, 0, 255, 255})
+	atlas, err := Build([]Image{{"image", img}}, Options{Width: 16, Height: 16, Extrude: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	region := atlas.Regions["image"]
+	page := atlas.Pages[0]
+	if region.X != 2 || region.Y != 2 {
+		t.Errorf("region at %d, %d", region.X, region.Y)
+	}
+	if page.At(0, 0) != img.At(0, 0) || page.At(5, 5) != img.At(1, 1) || page.At(2, 5) != img.At(0, 1) {
+		t.Errorf("border pixels not extruded")
+	}
+	if uv := region.UV; uv.X != 2.0/16 || uv.Width != 2.0/16 {
+		t.Errorf("uv %v", uv)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "atlas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	images := testImages(50, 60)
+	atlas, err := Build(images, Options{Width: 128, Height: 128, Padding: 1})
+	if err == nil {
+		path := filepath.Join(dir, "test.json")
+		err = atlas.Save(path)
+		if err == nil {
+			var loaded *Atlas
+			loaded, err = Load(path)
+			if err == nil {
+				if len(loaded.Pages) != len(atlas.Pages) || len(loaded.Regions) != len(images) {
+					t.Fatalf("%d pages and %d regions, want %d and %d", len(loaded.Pages), len(loaded.Regions), len(atlas.Pages), len(images))
+				}
+				for name, region := range atlas.Regions {
+					if loaded.Regions[name] != region {
+						t.Errorf("%s: %v, want %v", name, loaded.Regions[name], region)
+					}
+				}
+				for i := range atlas.Pages {
+					if string(loaded.Pages[i].Pix) != string(atlas.Pages[i].Pix) {
+						t.Errorf("page %d differs", i)
+					}
+				}
+			}
+		}
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func BenchmarkMaxRects(b *testing.B) {
+	benchmarkBuild(b, MaxRects)
+}
+
+func BenchmarkSkyline(b *testing.B) {
+	benchmarkBuild(b, Skyline)
+}
+
+func benchmarkBuild(b *testing.B, method Method) {
+	images := testImages(500, 64)
+	options := DefaultOptions()
+	options.Method = method
+	for i := 0; i < b.N; i++ {
+		Build(images, options)
+	}
+}
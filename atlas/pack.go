@@ -0,0 +1,197 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package atlas
+
+// Method is a rectangle packing algorithm.
+type Method int
+
+// Packing methods.
+const (
+	// MaxRects keeps a list of maximal free rectangles and chooses the one with best
+	// short side fit. It packs tighter than Skyline.
+	MaxRects Method = iota
+	// Skyline keeps the outline of the placed rectangles and places new ones as close
+	// to the top of the page as possible. It is faster than MaxRects.
+	Skyline
+)
+
+// packer places rectangles on one page.
+type packer interface {
+	// insert returns the position of a free rectangle of size width x height
+	insert(width, height int) (x, y int, ok bool)
+}
+
+type rect struct {
+	x, y, width, height int
+}
+
+type maxRectsPacker struct {
+	free []rect
+}
+
+type skylinePacker struct {
+	width, height int
+	segments      []rect
+}
+
+func newPacker(method Method, width, height int) packer {
+	if method == Skyline {
+		return &skylinePacker{width: width, height: height, segments: []rect{{0, 0, width, 0}}}
+	}
+	return &maxRectsPacker{free: []rect{{0, 0, width, height}}}
+}
+
+func (packer *maxRectsPacker) insert(width, height int) (int, int, bool) {
+	best := -1
+	bestShort, bestLong := 0, 0
+	for i, free := range packer.free {
+		if free.width >= width && free.height >= height {
+			short := min(free.width-width, free.height-height)
+			long := max(free.width-width, free.height-height)
+			if best < 0 || short < bestShort || short == bestShort && long < bestLong {
+				best, bestShort, bestLong = i, short, long
+			}
+		}
+	}
+	if best < 0 {
+		return 0, 0, false
+	}
+	placed := rect{packer.free[best].x, packer.free[best].y, width, height}
+	packer.split(placed)
+	packer.prune()
+	return placed.x, placed.y, true
+}
+
+// split replaces every free rectangle intersecting placed by up to four maximal
+// rectangles around placed.
+func (packer *maxRectsPacker) split(placed rect) {
+	free := packer.free[:0:0]
+	for _, r := range packer.free {
+		if !r.intersects(placed) {
+			free = append(free, r)
+			continue
+		}
+		if placed.x > r.x {
+			free = append(free, rect{r.x, r.y, placed.x - r.x, r.height})
+		}
+		if placed.x+placed.width < r.x+r.width {
+			free = append(free, rect{placed.x + placed.width, r.y, r.x + r.width - placed.x - placed.width, r.height})
+		}
+		if placed.y > r.y {
+			free = append(free, rect{r.x, r.y, r.width, placed.y - r.y})
+		}
+		if placed.y+placed.height < r.y+r.height {
+			free = append(free, rect{r.x, placed.y + placed.height, r.width, r.y + r.height - placed.y - placed.height})
+		}
+	}
+	packer.free = free
+}
+
+// prune removes free rectangles contained in other free rectangles.
+func (packer *maxRectsPacker) prune() {
+	free := packer.free[:0:0]
+	for i, r := range packer.free {
+		contained := false
+		for j, other := range packer.free {
+			// of two equal rectangles keep the first one
+			if i != j && other.contains(r) && (r != other || j < i) {
+				contained = true
+				break
+			}
+		}
+		if !contained {
+			free = append(free, r)
+		}
+	}
+	packer.free = free
+}
+
+func (packer *skylinePacker) insert(width, height int) (int, int, bool) {
+	best := -1
+	bestX, bestY, bestWidth := 0, 0, 0
+	for i, segment := range packer.segments {
+		if y, ok := packer.fit(i, width, height); ok {
+			// lowest position, then narrowest segment to reduce wasted space
+			if best < 0 || y < bestY || y == bestY && segment.width < bestWidth {
+				best, bestX, bestY, bestWidth = i, segment.x, y, segment.width
+			}
+		}
+	}
+	if best < 0 {
+		return 0, 0, false
+	}
+	packer.add(best, rect{bestX, bestY, width, height})
+	return bestX, bestY, true
+}
+
+// fit returns the lowest y, where a rectangle starting at segment index fits.
+func (packer *skylinePacker) fit(index, width, height int) (int, bool) {
+	x := packer.segments[index].x
+	if x+width > packer.width {
+		return 0, false
+	}
+	y := 0
+	remaining := width
+	for i := index; remaining > 0; i++ {
+		y = max(y, packer.segments[i].y)
+		if y+height > packer.height {
+			return 0, false
+		}
+		remaining -= packer.segments[i].width
+	}
+	return y, true
+}
+
+// add raises the skyline by placed, which starts at segment index.
+func (packer *skylinePacker) add(index int, placed rect) {
+	top := rect{placed.x, placed.y + placed.height, placed.width, 0}
+	segments := append([]rect(nil), packer.segments[:index]...)
+	segments = append(segments, top)
+	end := placed.x + placed.width
+	for _, segment := range packer.segments[index:] {
+		if segment.x+segment.width > end {
+			if segment.x < end {
+				// shorten segment partially covered by placed
+				segment.width -= end - segment.x
+				segment.x = end
+			}
+			segments = append(segments, segment)
+		}
+	}
+	// merge neighbouring segments of the same height
+	merged := segments[:1]
+	for _, segment := range segments[1:] {
+		last := &merged[len(merged)-1]
+		if last.y == segment.y {
+			last.width += segment.width
+		} else {
+			merged = append(merged, segment)
+		}
+	}
+	packer.segments = merged
+}
+
+func (r rect) intersects(other rect) bool {
+	return r.x < other.x+other.width && other.x < r.x+r.width && r.y < other.y+other.height && other.y < r.y+r.height
+}
+
+func (r rect) contains(other rect) bool {
+	return other.x >= r.x && other.y >= r.y && other.x+other.width <= r.x+r.width && other.y+other.height <= r.y+r.height
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,79 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+// Package camera provides orbit, fly-through and 2D orthographic cameras.
+package camera
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/vbsw/opengl-go-example/vmath"
+)
+
+// Camera is the interface of all cameras.
+type Camera interface {
+	// View returns the view matrix (world to camera space).
+	View() vmath.Mat4
+	// Projection returns the projection matrix (camera to clip space).
+	Projection() vmath.Mat4
+	// Resize updates the projection to the new window size (e.g. from onResize).
+	Resize(width, height int)
+}
+
+// Perspective is for storing the parameters of a perspective projection.
+type Perspective struct {
+	// vertical field of view in radians
+	Fovy   float32
+	Aspect float32
+	Near   float32
+	Far    float32
+}
+
+// NewPerspective returns a perspective projection with a vertical field of view of 60 degrees.
+func NewPerspective(width, height int) Perspective {
+	var perspective Perspective
+	perspective.Fovy = vmath.Radians(60)
+	perspective.Near = 0.1
+	perspective.Far = 100
+	perspective.Resize(width, height)
+	return perspective
+}
+
+// Projection returns the projection matrix.
+func (perspective *Perspective) Projection() vmath.Mat4 {
+	return vmath.Perspective(perspective.Fovy, perspective.Aspect, perspective.Near, perspective.Far)
+}
+
+// Resize sets the aspect ratio to width / height.
+func (perspective *Perspective) Resize(width, height int) {
+	if width > 0 && height > 0 {
+		perspective.Aspect = float32(width) / float32(height)
+	}
+}
+
+// ViewProjection returns projection * view of camera.
+func ViewProjection(camera Camera) vmath.Mat4 {
+	return camera.Projection().Mul(camera.View())
+}
+
+// SetUniforms uploads the matrices of camera to the current program. If viewLocation
+// is -1 (e.g. shaders of github.com/vbsw/shaders have no view uniform), projection * view
+// is uploaded to projectionLocation.
+func SetUniforms(camera Camera, projectionLocation, viewLocation int32) {
+	if viewLocation >= 0 {
+		view := camera.View()
+		projection := camera.Projection()
+		gl.UniformMatrix4fv(viewLocation, 1, false, view.Ptr())
+		gl.UniformMatrix4fv(projectionLocation, 1, false, projection.Ptr())
+	} else {
+		viewProjection := ViewProjection(camera)
+		gl.UniformMatrix4fv(projectionLocation, 1, false, viewProjection.Ptr())
+	}
+}
+
+// Position returns the position of camera in world space.
+func Position(camera Camera) vmath.Vec3 {
+	inv, _ := camera.View().Inverse()
+	return inv.Translation()
+}
@@ -0,0 +1,174 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package camera
+
+import (
+	"github.com/vbsw/opengl-go-example/vmath"
+	"math"
+	"testing"
+)
+
+func TestOrbitView(t *testing.T) {
+	tests := []struct {
+		name       string
+		yaw, pitch float32
+		eye        vmath.Vec3
+	}{
+		{"front", 0, 0, vmath.Vec3{1, 2, 8}},
+		{"right", math.Pi / 2, 0, vmath.Vec3{6, 2, 3}},
+		{"above", 0, -math.Pi / 2, vmath.Vec3{1, 7, 3}},
+	}
+	target := vmath.Vec3{1, 2, 3}
+	for _, test := range tests {
+		orbit := NewOrbit(target, 5, 800, 600)
+		orbit.Yaw, orbit.Pitch = test.yaw, test.pitch
+		if eye := orbit.Eye(); !eye.ApproxEqual(test.eye) {
+			t.Errorf("%s: eye %v, want %v", test.name, eye, test.eye)
+		}
+		if test.pitch == 0 {
+			// the target is on the negative z axis of camera space
+			view := orbit.View()
+			if got, want := view.TransformPoint(target), (vmath.Vec3{0, 0, -5}); !got.ApproxEqual(want) {
+				t.Errorf("%s: target in view space %v, want %v", test.name, got, want)
+			}
+			if position := Position(orbit); !position.ApproxEqual(test.eye) {
+				t.Errorf("%s: position %v, want %v", test.name, position, test.eye)
+			}
+		}
+	}
+}
+
+func TestOrbitClamp(t *testing.T) {
+	orbit := NewOrbit(vmath.Vec3{}, 5, 800, 600)
+	orbit.Rotate(0, 1e6)
+	if !vmath.ApproxEqual(orbit.Pitch, vmath.Radians(-89)) {
+		t.Errorf("pitch %g", orbit.Pitch)
+	}
+	orbit.Rotate(0, -1e6)
+	if !vmath.ApproxEqual(orbit.Pitch, vmath.Radians(89)) {
+		t.Errorf("pitch %g", orbit.Pitch)
+	}
+	orbit.Zoom(1000)
+	if orbit.Distance != orbit.MinDistance {
+		t.Errorf("distance %g", orbit.Distance)
+	}
+	orbit.Zoom(-1000)
+	if orbit.Distance != orbit.MaxDistance {
+		t.Errorf("distance %g", orbit.Distance)
+	}
+}
+
+func TestOrbitPan(t *testing.T) {
+	orbit := NewOrbit(vmath.Vec3{}, 10, 600, 600)
+	orbit.Pitch = 0
+	// the height of the window covers 2 * 10 * tan(30°) units at the target
+	orbit.Pan(-100, 0, 600)
+	want := 2 * 10 * float32(math.Tan(math.Pi/6)) / 6
+	if got := orbit.Target; !got.ApproxEqual(vmath.Vec3{want, 0, 0}) {
+		t.Errorf("target %v, want x %g", got, want)
+	}
+}
+
+func TestFlyView(t *testing.T) {
+	tests := []struct {
+		name       string
+		yaw, pitch float32
+		forward    vmath.Vec3
+		right      vmath.Vec3
+	}{
+		{"default", 0, 0, vmath.Vec3{0, 0, -1}, vmath.Vec3{1, 0, 0}},
+		{"left", math.Pi / 2, 0, vmath.Vec3{-1, 0, 0}, vmath.Vec3{0, 0, -1}},
+		{"down", 0, -math.Pi / 4, vmath.Vec3{0, -1, -1}.Normalize(), vmath.Vec3{1, 0, 0}},
+	}
+	for _, test := range tests {
+		fly := NewFly(vmath.Vec3{1, 2, 3}, 800, 600)
+		fly.Yaw, fly.Pitch = test.yaw, test.pitch
+		if forward := fly.Forward(); !forward.ApproxEqual(test.forward) {
+			t.Errorf("%s: forward %v, want %v", test.name, forward, test.forward)
+		}
+		if right := fly.Right(); !right.ApproxEqual(test.right) {
+			t.Errorf("%s: right %v, want %v", test.name, right, test.right)
+		}
+		view := fly.View()
+		if got := view.TransformPoint(fly.Position.Add(test.forward)); !got.ApproxEqual(vmath.Vec3{0, 0, -1}) {
+			t.Errorf("%s: point ahead in view space %v", test.name, got)
+		}
+		if position := Position(fly); !position.ApproxEqual(fly.Position) {
+			t.Errorf("%s: position %v", test.name, position)
+		}
+	}
+}
+
+func TestFlyMoveAndClamp(t *testing.T) {
+	fly := NewFly(vmath.Vec3{}, 800, 600)
+	fly.Look(0, -1e6)
+	if !vmath.ApproxEqual(fly.Pitch, vmath.Radians(89)) {
+		t.Errorf("pitch %g", fly.Pitch)
+	}
+	fly.Pitch = 0
+	// diagonal movement is not faster
+	fly.Move(1, 1, 0, 2, 0.5)
+	if length := fly.Position.Len(); !vmath.ApproxEqual(length, 1) {
+		t.Errorf("moved %g", length)
+	}
+}
+
+func TestResize(t *testing.T) {
+	tests := []struct {
+		width, height int
+		aspect        float32
+	}{
+		{800, 400, 2},
+		{300, 600, 0.5},
+		// ignored (e.g. minimized window)
+		{0, 0, 0.5},
+		{100, 0, 0.5},
+	}
+	orbit := NewOrbit(vmath.Vec3{}, 5, 600, 600)
+	ortho := NewOrtho2D(600, 600)
+	for _, test := range tests {
+		orbit.Resize(test.width, test.height)
+		ortho.Resize(test.width, test.height)
+		if orbit.Aspect != test.aspect {
+			t.Errorf("%dx%d: aspect %g, want %g", test.width, test.height, orbit.Aspect, test.aspect)
+		}
+		if float32(ortho.Width)/float32(ortho.Height) != test.aspect {
+			t.Errorf("%dx%d: 2D size %dx%d", test.width, test.height, ortho.Width, ortho.Height)
+		}
+		// the x scale of the projection is the y scale divided by the aspect
+		projection := orbit.Projection()
+		if !vmath.ApproxEqual(projection[0]*test.aspect, projection[5]) {
+			t.Errorf("%dx%d: projection %v", test.width, test.height, projection)
+		}
+	}
+}
+
+func TestOrtho2D(t *testing.T) {
+	ortho := NewOrtho2D(800, 600)
+	if got := ortho.ScreenToWorld(400, 300); !got.ApproxEqual(vmath.Vec2{0, 0}) {
+		t.Errorf("center %v", got)
+	}
+	ortho.Pan(100, 50)
+	if got := ortho.Center; !got.ApproxEqual(vmath.Vec2{-100, 50}) {
+		t.Errorf("center after pan %v", got)
+	}
+	// the world position under the cursor stays fixed
+	before := ortho.ScreenToWorld(700, 100)
+	ortho.ZoomAt(3, 700, 100)
+	if after := ortho.ScreenToWorld(700, 100); !after.ApproxEqual(before) || ortho.Zoom <= 1 {
+		t.Errorf("zoom %g, world position %v, want %v", ortho.Zoom, after, before)
+	}
+	ortho.ZoomAt(1000, 0, 0)
+	if ortho.Zoom != ortho.MaxZoom {
+		t.Errorf("zoom %g", ortho.Zoom)
+	}
+	// at zoom 1 the right edge is half the window width right of the center
+	ortho = NewOrtho2D(800, 600)
+	viewProjection := ViewProjection(ortho)
+	if clip := viewProjection.TransformPoint(vmath.Vec3{400, 0, 0}); !clip.ApproxEqual(vmath.Vec3{1, 0, 0}) {
+		t.Errorf("clip %v", clip)
+	}
+}
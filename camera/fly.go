@@ -0,0 +1,101 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package camera
+
+import (
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/vbsw/opengl-go-example/input"
+	"github.com/vbsw/opengl-go-example/vmath"
+)
+
+// Fly is a first-person camera moving freely through the scene.
+type Fly struct {
+	Perspective
+	Position vmath.Vec3
+	// rotation around y axis in radians
+	Yaw float32
+	// rotation around x axis in radians (limited to -89 to 89 degrees)
+	Pitch float32
+	// units per second
+	Speed float32
+	// speed multiplier while shift is held down
+	FastFactor float32
+	// radians per pixel of mouse motion
+	LookSpeed float32
+}
+
+// NewFly returns a new instance of Fly at position looking along negative z.
+func NewFly(position vmath.Vec3, width, height int) *Fly {
+	fly := new(Fly)
+	fly.Perspective = NewPerspective(width, height)
+	fly.Position = position
+	fly.Speed = 3
+	fly.FastFactor = 4
+	fly.LookSpeed = 0.003
+	return fly
+}
+
+// View returns the view matrix.
+func (fly *Fly) View() vmath.Mat4 {
+	return vmath.LookAt(fly.Position, fly.Position.Add(fly.Forward()), vmath.Vec3{0, 1, 0})
+}
+
+// Forward returns the direction the camera looks at.
+func (fly *Fly) Forward() vmath.Vec3 {
+	return vmath.QuatEuler(fly.Pitch, fly.Yaw, 0).Rotate(vmath.Vec3{0, 0, -1})
+}
+
+// Right returns the direction to the right of the camera (parallel to ground).
+func (fly *Fly) Right() vmath.Vec3 {
+	return vmath.QuatEuler(0, fly.Yaw, 0).Rotate(vmath.Vec3{1, 0, 0})
+}
+
+// Look turns the camera by dx and dy pixels of mouse motion.
+func (fly *Fly) Look(dx, dy float32) {
+	fly.Yaw -= dx * fly.LookSpeed
+	fly.Pitch = vmath.Clamp(fly.Pitch-dy*fly.LookSpeed, vmath.Radians(-89), vmath.Radians(89))
+}
+
+// Move moves the camera relative to its orientation. Forward, right and up are -1 to 1.
+func (fly *Fly) Move(forward, right, up, speed, dt float32) {
+	direction := fly.Forward().Scale(forward).Add(fly.Right().Scale(right)).Add(vmath.Vec3{0, up, 0})
+	if direction.Len() > 1 {
+		direction = direction.Normalize()
+	}
+	fly.Position = fly.Position.Add(direction.Scale(speed * dt))
+}
+
+// HandleMouse turns the camera, if the cursor is disabled (captured) or the right
+// mouse button is held down.
+func (fly *Fly) HandleMouse(window *glfw.Window, mouse *input.Mouse) {
+	if mouse.CursorMode == glfw.CursorDisabled || mouse.ButtonDown(glfw.MouseButtonRight) {
+		fly.Look(float32(mouse.DeltaX), float32(mouse.DeltaY))
+	}
+}
+
+// HandleKeys moves the camera with W, A, S, D, Q (down) and E (up). Shift moves faster.
+func (fly *Fly) HandleKeys(window *glfw.Window, dt float32) {
+	var forward, right, up float32
+	speed := fly.Speed
+	forward += keyAxis(window, glfw.KeyW, glfw.KeyS)
+	right += keyAxis(window, glfw.KeyD, glfw.KeyA)
+	up += keyAxis(window, glfw.KeyE, glfw.KeyQ)
+	if window.GetKey(glfw.KeyLeftShift) == glfw.Press || window.GetKey(glfw.KeyRightShift) == glfw.Press {
+		speed *= fly.FastFactor
+	}
+	fly.Move(forward, right, up, speed, dt)
+}
+
+func keyAxis(window *glfw.Window, positive, negative glfw.Key) float32 {
+	var value float32
+	if window.GetKey(positive) == glfw.Press {
+		value++
+	}
+	if window.GetKey(negative) == glfw.Press {
+		value--
+	}
+	return value
+}
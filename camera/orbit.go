@@ -0,0 +1,92 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package camera
+
+import (
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/vbsw/opengl-go-example/input"
+	"github.com/vbsw/opengl-go-example/vmath"
+	"math"
+)
+
+// Orbit is a camera rotating around a target.
+type Orbit struct {
+	Perspective
+	Target   vmath.Vec3
+	Distance float32
+	// rotation around y axis in radians
+	Yaw float32
+	// rotation around x axis in radians (limited to -89 to 89 degrees)
+	Pitch       float32
+	MinDistance float32
+	MaxDistance float32
+	// radians per pixel of mouse motion
+	RotateSpeed float32
+	// zoom factor per scroll step
+	ZoomSpeed float32
+}
+
+// NewOrbit returns a new instance of Orbit looking at target from distance.
+func NewOrbit(target vmath.Vec3, distance float32, width, height int) *Orbit {
+	orbit := new(Orbit)
+	orbit.Perspective = NewPerspective(width, height)
+	orbit.Target = target
+	orbit.Distance = distance
+	orbit.Pitch = vmath.Radians(-20)
+	orbit.MinDistance = 0.5
+	orbit.MaxDistance = 50
+	orbit.RotateSpeed = 0.01
+	orbit.ZoomSpeed = 1.1
+	return orbit
+}
+
+// View returns the view matrix.
+func (orbit *Orbit) View() vmath.Mat4 {
+	return vmath.LookAt(orbit.Eye(), orbit.Target, vmath.Vec3{0, 1, 0})
+}
+
+// Eye returns the position of the camera.
+func (orbit *Orbit) Eye() vmath.Vec3 {
+	rotation := vmath.QuatEuler(orbit.Pitch, orbit.Yaw, 0)
+	return orbit.Target.Add(rotation.Rotate(vmath.Vec3{0, 0, orbit.Distance}))
+}
+
+// Rotate rotates the camera around the target by dx and dy pixels of mouse motion.
+func (orbit *Orbit) Rotate(dx, dy float32) {
+	orbit.Yaw -= dx * orbit.RotateSpeed
+	orbit.Pitch = vmath.Clamp(orbit.Pitch-dy*orbit.RotateSpeed, vmath.Radians(-89), vmath.Radians(89))
+}
+
+// Pan moves the target parallel to the view plane by dx and dy pixels of mouse motion.
+func (orbit *Orbit) Pan(dx, dy float32, height int) {
+	if height > 0 {
+		// world units per pixel at the distance of the target
+		scale := 2 * orbit.Distance * float32(math.Tan(float64(orbit.Fovy)/2)) / float32(height)
+		rotation := vmath.QuatEuler(orbit.Pitch, orbit.Yaw, 0)
+		right := rotation.Rotate(vmath.Vec3{1, 0, 0})
+		up := rotation.Rotate(vmath.Vec3{0, 1, 0})
+		orbit.Target = orbit.Target.Sub(right.Scale(dx * scale)).Add(up.Scale(dy * scale))
+	}
+}
+
+// Zoom changes the distance to target by steps (e.g. scroll offset).
+func (orbit *Orbit) Zoom(steps float32) {
+	distance := orbit.Distance * float32(math.Pow(float64(orbit.ZoomSpeed), float64(-steps)))
+	orbit.Distance = vmath.Clamp(distance, orbit.MinDistance, orbit.MaxDistance)
+}
+
+// HandleMouse rotates with left mouse button, pans with right mouse button and zooms with
+// the mouse wheel.
+func (orbit *Orbit) HandleMouse(window *glfw.Window, mouse *input.Mouse) {
+	if mouse.ButtonDown(glfw.MouseButtonLeft) {
+		orbit.Rotate(float32(mouse.DeltaX), float32(mouse.DeltaY))
+	}
+	if mouse.ButtonDown(glfw.MouseButtonRight) {
+		_, height := window.GetSize()
+		orbit.Pan(float32(mouse.DeltaX), float32(mouse.DeltaY), height)
+	}
+	orbit.Zoom(float32(mouse.ScrollY))
+}
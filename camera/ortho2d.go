@@ -0,0 +1,89 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package camera
+
+import (
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/vbsw/opengl-go-example/input"
+	"github.com/vbsw/opengl-go-example/vmath"
+	"math"
+)
+
+// Ortho2D is a 2D camera with pan and zoom. One world unit is one pixel at zoom 1,
+// y points up.
+type Ortho2D struct {
+	// world position at the center of the window
+	Center vmath.Vec2
+	Zoom   float32
+	// window size in screen coordinates
+	Width, Height int
+	MinZoom       float32
+	MaxZoom       float32
+	// zoom factor per scroll step
+	ZoomSpeed float32
+}
+
+// NewOrtho2D returns a new instance of Ortho2D with the world origin at the center of the window.
+func NewOrtho2D(width, height int) *Ortho2D {
+	ortho := new(Ortho2D)
+	ortho.Zoom = 1
+	ortho.MinZoom = 0.01
+	ortho.MaxZoom = 100
+	ortho.ZoomSpeed = 1.1
+	ortho.Resize(width, height)
+	return ortho
+}
+
+// View returns the view matrix.
+func (ortho *Ortho2D) View() vmath.Mat4 {
+	scale := vmath.Scale(vmath.Vec3{ortho.Zoom, ortho.Zoom, 1})
+	return scale.Mul(vmath.Translate(vmath.Vec3{-ortho.Center[0], -ortho.Center[1], 0}))
+}
+
+// Projection returns the projection matrix.
+func (ortho *Ortho2D) Projection() vmath.Mat4 {
+	halfWidth := float32(ortho.Width) / 2
+	halfHeight := float32(ortho.Height) / 2
+	return vmath.Ortho2D(-halfWidth, halfWidth, -halfHeight, halfHeight)
+}
+
+// Resize sets the window size.
+func (ortho *Ortho2D) Resize(width, height int) {
+	if width > 0 && height > 0 {
+		ortho.Width, ortho.Height = width, height
+	}
+}
+
+// ScreenToWorld returns the world position at window coordinates x and y.
+func (ortho *Ortho2D) ScreenToWorld(x, y float32) vmath.Vec2 {
+	offset := vmath.Vec2{x - float32(ortho.Width)/2, float32(ortho.Height)/2 - y}
+	return ortho.Center.Add(offset.Scale(1 / ortho.Zoom))
+}
+
+// Pan moves the view by dx and dy pixels of mouse motion.
+func (ortho *Ortho2D) Pan(dx, dy float32) {
+	ortho.Center = ortho.Center.Add(vmath.Vec2{-dx, dy}.Scale(1 / ortho.Zoom))
+}
+
+// ZoomAt zooms by steps (e.g. scroll offset) keeping the world position at window
+// coordinates x and y fixed.
+func (ortho *Ortho2D) ZoomAt(steps, x, y float32) {
+	before := ortho.ScreenToWorld(x, y)
+	zoom := ortho.Zoom * float32(math.Pow(float64(ortho.ZoomSpeed), float64(steps)))
+	ortho.Zoom = vmath.Clamp(zoom, ortho.MinZoom, ortho.MaxZoom)
+	after := ortho.ScreenToWorld(x, y)
+	ortho.Center = ortho.Center.Add(before.Sub(after))
+}
+
+// HandleMouse pans with left or right mouse button and zooms at the cursor with the mouse wheel.
+func (ortho *Ortho2D) HandleMouse(window *glfw.Window, mouse *input.Mouse) {
+	if mouse.ButtonDown(glfw.MouseButtonLeft) || mouse.ButtonDown(glfw.MouseButtonRight) {
+		ortho.Pan(float32(mouse.DeltaX), float32(mouse.DeltaY))
+	}
+	if mouse.ScrollY != 0 {
+		ortho.ZoomAt(float32(mouse.ScrollY), float32(mouse.X), float32(mouse.Y))
+	}
+}
@@ -0,0 +1,112 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+// Atlaspack packs PNG images into texture atlas pages. It writes the pages as PNG
+// files and the regions of the images as JSON file, which can be read with atlas.Load.
+//
+//	$ atlaspack -o sprites.json -size 512 images/*.png
+//
+// Directories are searched for PNG files (not recursively). Images are named by
+// their file name without extension.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/vbsw/opengl-go-example/atlas"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var output = flag.String("o", "atlas.json", "output JSON file, pages are written next to it")
+var size = flag.Int("size", 1024, "page width and height in pixels")
+var padding = flag.Int("padding", 2, "transparent pixels between images")
+var extrude = flag.Int("extrude", 1, "pixels the image borders are repeated")
+var method = flag.String("method", "maxrects", "packing method (maxrects or skyline)")
+
+func main() {
+	flag.Parse()
+	options := atlas.Options{Width: *size, Height: *size, Padding: *padding, Extrude: *extrude}
+	images, err := readImages(flag.Args())
+
+	if err == nil {
+		options.Method, err = parseMethod(*method)
+
+		if err == nil {
+			var packed *atlas.Atlas
+			packed, err = atlas.Build(images, options)
+
+			if err == nil {
+				err = packed.Save(*output)
+
+				if err == nil {
+					fmt.Printf("packed %d images into %d pages\n", len(images), len(packed.Pages))
+				}
+			}
+		}
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
+
+func parseMethod(name string) (atlas.Method, error) {
+	switch name {
+	case "maxrects":
+		return atlas.MaxRects, nil
+	case "skyline":
+		return atlas.Skyline, nil
+	}
+	return atlas.MaxRects, errors.New("unknown packing method " + name)
+}
+
+func readImages(paths []string) ([]atlas.Image, error) {
+	var images []atlas.Image
+	var err error
+	if len(paths) == 0 {
+		err = errors.New("no images specified")
+	}
+	for i := 0; i < len(paths) && err == nil; i++ {
+		var info os.FileInfo
+		info, err = os.Stat(paths[i])
+
+		if err == nil {
+			if info.IsDir() {
+				var infos []os.FileInfo
+				infos, err = ioutil.ReadDir(paths[i])
+				for j := 0; j < len(infos) && err == nil; j++ {
+					if !infos[j].IsDir() && strings.EqualFold(filepath.Ext(infos[j].Name()), ".png") {
+						images, err = appendImage(images, filepath.Join(paths[i], infos[j].Name()))
+					}
+				}
+			} else {
+				images, err = appendImage(images, paths[i])
+			}
+		}
+	}
+	return images, err
+}
+
+func appendImage(images []atlas.Image, path string) ([]atlas.Image, error) {
+	file, err := os.Open(path)
+	if err == nil {
+		var img image.Image
+		defer file.Close()
+		img, err = png.Decode(file)
+		if err == nil {
+			name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+			images = append(images, atlas.Image{Name: name, Image: img})
+		} else {
+			err = errors.New(path + ": " + err.Error())
+		}
+	}
+	return images, err
+}
@@ -0,0 +1,293 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package gfx
+
+import (
+	"fmt"
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// bits of StateCache.known
+const (
+	knownProgram = 1 << iota
+	knownVertexArray
+	knownArrayBuffer
+	knownElementBuffer
+	knownActiveTexture
+	knownBlend
+	knownBlendFunc
+	knownDepthTest
+	knownDepthWrite
+	knownDepthFunc
+	knownCull
+	knownCullFace
+	knownFrontFace
+	knownViewport
+)
+
+// StateCache shadows OpenGL state and skips calls that would not change it. The
+// state is unknown initially and after Invalidate, so the first call of each kind
+// always reaches OpenGL. State changed by other code (e.g. Mesh.Draw or deleting
+// bound objects) requires Invalidate.
+type StateCache struct {
+	// if false, all calls are passed on (for comparison), but still counted
+	Enabled bool
+	// statistics of the previous frame
+	Last CacheStats
+	// statistics of the current frame
+	Current CacheStats
+	calls   *glCalls
+	known   uint32
+	program uint32
+	vao     uint32
+	buffers [2]uint32
+	// element buffers of vertex arrays (the binding is part of the vertex array)
+	elements   map[uint32]uint32
+	unit       uint32
+	textures   map[textureBinding]uint32
+	blend      bool
+	blendFunc  [2]uint32
+	depthTest  bool
+	depthWrite bool
+	depthFunc  uint32
+	cull       bool
+	cullFace   uint32
+	frontFace  uint32
+	viewport   [4]int32
+}
+
+// CacheStats are the counts of a frame.
+type CacheStats struct {
+	DrawCalls int
+	// calls that changed state
+	StateChanges int
+	// redundant calls skipped
+	Skipped int
+}
+
+type textureBinding struct {
+	unit, target uint32
+}
+
+// glCalls are the OpenGL functions used by StateCache (replaced in tests).
+type glCalls struct {
+	useProgram      func(program uint32)
+	bindVertexArray func(array uint32)
+	bindBuffer      func(target, buffer uint32)
+	activeTexture   func(texture uint32)
+	bindTexture     func(target, texture uint32)
+	enable          func(capability uint32)
+	disable         func(capability uint32)
+	blendFunc       func(src, dst uint32)
+	depthMask       func(flag bool)
+	depthFunc       func(function uint32)
+	cullFace        func(mode uint32)
+	frontFace       func(mode uint32)
+	viewport        func(x, y, width, height int32)
+	drawArrays      func(mode uint32, first, count int32)
+	drawElements    func(mode uint32, count int32, indexType uint32, offset int)
+}
+
+var defaultCalls = glCalls{
+	useProgram:      gl.UseProgram,
+	bindVertexArray: gl.BindVertexArray,
+	bindBuffer:      gl.BindBuffer,
+	activeTexture:   gl.ActiveTexture,
+	bindTexture:     gl.BindTexture,
+	enable:          gl.Enable,
+	disable:         gl.Disable,
+	blendFunc:       gl.BlendFunc,
+	depthMask:       gl.DepthMask,
+	depthFunc:       gl.DepthFunc,
+	cullFace:        gl.CullFace,
+	frontFace:       gl.FrontFace,
+	viewport:        gl.Viewport,
+	drawArrays:      gl.DrawArrays,
+	drawElements: func(mode uint32, count int32, indexType uint32, offset int) {
+		gl.DrawElements(mode, count, indexType, gl.PtrOffset(offset))
+	},
+}
+
+// NewStateCache returns an enabled cache with unknown state.
+func NewStateCache() *StateCache {
+	return newStateCache(&defaultCalls)
+}
+
+func newStateCache(calls *glCalls) *StateCache {
+	cache := &StateCache{Enabled: true, calls: calls}
+	cache.Invalidate()
+	return cache
+}
+
+// Invalidate forgets the shadowed state.
+func (cache *StateCache) Invalidate() {
+	cache.known = 0
+	cache.elements = make(map[uint32]uint32)
+	cache.textures = make(map[textureBinding]uint32)
+}
+
+// EndFrame stores the statistics of the current frame in Last, resets them and
+// returns them.
+func (cache *StateCache) EndFrame() CacheStats {
+	cache.Last, cache.Current = cache.Current, CacheStats{}
+	return cache.Last
+}
+
+// UseProgram makes program current.
+func (cache *StateCache) UseProgram(program uint32) {
+	if cache.change(knownProgram, cache.program == program) {
+		cache.program = program
+		cache.calls.useProgram(program)
+	}
+}
+
+// BindVertexArray binds vertex array vao. The element buffer bound with it before is
+// known.
+func (cache *StateCache) BindVertexArray(vao uint32) {
+	if cache.change(knownVertexArray, cache.vao == vao) {
+		cache.vao = vao
+		cache.calls.bindVertexArray(vao)
+		cache.buffers[1], cache.known = 0, cache.known&^knownElementBuffer
+		if buffer, ok := cache.elements[vao]; ok {
+			cache.buffers[1], cache.known = buffer, cache.known|knownElementBuffer
+		}
+	}
+}
+
+// BindBuffer binds buffer to gl.ARRAY_BUFFER or gl.ELEMENT_ARRAY_BUFFER. Other
+// targets are passed on.
+func (cache *StateCache) BindBuffer(target, buffer uint32) {
+	switch target {
+	case gl.ARRAY_BUFFER:
+		if cache.change(knownArrayBuffer, cache.buffers[0] == buffer) {
+			cache.buffers[0] = buffer
+			cache.calls.bindBuffer(target, buffer)
+		}
+	case gl.ELEMENT_ARRAY_BUFFER:
+		if cache.change(knownElementBuffer, cache.buffers[1] == buffer) {
+			cache.buffers[1] = buffer
+			cache.calls.bindBuffer(target, buffer)
+			if cache.known&knownVertexArray != 0 {
+				cache.elements[cache.vao] = buffer
+			}
+		}
+	default:
+		cache.Current.StateChanges++
+		cache.calls.bindBuffer(target, buffer)
+	}
+}
+
+// ActiveTexture selects texture unit (0, 1, 2 etc., not gl.TEXTURE0).
+func (cache *StateCache) ActiveTexture(unit uint32) {
+	if cache.change(knownActiveTexture, cache.unit == unit) {
+		cache.unit = unit
+		cache.calls.activeTexture(gl.TEXTURE0 + unit)
+	}
+}
+
+// BindTexture binds texture to target of texture unit. The unit is activated, if
+// necessary.
+func (cache *StateCache) BindTexture(unit, target, texture uint32) {
+	binding := textureBinding{unit, target}
+	bound, ok := cache.textures[binding]
+	if cache.change(0, ok && bound == texture) {
+		cache.ActiveTexture(unit)
+		cache.textures[binding] = texture
+		cache.calls.bindTexture(target, texture)
+	}
+}
+
+// SetBlend enables or disables blending with factors src and dst (e.g. gl.SRC_ALPHA
+// and gl.ONE_MINUS_SRC_ALPHA). The factors are ignored, if blending is disabled.
+func (cache *StateCache) SetBlend(enabled bool, src, dst uint32) {
+	cache.setCapability(knownBlend, gl.BLEND, &cache.blend, enabled)
+	if enabled && cache.change(knownBlendFunc, cache.blendFunc == [2]uint32{src, dst}) {
+		cache.blendFunc = [2]uint32{src, dst}
+		cache.calls.blendFunc(src, dst)
+	}
+}
+
+// SetState sets depth test, depth writes and face culling. Depth function and
+// culled faces are ignored, if disabled.
+func (cache *StateCache) SetState(state RenderState) {
+	cache.setCapability(knownDepthTest, gl.DEPTH_TEST, &cache.depthTest, state.DepthTest)
+	if state.DepthTest && cache.change(knownDepthFunc, cache.depthFunc == state.DepthFunc) {
+		cache.depthFunc = state.DepthFunc
+		cache.calls.depthFunc(state.DepthFunc)
+	}
+	if cache.change(knownDepthWrite, cache.depthWrite == state.DepthWrite) {
+		cache.depthWrite = state.DepthWrite
+		cache.calls.depthMask(state.DepthWrite)
+	}
+	cache.setCapability(knownCull, gl.CULL_FACE, &cache.cull, state.Cull)
+	if state.Cull && cache.change(knownCullFace, cache.cullFace == state.CullFace) {
+		cache.cullFace = state.CullFace
+		cache.calls.cullFace(state.CullFace)
+	}
+	if cache.change(knownFrontFace, cache.frontFace == state.FrontFace) {
+		cache.frontFace = state.FrontFace
+		cache.calls.frontFace(state.FrontFace)
+	}
+}
+
+// Viewport sets the viewport.
+func (cache *StateCache) Viewport(x, y, width, height int32) {
+	viewport := [4]int32{x, y, width, height}
+	if cache.change(knownViewport, cache.viewport == viewport) {
+		cache.viewport = viewport
+		cache.calls.viewport(x, y, width, height)
+	}
+}
+
+// DrawArrays draws count vertices from first with the current state.
+func (cache *StateCache) DrawArrays(mode uint32, first, count int32) {
+	cache.Current.DrawCalls++
+	cache.calls.drawArrays(mode, first, count)
+}
+
+// DrawElements draws count indices from byte offset of the bound element buffer.
+func (cache *StateCache) DrawElements(mode uint32, count int32, indexType uint32, offset int) {
+	cache.Current.DrawCalls++
+	cache.calls.drawElements(mode, count, indexType, offset)
+}
+
+// CountDraw counts a draw call made without the cache.
+func (cache *StateCache) CountDraw() {
+	cache.Current.DrawCalls++
+}
+
+// CountStateChanges counts n state changes made without the cache.
+func (cache *StateCache) CountStateChanges(n int) {
+	cache.Current.StateChanges += n
+}
+
+// String returns the statistics in short form.
+func (stats CacheStats) String() string {
+	return fmt.Sprintf("%d draws, %d state changes, %d skipped", stats.DrawCalls, stats.StateChanges, stats.Skipped)
+}
+
+// change returns true, if the call must be made, i.e. the cache is disabled, the
+// state is unknown (flag not in known) or not same. It counts the call.
+func (cache *StateCache) change(flag uint32, same bool) bool {
+	if cache.Enabled && same && cache.known&flag == flag {
+		cache.Current.Skipped++
+		return false
+	}
+	cache.known |= flag
+	cache.Current.StateChanges++
+	return true
+}
+
+func (cache *StateCache) setCapability(flag, capability uint32, current *bool, enabled bool) {
+	if cache.change(flag, *current == enabled) {
+		*current = enabled
+		if enabled {
+			cache.calls.enable(capability)
+		} else {
+			cache.calls.disable(capability)
+		}
+	}
+}
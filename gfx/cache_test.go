@@ -0,0 +1,145 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package gfx
+
+import (
+	"fmt"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"strings"
+	"testing"
+)
+
+// newTestCache returns a cache, that records the OpenGL calls in log.
+func newTestCache(log *[]string) *StateCache {
+	record := func(format string, args ...interface{}) {
+		*log = append(*log, fmt.Sprintf(format, args...))
+	}
+	calls := &glCalls{
+		useProgram:      func(program uint32) { record("program %d", program) },
+		bindVertexArray: func(array uint32) { record("vao %d", array) },
+		bindBuffer:      func(target, buffer uint32) { record("buffer %x %d", target, buffer) },
+		activeTexture:   func(texture uint32) { record("unit %d", texture-gl.TEXTURE0) },
+		bindTexture:     func(target, texture uint32) { record("texture %d", texture) },
+		enable:          func(capability uint32) { record("enable %x", capability) },
+		disable:         func(capability uint32) { record("disable %x", capability) },
+		blendFunc:       func(src, dst uint32) { record("blend %x %x", src, dst) },
+		depthMask:       func(flag bool) { record("depthmask %v", flag) },
+		depthFunc:       func(function uint32) { record("depthfunc %x", function) },
+		cullFace:        func(mode uint32) { record("cullface %x", mode) },
+		frontFace:       func(mode uint32) { record("frontface %x", mode) },
+		viewport:        func(x, y, width, height int32) { record("viewport %d %d", width, height) },
+		drawArrays:      func(mode uint32, first, count int32) { record("draw %d", count) },
+		drawElements:    func(mode uint32, count int32, indexType uint32, offset int) { record("draw %d", count) },
+	}
+	return newStateCache(calls)
+}
+
+// frame is the render loop of the texture3 example.
+func frame(cache *StateCache) {
+	cache.UseProgram(1)
+	cache.BindVertexArray(1)
+	cache.DrawArrays(gl.TRIANGLES, 0, 3)
+	cache.UseProgram(2)
+	cache.BindVertexArray(2)
+	cache.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 5)
+	cache.BindTexture(0, gl.TEXTURE_2D, 7)
+	cache.DrawElements(gl.TRIANGLES, 6, gl.UNSIGNED_INT, 0)
+}
+
+func TestStateCacheFrames(t *testing.T) {
+	var log []string
+	cache := newTestCache(&log)
+	frame(cache)
+	first := cache.EndFrame()
+	if first.DrawCalls != 2 || first.Skipped != 0 || first.StateChanges != 7 {
+		t.Errorf("first frame: %v (%s)", first, strings.Join(log, ", "))
+	}
+	log = log[:0]
+	frame(cache)
+	second := cache.EndFrame()
+	// program and vertex array change twice per frame, EBO and texture are known
+	want := "program 1, vao 1, draw 3, program 2, vao 2, draw 6"
+	if got := strings.Join(log, ", "); got != want {
+		t.Errorf("second frame: got %q, want %q", got, want)
+	}
+	if second.DrawCalls != 2 || second.StateChanges != 4 || second.Skipped != 2 {
+		t.Errorf("second frame: %v", second)
+	}
+	if cache.Last != second || cache.Current != (CacheStats{}) {
+		t.Errorf("EndFrame: last %v, current %v", cache.Last, cache.Current)
+	}
+
+	cache.Enabled = false
+	log = log[:0]
+	frame(cache)
+	if disabled := cache.EndFrame(); disabled.Skipped != 0 || len(log) != 9 {
+		t.Errorf("disabled: %v (%s)", disabled, strings.Join(log, ", "))
+	}
+}
+
+func TestStateCacheSkips(t *testing.T) {
+	tests := []struct {
+		name  string
+		calls func(cache *StateCache)
+		want  string
+	}{
+		{"program", func(c *StateCache) { c.UseProgram(3); c.UseProgram(3); c.UseProgram(4) }, "program 3, program 4"},
+		{"invalidate", func(c *StateCache) { c.UseProgram(3); c.Invalidate(); c.UseProgram(3) }, "program 3, program 3"},
+		{"texture units", func(c *StateCache) {
+			c.BindTexture(0, gl.TEXTURE_2D, 1)
+			c.BindTexture(1, gl.TEXTURE_2D, 2)
+			c.BindTexture(0, gl.TEXTURE_2D, 1)
+			c.BindTexture(1, gl.TEXTURE_2D, 3)
+		}, "unit 0, texture 1, unit 1, texture 2, texture 3"},
+		{"element buffer of vao", func(c *StateCache) {
+			c.BindVertexArray(1)
+			c.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 5)
+			c.BindVertexArray(2)
+			c.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 5)
+			c.BindVertexArray(1)
+			c.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 5)
+		}, "vao 1, buffer 8893 5, vao 2, buffer 8893 5, vao 1"},
+		{"array buffer", func(c *StateCache) {
+			c.BindBuffer(gl.ARRAY_BUFFER, 1)
+			c.BindVertexArray(1)
+			c.BindBuffer(gl.ARRAY_BUFFER, 1)
+		}, "buffer 8892 1, vao 1"},
+		{"blend", func(c *StateCache) {
+			c.SetBlend(true, gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+			c.SetBlend(false, gl.ONE, gl.ONE)
+			c.SetBlend(true, gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+		}, "enable be2, blend 302 303, disable be2, enable be2"},
+		{"render state", func(c *StateCache) {
+			c.SetState(Opaque3D())
+			c.SetState(Opaque3D())
+			c.SetState(Flat2D())
+		}, "enable b71, depthfunc 201, depthmask true, enable b44, cullface 405, frontface 901, disable b71, depthmask false, disable b44"},
+		{"viewport", func(c *StateCache) {
+			c.Viewport(0, 0, 800, 600)
+			c.Viewport(0, 0, 800, 600)
+			c.Viewport(0, 0, 400, 300)
+		}, "viewport 800 600, viewport 400 300"},
+	}
+	for _, test := range tests {
+		var log []string
+		test.calls(newTestCache(&log))
+		if got := strings.Join(log, ", "); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func BenchmarkStateCache(b *testing.B) {
+	var log []string
+	cache := newTestCache(&log)
+	cache.calls.drawArrays = func(mode uint32, first, count int32) {}
+	cache.calls.drawElements = func(mode uint32, count int32, indexType uint32, offset int) {}
+	for i := 0; i < b.N; i++ {
+		frame(cache)
+		cache.EndFrame()
+		log = log[:0]
+	}
+}
@@ -0,0 +1,289 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package gfx
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// DepthMode is the kind of depth (and stencil) attachment of a framebuffer.
+type DepthMode int
+
+// Depth modes.
+const (
+	// DepthNone has no depth attachment.
+	DepthNone DepthMode = iota
+	// DepthRenderbuffer is a 24 bit depth and 8 bit stencil renderbuffer, that can't
+	// be sampled, but blitted.
+	DepthRenderbuffer
+	// DepthTexture is a 24 bit depth and 8 bit stencil texture, that can be sampled.
+	DepthTexture
+	// DepthOnlyTexture is a 32 bit float depth texture without stencil (e.g. for
+	// shadow maps).
+	DepthOnlyTexture
+)
+
+// FramebufferConfig describes the attachments of a framebuffer.
+type FramebufferConfig struct {
+	Width, Height int
+	// internal formats of the color textures (e.g. gl.RGBA8 or gl.RGBA16F)
+	Colors []int32
+	Depth  DepthMode
+	// filter of the color textures (default gl.LINEAR)
+	Filter int32
+	// number of samples per pixel; with more than 1 the color attachments are
+	// multisample renderbuffers, which must be resolved with Resolve or Blit
+	Samples int
+}
+
+// Framebuffer is a framebuffer object with color textures and an optional depth
+// attachment.
+type Framebuffer struct {
+	ID     uint32
+	Config FramebufferConfig
+	// color textures in order of Config.Colors (gl.COLOR_ATTACHMENT0 etc.)
+	Textures []uint32
+	// color renderbuffers instead of textures, if multisampled
+	Renderbuffers     []uint32
+	DepthTexture      uint32
+	DepthRenderbuffer uint32
+}
+
+// NewFramebuffer returns a new framebuffer with the attachments of config or an
+// error, if the framebuffer is not complete. Config.Samples must not exceed
+// MaxSamples.
+func NewFramebuffer(config FramebufferConfig) (*Framebuffer, error) {
+	framebuffer := &Framebuffer{Config: config}
+	if framebuffer.Config.Filter == 0 {
+		framebuffer.Config.Filter = gl.LINEAR
+	}
+	gl.GenFramebuffers(1, &framebuffer.ID)
+	err := framebuffer.attach()
+	if err != nil {
+		framebuffer.Delete()
+		return nil, err
+	}
+	return framebuffer, nil
+}
+
+// Width returns the width in pixels.
+func (framebuffer *Framebuffer) Width() int {
+	return framebuffer.Config.Width
+}
+
+// Height returns the height in pixels.
+func (framebuffer *Framebuffer) Height() int {
+	return framebuffer.Config.Height
+}
+
+// Multisampled returns true, if the framebuffer has more than one sample per pixel.
+func (framebuffer *Framebuffer) Multisampled() bool {
+	return framebuffer.Config.Samples > 1
+}
+
+// Texture returns the color texture of attachment index. Multisampled framebuffers
+// have no textures.
+func (framebuffer *Framebuffer) Texture(index int) uint32 {
+	return framebuffer.Textures[index]
+}
+
+// Bind binds the framebuffer for drawing and sets the viewport to its size.
+func (framebuffer *Framebuffer) Bind() {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, framebuffer.ID)
+	gl.Viewport(0, 0, int32(framebuffer.Config.Width), int32(framebuffer.Config.Height))
+}
+
+// BindDefault binds the default framebuffer (window) and sets the viewport to width
+// x height.
+func BindDefault(width, height int) {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.Viewport(0, 0, int32(width), int32(height))
+}
+
+// Resize recreates the attachments with a new size. The content is lost. It is meant
+// to be called from the framebuffer size callback of the window (not the window
+// size callback, the sizes differ on high DPI displays).
+func (framebuffer *Framebuffer) Resize(width, height int) error {
+	if width == framebuffer.Config.Width && height == framebuffer.Config.Height {
+		return nil
+	}
+	framebuffer.deleteAttachments()
+	framebuffer.Config.Width, framebuffer.Config.Height = width, height
+	return framebuffer.attach()
+}
+
+// Blit copies the buffers in mask (e.g. gl.COLOR_BUFFER_BIT) of the first color
+// attachment to target. If target is nil, the default framebuffer with size width x
+// height is the target. Filter must be gl.NEAREST for depth and stencil.
+func (framebuffer *Framebuffer) Blit(target *Framebuffer, width, height int, mask uint32, filter uint32) {
+	var targetID uint32
+	if target != nil {
+		targetID = target.ID
+		width, height = target.Config.Width, target.Config.Height
+	}
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, framebuffer.ID)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, targetID)
+	if len(framebuffer.Config.Colors) > 0 {
+		gl.ReadBuffer(gl.COLOR_ATTACHMENT0)
+	}
+	srcWidth, srcHeight := int32(framebuffer.Config.Width), int32(framebuffer.Config.Height)
+	gl.BlitFramebuffer(0, 0, srcWidth, srcHeight, 0, 0, int32(width), int32(height), mask, filter)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+}
+
+// Resolve copies the first color attachment of a multisampled framebuffer to target
+// of the same size. If target is nil, the default framebuffer of size width x height
+// is the target, which must not be multisampled itself (glfw.Samples).
+func (framebuffer *Framebuffer) Resolve(target *Framebuffer, width, height int) {
+	framebuffer.Blit(target, width, height, gl.COLOR_BUFFER_BIT, gl.NEAREST)
+}
+
+// BlitToScreen copies the first color attachment to the default framebuffer of size
+// width x height with linear filtering.
+func (framebuffer *Framebuffer) BlitToScreen(width, height int) {
+	framebuffer.Blit(nil, width, height, gl.COLOR_BUFFER_BIT, gl.LINEAR)
+}
+
+// Delete deletes the framebuffer and its attachments.
+func (framebuffer *Framebuffer) Delete() {
+	framebuffer.deleteAttachments()
+	gl.DeleteFramebuffers(1, &framebuffer.ID)
+}
+
+func (framebuffer *Framebuffer) attach() error {
+	config := &framebuffer.Config
+	width, height := int32(config.Width), int32(config.Height)
+	samples := int32(config.Samples)
+	if samples > 1 && config.Depth != DepthNone && config.Depth != DepthRenderbuffer {
+		return errors.New("framebuffer: multisampled depth must be a renderbuffer")
+	}
+	gl.BindFramebuffer(gl.FRAMEBUFFER, framebuffer.ID)
+	drawBuffers := make([]uint32, len(config.Colors))
+	for i, internalFormat := range config.Colors {
+		drawBuffers[i] = gl.COLOR_ATTACHMENT0 + uint32(i)
+		if samples > 1 {
+			renderbuffer := newAttachmentRenderbuffer(width, height, uint32(internalFormat), samples)
+			framebuffer.Renderbuffers = append(framebuffer.Renderbuffers, renderbuffer)
+			gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, drawBuffers[i], gl.RENDERBUFFER, renderbuffer)
+		} else {
+			format, dataType := textureFormat(internalFormat)
+			texture := newAttachmentTexture(width, height, internalFormat, format, dataType, config.Filter)
+			framebuffer.Textures = append(framebuffer.Textures, texture)
+			gl.FramebufferTexture2D(gl.FRAMEBUFFER, drawBuffers[i], gl.TEXTURE_2D, texture, 0)
+		}
+	}
+	switch config.Depth {
+	case DepthRenderbuffer:
+		framebuffer.DepthRenderbuffer = newAttachmentRenderbuffer(width, height, gl.DEPTH24_STENCIL8, samples)
+		gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_STENCIL_ATTACHMENT, gl.RENDERBUFFER, framebuffer.DepthRenderbuffer)
+	case DepthTexture:
+		framebuffer.DepthTexture = newAttachmentTexture(width, height, gl.DEPTH24_STENCIL8, gl.DEPTH_STENCIL, gl.UNSIGNED_INT_24_8, gl.NEAREST)
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.DEPTH_STENCIL_ATTACHMENT, gl.TEXTURE_2D, framebuffer.DepthTexture, 0)
+	case DepthOnlyTexture:
+		framebuffer.DepthTexture = newAttachmentTexture(width, height, gl.DEPTH_COMPONENT32F, gl.DEPTH_COMPONENT, gl.FLOAT, gl.NEAREST)
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.TEXTURE_2D, framebuffer.DepthTexture, 0)
+	}
+	if len(drawBuffers) > 0 {
+		gl.DrawBuffers(int32(len(drawBuffers)), &drawBuffers[0])
+	} else {
+		// depth only
+		gl.DrawBuffer(gl.NONE)
+		gl.ReadBuffer(gl.NONE)
+	}
+	err := checkFramebuffer(gl.CheckFramebufferStatus(gl.FRAMEBUFFER))
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	return err
+}
+
+func (framebuffer *Framebuffer) deleteAttachments() {
+	if len(framebuffer.Textures) > 0 {
+		gl.DeleteTextures(int32(len(framebuffer.Textures)), &framebuffer.Textures[0])
+		framebuffer.Textures = nil
+	}
+	if len(framebuffer.Renderbuffers) > 0 {
+		gl.DeleteRenderbuffers(int32(len(framebuffer.Renderbuffers)), &framebuffer.Renderbuffers[0])
+		framebuffer.Renderbuffers = nil
+	}
+	if framebuffer.DepthTexture != 0 {
+		gl.DeleteTextures(1, &framebuffer.DepthTexture)
+		framebuffer.DepthTexture = 0
+	}
+	if framebuffer.DepthRenderbuffer != 0 {
+		gl.DeleteRenderbuffers(1, &framebuffer.DepthRenderbuffer)
+		framebuffer.DepthRenderbuffer = 0
+	}
+}
+
+func newAttachmentTexture(width, height, internalFormat int32, format, dataType uint32, filter int32) uint32 {
+	var texture uint32
+	gl.GenTextures(1, &texture)
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, internalFormat, width, height, 0, format, dataType, nil)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, filter)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, filter)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	return texture
+}
+
+func newAttachmentRenderbuffer(width, height int32, internalFormat uint32, samples int32) uint32 {
+	var renderbuffer uint32
+	gl.GenRenderbuffers(1, &renderbuffer)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, renderbuffer)
+	if samples > 1 {
+		gl.RenderbufferStorageMultisample(gl.RENDERBUFFER, samples, internalFormat, width, height)
+	} else {
+		gl.RenderbufferStorage(gl.RENDERBUFFER, internalFormat, width, height)
+	}
+	gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+	return renderbuffer
+}
+
+// textureFormat returns format and type of pixel data matching internalFormat.
+func textureFormat(internalFormat int32) (uint32, uint32) {
+	switch internalFormat {
+	case gl.R8:
+		return gl.RED, gl.UNSIGNED_BYTE
+	case gl.R16F, gl.R32F:
+		return gl.RED, gl.FLOAT
+	case gl.RG8:
+		return gl.RG, gl.UNSIGNED_BYTE
+	case gl.RG16F, gl.RG32F:
+		return gl.RG, gl.FLOAT
+	case gl.RGB8, gl.SRGB8:
+		return gl.RGB, gl.UNSIGNED_BYTE
+	case gl.RGB16F, gl.RGB32F, gl.R11F_G11F_B10F:
+		return gl.RGB, gl.FLOAT
+	case gl.RGBA16F, gl.RGBA32F:
+		return gl.RGBA, gl.FLOAT
+	}
+	return gl.RGBA, gl.UNSIGNED_BYTE
+}
+
+func checkFramebuffer(status uint32) error {
+	switch status {
+	case gl.FRAMEBUFFER_COMPLETE:
+		return nil
+	case gl.FRAMEBUFFER_INCOMPLETE_ATTACHMENT:
+		return errors.New("framebuffer incomplete: attachment")
+	case gl.FRAMEBUFFER_INCOMPLETE_MISSING_ATTACHMENT:
+		return errors.New("framebuffer incomplete: missing attachment")
+	case gl.FRAMEBUFFER_INCOMPLETE_DRAW_BUFFER:
+		return errors.New("framebuffer incomplete: draw buffer")
+	case gl.FRAMEBUFFER_INCOMPLETE_READ_BUFFER:
+		return errors.New("framebuffer incomplete: read buffer")
+	case gl.FRAMEBUFFER_UNSUPPORTED:
+		return errors.New("framebuffer unsupported: combination of formats")
+	case gl.FRAMEBUFFER_INCOMPLETE_MULTISAMPLE:
+		return errors.New("framebuffer incomplete: multisample")
+	case gl.FRAMEBUFFER_UNDEFINED:
+		return errors.New("framebuffer undefined")
+	}
+	return fmt.Errorf("framebuffer incomplete: status 0x%x", status)
+}
@@ -0,0 +1,47 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package gfx
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// FullscreenVertexShader generates a triangle covering the viewport from
+// gl_VertexID. Texture coordinates are passed to the fragment shader as texCoords.
+const FullscreenVertexShader = `#version 130
+
+out vec2 texCoords;
+
+void main() {
+	texCoords = vec2((gl_VertexID << 1) & 2, gl_VertexID & 2);
+	gl_Position = vec4(texCoords * 2.0 - 1.0, 0.0, 1.0);
+}
+`
+
+// FullscreenTriangle draws one triangle covering the viewport. It has no vertex
+// buffer, but the core profile requires a vertex array object to be bound.
+type FullscreenTriangle struct {
+	VAO uint32
+}
+
+// NewFullscreenTriangle returns a new FullscreenTriangle.
+func NewFullscreenTriangle() *FullscreenTriangle {
+	triangle := new(FullscreenTriangle)
+	gl.GenVertexArrays(1, &triangle.VAO)
+	return triangle
+}
+
+// Draw draws the triangle with the current program (e.g. with FullscreenVertexShader).
+func (triangle *FullscreenTriangle) Draw() {
+	gl.BindVertexArray(triangle.VAO)
+	gl.DrawArrays(gl.TRIANGLES, 0, 3)
+	gl.BindVertexArray(0)
+}
+
+// Delete deletes the vertex array object.
+func (triangle *FullscreenTriangle) Delete() {
+	gl.DeleteVertexArrays(1, &triangle.VAO)
+}
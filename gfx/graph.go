@@ -0,0 +1,158 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package gfx
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/vbsw/opengl-go-example/stats"
+)
+
+const graphVertexShader = `#version 130
+
+in vec2 positionIn;
+in vec4 colorIn;
+out vec4 fragmentColor;
+
+void main() {
+	gl_Position = vec4(positionIn, 0.0, 1.0);
+	fragmentColor = colorIn;
+}
+`
+
+const graphFragmentShader = `#version 130
+
+in vec4 fragmentColor;
+out vec4 color;
+
+void main() {
+	color = fragmentColor;
+}
+`
+
+// x, y, r, g, b, a
+const graphVertexSize = 6
+
+// FrameGraph draws frame timings as bar graph on top of the scene.
+// Bars show the frame interval (yellow), the swap time (blue) and the CPU time (green).
+// The horizontal lines mark 60 and 30 frames per second.
+type FrameGraph struct {
+	// lower left corner in normalized device coordinates
+	X, Y float32
+	// size in normalized device coordinates
+	Width, Height float32
+	// frame time in seconds, that is shown with full height
+	Scale float64
+	// number of latest frames shown
+	Frames           int
+	program          uint32
+	vao              uint32
+	vbo              uint32
+	positionLocation int32
+	colorLocation    int32
+	vertices         []float32
+}
+
+// NewFrameGraph returns a new instance of FrameGraph in the upper left corner.
+func NewFrameGraph() (*FrameGraph, error) {
+	program, err := NewProgram(graphVertexShader, graphFragmentShader)
+	if err == nil {
+		graph := new(FrameGraph)
+		graph.X, graph.Y = -0.95, 0.55
+		graph.Width, graph.Height = 0.8, 0.4
+		graph.Scale = 1.0 / 20.0
+		graph.Frames = 240
+		graph.program = program
+		graph.positionLocation = AttribLocation(program, "positionIn")
+		graph.colorLocation = AttribLocation(program, "colorIn")
+		gl.GenVertexArrays(1, &graph.vao)
+		gl.GenBuffers(1, &graph.vbo)
+		gl.BindVertexArray(graph.vao)
+		gl.BindBuffer(gl.ARRAY_BUFFER, graph.vbo)
+		gl.EnableVertexAttribArray(uint32(graph.positionLocation))
+		gl.EnableVertexAttribArray(uint32(graph.colorLocation))
+		gl.VertexAttribPointer(uint32(graph.positionLocation), 2, gl.FLOAT, false, graphVertexSize*4, gl.PtrOffset(0))
+		gl.VertexAttribPointer(uint32(graph.colorLocation), 4, gl.FLOAT, false, graphVertexSize*4, gl.PtrOffset(2*4))
+		gl.BindVertexArray(0)
+		gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+		return graph, nil
+	}
+	return nil, err
+}
+
+// Draw draws the bar graph of the latest stored frames. The current program, vertex
+// array and blend state are restored afterwards.
+func (graph *FrameGraph) Draw(frameStats *stats.Stats) {
+	var program, vao, srcRGB, dstRGB, srcAlpha, dstAlpha int32
+	frames := frameStats.Frames()
+	if len(frames) > graph.Frames {
+		frames = frames[len(frames)-graph.Frames:]
+	}
+	barWidth := graph.Width / float32(graph.Frames)
+	graph.vertices = graph.vertices[:0]
+	graph.addQuad(graph.X, graph.Y, graph.Width, graph.Height, 0, 0, 0, 0.5)
+	for i, frame := range frames {
+		x := graph.X + float32(i)*barWidth
+		interval := graph.barHeight(frame.Interval)
+		cpu := graph.barHeight(frame.CPU)
+		swap := graph.barHeight(frame.Swap)
+		graph.addQuad(x, graph.Y, barWidth, interval, 1, 0.8, 0, 0.6)
+		graph.addQuad(x, graph.Y, barWidth, cpu, 0, 0.9, 0, 0.9)
+		graph.addQuad(x, graph.Y+cpu, barWidth, swap, 0.2, 0.4, 1, 0.9)
+	}
+	// 60 and 30 fps
+	graph.addQuad(graph.X, graph.Y+graph.barHeight(1.0/60.0), graph.Width, 0.005, 1, 1, 1, 0.8)
+	graph.addQuad(graph.X, graph.Y+graph.barHeight(1.0/30.0), graph.Width, 0.005, 1, 0.3, 0.3, 0.8)
+
+	gl.GetIntegerv(gl.CURRENT_PROGRAM, &program)
+	gl.GetIntegerv(gl.VERTEX_ARRAY_BINDING, &vao)
+	blend := gl.IsEnabled(gl.BLEND)
+	gl.GetIntegerv(gl.BLEND_SRC_RGB, &srcRGB)
+	gl.GetIntegerv(gl.BLEND_DST_RGB, &dstRGB)
+	gl.GetIntegerv(gl.BLEND_SRC_ALPHA, &srcAlpha)
+	gl.GetIntegerv(gl.BLEND_DST_ALPHA, &dstAlpha)
+
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	gl.UseProgram(graph.program)
+	gl.BindVertexArray(graph.vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, graph.vbo)
+	// orphan the buffer, it is refilled every frame
+	gl.BufferData(gl.ARRAY_BUFFER, len(graph.vertices)*4, gl.Ptr(graph.vertices), gl.STREAM_DRAW)
+	gl.DrawArrays(gl.TRIANGLES, 0, int32(len(graph.vertices)/graphVertexSize))
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+
+	gl.BindVertexArray(uint32(vao))
+	gl.UseProgram(uint32(program))
+	gl.BlendFuncSeparate(uint32(srcRGB), uint32(dstRGB), uint32(srcAlpha), uint32(dstAlpha))
+	if !blend {
+		gl.Disable(gl.BLEND)
+	}
+}
+
+// Delete deletes the OpenGL objects of graph.
+func (graph *FrameGraph) Delete() {
+	gl.DeleteBuffers(1, &graph.vbo)
+	gl.DeleteVertexArrays(1, &graph.vao)
+	gl.DeleteProgram(graph.program)
+}
+
+func (graph *FrameGraph) barHeight(seconds float64) float32 {
+	height := float32(seconds/graph.Scale) * graph.Height
+	if height > graph.Height {
+		return graph.Height
+	}
+	return height
+}
+
+func (graph *FrameGraph) addQuad(x, y, width, height, r, g, b, a float32) {
+	graph.vertices = append(graph.vertices,
+		x, y, r, g, b, a,
+		x+width, y, r, g, b, a,
+		x, y+height, r, g, b, a,
+		x, y+height, r, g, b, a,
+		x+width, y, r, g, b, a,
+		x+width, y+height, r, g, b, a)
+}
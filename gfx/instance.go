@@ -0,0 +1,68 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package gfx
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// InstanceBuffer is a vertex buffer with per-instance attributes (e.g. transform, color)
+// attached to the vertex array object of a mesh. Attributes advance once per instance
+// (divisor 1) instead of once per vertex.
+type InstanceBuffer struct {
+	VBO uint32
+	// number of floats per instance
+	Stride int
+	// number of instances stored in the buffer
+	Count int32
+}
+
+// NewInstanceBuffer creates a buffer for per-instance attributes and attaches it to
+// mesh. Attributes with more than 4 floats (e.g. 16 for mat4) occupy consecutive
+// locations, one per column.
+func NewInstanceBuffer(mesh *Mesh, stride int, attributes ...VertexAttribute) *InstanceBuffer {
+	buffer := &InstanceBuffer{Stride: stride}
+	gl.GenBuffers(1, &buffer.VBO)
+	gl.BindVertexArray(mesh.VAO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, buffer.VBO)
+	for _, attribute := range attributes {
+		if attribute.Location >= 0 {
+			for column := int32(0); column*4 < attribute.Size; column++ {
+				location := uint32(attribute.Location + column)
+				size := attribute.Size - column*4
+				if size > 4 {
+					size = 4
+				}
+				offset := attribute.Offset + int(column)*4
+				gl.EnableVertexAttribArray(location)
+				gl.VertexAttribPointer(location, size, gl.FLOAT, false, int32(stride*4), gl.PtrOffset(offset*4))
+				gl.VertexAttribDivisor(location, 1)
+			}
+		}
+	}
+	gl.BindVertexArray(0)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	return buffer
+}
+
+// Update replaces the instance data. Usage is gl.STATIC_DRAW for data set once or
+// gl.STREAM_DRAW for data changing every frame. Empty data leaves an empty buffer.
+func (buffer *InstanceBuffer) Update(data []float32, usage uint32) {
+	gl.BindBuffer(gl.ARRAY_BUFFER, buffer.VBO)
+	if len(data) > 0 {
+		gl.BufferData(gl.ARRAY_BUFFER, len(data)*4, gl.Ptr(data), usage)
+	} else {
+		// gl.Ptr panics on an empty slice
+		gl.BufferData(gl.ARRAY_BUFFER, 0, nil, usage)
+	}
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	buffer.Count = int32(len(data) / buffer.Stride)
+}
+
+// Delete deletes the buffer.
+func (buffer *InstanceBuffer) Delete() {
+	gl.DeleteBuffers(1, &buffer.VBO)
+}
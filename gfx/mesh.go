@@ -0,0 +1,97 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package gfx
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Mesh is a vertex array object drawn with DrawArrays or, if IndexType is not zero,
+// with DrawElements.
+type Mesh struct {
+	VAO uint32
+	// vertex and index buffer, if created by NewMesh
+	VBO uint32
+	EBO uint32
+	// primitive type (e.g. gl.TRIANGLES)
+	Mode uint32
+	// number of vertices or indices to draw
+	Count int32
+	// gl.UNSIGNED_SHORT or gl.UNSIGNED_INT for indexed meshes, otherwise zero
+	IndexType uint32
+}
+
+// VertexAttribute describes one attribute of interleaved float vertices.
+type VertexAttribute struct {
+	// attribute location in the program, attributes with location -1 are skipped
+	Location int32
+	// number of floats
+	Size int32
+	// offset in number of floats
+	Offset int
+}
+
+// NewMesh uploads interleaved vertices with stride floats per vertex and indices to
+// new buffers. If indices is empty, the mesh is drawn with DrawArrays.
+func NewMesh(mode uint32, vertices []float32, stride int, indices []uint32, attributes ...VertexAttribute) *Mesh {
+	mesh := &Mesh{Mode: mode}
+	gl.GenVertexArrays(1, &mesh.VAO)
+	gl.GenBuffers(1, &mesh.VBO)
+	gl.BindVertexArray(mesh.VAO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, mesh.VBO)
+	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices), gl.STATIC_DRAW)
+	for _, attribute := range attributes {
+		if attribute.Location >= 0 {
+			gl.EnableVertexAttribArray(uint32(attribute.Location))
+			gl.VertexAttribPointer(uint32(attribute.Location), attribute.Size, gl.FLOAT, false, int32(stride*4), gl.PtrOffset(attribute.Offset*4))
+		}
+	}
+	if len(indices) > 0 {
+		gl.GenBuffers(1, &mesh.EBO)
+		// the element buffer binding is stored in the vertex array object
+		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, mesh.EBO)
+		gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(indices)*4, gl.Ptr(indices), gl.STATIC_DRAW)
+		mesh.Count = int32(len(indices))
+		mesh.IndexType = gl.UNSIGNED_INT
+	} else {
+		mesh.Count = int32(len(vertices) / stride)
+	}
+	gl.BindVertexArray(0)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	return mesh
+}
+
+// Draw binds the vertex array object and draws it.
+func (mesh *Mesh) Draw() {
+	gl.BindVertexArray(mesh.VAO)
+	if mesh.IndexType != 0 {
+		gl.DrawElements(mesh.Mode, mesh.Count, mesh.IndexType, nil)
+	} else {
+		gl.DrawArrays(mesh.Mode, 0, mesh.Count)
+	}
+}
+
+// DrawInstanced draws instances copies of the mesh with DrawArraysInstanced or
+// DrawElementsInstanced. Per-instance attributes are set up with NewInstanceBuffer.
+func (mesh *Mesh) DrawInstanced(instances int32) {
+	gl.BindVertexArray(mesh.VAO)
+	if mesh.IndexType != 0 {
+		gl.DrawElementsInstanced(mesh.Mode, mesh.Count, mesh.IndexType, nil, instances)
+	} else {
+		gl.DrawArraysInstanced(mesh.Mode, 0, mesh.Count, instances)
+	}
+}
+
+// Delete deletes the vertex array object and the buffers created by NewMesh.
+func (mesh *Mesh) Delete() {
+	gl.DeleteVertexArrays(1, &mesh.VAO)
+	if mesh.VBO != 0 {
+		gl.DeleteBuffers(1, &mesh.VBO)
+	}
+	if mesh.EBO != 0 {
+		gl.DeleteBuffers(1, &mesh.EBO)
+	}
+}
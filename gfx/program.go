@@ -0,0 +1,122 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+// Package gfx contains reusable rendering building blocks based on OpenGL 3.3.
+package gfx
+
+import (
+	"errors"
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// NewProgram compiles vertex and fragment shader source and links them to a program.
+// The shaders are deleted after linking.
+func NewProgram(vertexShader, fragmentShader string) (uint32, error) {
+	return NewProgramWithAttributes(vertexShader, fragmentShader)
+}
+
+// NewProgramWithAttributes is like NewProgram, but binds the attributes to the
+// locations 0, 1, 2 etc. in the given order before linking. Programs with the same
+// attribute order can draw the same vertex array objects.
+func NewProgramWithAttributes(vertexShader, fragmentShader string, attributes ...string) (uint32, error) {
+	var program uint32
+	vertexShaderID, err := NewShader(gl.VERTEX_SHADER, vertexShader)
+
+	if err == nil {
+		var fragmentShaderID uint32
+		fragmentShaderID, err = NewShader(gl.FRAGMENT_SHADER, fragmentShader)
+
+		if err == nil {
+			program = gl.CreateProgram()
+			gl.AttachShader(program, vertexShaderID)
+			gl.AttachShader(program, fragmentShaderID)
+			for i, attribute := range attributes {
+				gl.BindAttribLocation(program, uint32(i), gl.Str(attribute+"\x00"))
+			}
+			gl.LinkProgram(program)
+			err = checkProgram(program, gl.LINK_STATUS)
+			gl.DetachShader(program, vertexShaderID)
+			gl.DetachShader(program, fragmentShaderID)
+			gl.DeleteShader(fragmentShaderID)
+
+			if err != nil {
+				gl.DeleteProgram(program)
+				program = 0
+			}
+		}
+		gl.DeleteShader(vertexShaderID)
+	}
+	return program, err
+}
+
+// NewShader compiles shader source. Shader type is gl.VERTEX_SHADER or gl.FRAGMENT_SHADER.
+func NewShader(shaderType uint32, source string) (uint32, error) {
+	shader := gl.CreateShader(shaderType)
+	sources, free := gl.Strs(source + "\x00")
+	gl.ShaderSource(shader, 1, sources, nil)
+	free()
+	gl.CompileShader(shader)
+	err := checkShader(shader, gl.COMPILE_STATUS)
+
+	if err != nil {
+		gl.DeleteShader(shader)
+		shader = 0
+	}
+	return shader, err
+}
+
+// AttribLocation returns the location of attribute name in program.
+func AttribLocation(program uint32, name string) int32 {
+	return gl.GetAttribLocation(program, gl.Str(name+"\x00"))
+}
+
+// UniformLocation returns the location of uniform name in program.
+func UniformLocation(program uint32, name string) int32 {
+	return gl.GetUniformLocation(program, gl.Str(name+"\x00"))
+}
+
+func checkShader(shader, statusType uint32) error {
+	var status int32
+	var err error
+
+	gl.GetShaderiv(shader, statusType, &status)
+
+	if status == gl.FALSE {
+		var length int32
+		var infoLog string
+
+		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &length)
+
+		if length > 0 {
+			infoLogBytes := make([]byte, length)
+			gl.GetShaderInfoLog(shader, length, nil, &infoLogBytes[0])
+			infoLog = string(infoLogBytes)
+		}
+		err = errors.New("shader " + infoLog)
+	}
+	return err
+}
+
+func checkProgram(program, statusType uint32) error {
+	var status int32
+	var err error
+
+	gl.GetProgramiv(program, statusType, &status)
+
+	if status == gl.FALSE {
+		var length int32
+		var infoLog string
+
+		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &length)
+
+		if length > 0 {
+			infoLogBytes := make([]byte, length)
+			gl.GetProgramInfoLog(program, length, nil, &infoLogBytes[0])
+			infoLog = string(infoLogBytes)
+		}
+		err = errors.New("program " + infoLog)
+	}
+	return err
+}
@@ -0,0 +1,278 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package gfx
+
+import (
+	"fmt"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/vbsw/opengl-go-example/vmath"
+	"math"
+	"sort"
+)
+
+// SpriteVertexShader is the vertex shader of the default program of SpriteBatch.
+// Custom sprite programs can use it with their own fragment shader.
+const SpriteVertexShader = `#version 130
+
+in vec2 positionIn;
+in vec2 textureCoordsIn;
+in vec4 colorIn;
+uniform mat4 projection;
+out vec2 fragmentTextureCoords;
+out vec4 fragmentColor;
+
+void main() {
+	gl_Position = projection * vec4(positionIn, 0.0, 1.0);
+	fragmentTextureCoords = textureCoordsIn;
+	fragmentColor = colorIn;
+}
+`
+
+const spriteFragmentShader = `#version 130
+
+in vec2 fragmentTextureCoords;
+in vec4 fragmentColor;
+uniform sampler2D image;
+out vec4 color;
+
+void main() {
+	color = texture(image, fragmentTextureCoords) * fragmentColor;
+}
+`
+
+// x, y, u, v, r, g, b, a
+const spriteVertexSize = 8
+
+// Sprite is a textured quad drawn by SpriteBatch.
+type Sprite struct {
+	// nil draws with no texture bound (texture 0)
+	Texture *Texture
+	// region of the texture (e.g. from an atlas), zero means the whole texture
+	Region Rect
+	// position of the origin
+	X, Y float32
+	// size before scaling
+	Width, Height float32
+	// pivot of rotation and scaling relative to size (0.5, 0.5 is the center)
+	OriginX, OriginY float32
+	// counter-clockwise in radians
+	Rotation       float32
+	ScaleX, ScaleY float32
+	// multiplied with the texture color
+	Color vmath.Vec4
+	// sprites with higher layers are drawn on top
+	Layer int
+	// program to draw the sprite with, zero means the default program of the batch
+	Program uint32
+}
+
+// SpriteBatch collects sprites and draws them with as few draw calls as possible.
+// Sprites are sorted by layer (stable, i.e. in order of submission within a layer)
+// and written to a streaming vertex buffer. A draw call is issued whenever the texture
+// or the program changes or the buffer is full.
+type SpriteBatch struct {
+	// number of draw calls of last End
+	DrawCalls int
+	program   uint32
+	vao       uint32
+	vbo       uint32
+	ebo       uint32
+	capacity  int
+	sprites   []Sprite
+	vertices  []float32
+	// attribute and uniform locations of every program used
+	locations  map[uint32]spriteLocations
+	projection vmath.Mat4
+	current    uint32
+	texture    *Texture
+	// program whose attribute locations are enabled in the vertex array object
+	bound uint32
+}
+
+type spriteLocations struct {
+	position, textureCoords, color, projection, image int32
+}
+
+// NewSprite returns a sprite showing the whole texture with its size in pixels.
+func NewSprite(texture *Texture) Sprite {
+	return Sprite{Texture: texture, Width: float32(texture.Width), Height: float32(texture.Height), OriginX: 0.5, OriginY: 0.5, ScaleX: 1, ScaleY: 1, Color: vmath.Vec4{1, 1, 1, 1}}
+}
+
+// NewSpriteBatch returns a new instance of SpriteBatch, that draws up to capacity
+// sprites per draw call. Capacity must be at least 1.
+func NewSpriteBatch(capacity int) (*SpriteBatch, error) {
+	if capacity < 1 {
+		return nil, fmt.Errorf("sprite batch capacity %d, must be at least 1", capacity)
+	}
+	program, err := NewProgram(SpriteVertexShader, spriteFragmentShader)
+	if err == nil {
+		batch := &SpriteBatch{program: program, capacity: capacity, locations: make(map[uint32]spriteLocations)}
+		indices := make([]uint32, 0, capacity*6)
+		for i := uint32(0); i < uint32(capacity); i++ {
+			indices = append(indices, i*4, i*4+1, i*4+2, i*4+2, i*4+3, i*4)
+		}
+		gl.GenVertexArrays(1, &batch.vao)
+		gl.GenBuffers(1, &batch.vbo)
+		gl.GenBuffers(1, &batch.ebo)
+		gl.BindVertexArray(batch.vao)
+		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, batch.ebo)
+		gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(indices)*4, gl.Ptr(indices), gl.STATIC_DRAW)
+		gl.BindBuffer(gl.ARRAY_BUFFER, batch.vbo)
+		gl.BufferData(gl.ARRAY_BUFFER, capacity*4*spriteVertexSize*4, nil, gl.STREAM_DRAW)
+		gl.BindVertexArray(0)
+		gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+		batch.vertices = make([]float32, 0, capacity*4*spriteVertexSize)
+		return batch, nil
+	}
+	return nil, err
+}
+
+// Begin starts a new batch. Projection transforms sprite coordinates to clip space
+// (e.g. vmath.Ortho2D(0, width, 0, height) for pixels).
+func (batch *SpriteBatch) Begin(projection vmath.Mat4) {
+	batch.projection = projection
+	batch.sprites = batch.sprites[:0]
+	batch.DrawCalls = 0
+}
+
+// Draw adds sprite to the batch.
+func (batch *SpriteBatch) Draw(sprite *Sprite) {
+	batch.sprites = append(batch.sprites, *sprite)
+}
+
+// End sorts the sprites and draws them. Blending is enabled (alpha), depth test and
+// face culling are disabled.
+func (batch *SpriteBatch) End() {
+	sort.SliceStable(batch.sprites, func(i, j int) bool {
+		return batch.sprites[i].Layer < batch.sprites[j].Layer
+	})
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Disable(gl.CULL_FACE)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindVertexArray(batch.vao)
+	batch.current, batch.texture = 0, nil
+	batch.bindTexture()
+	for i := range batch.sprites {
+		sprite := &batch.sprites[i]
+		program := sprite.Program
+		if program == 0 {
+			program = batch.program
+		}
+		if program != batch.current || sprite.Texture != batch.texture || len(batch.vertices) == cap(batch.vertices) {
+			batch.flush()
+			if program != batch.current {
+				batch.useProgram(program)
+			}
+			if sprite.Texture != batch.texture {
+				batch.texture = sprite.Texture
+				batch.bindTexture()
+			}
+		}
+		batch.addSprite(sprite)
+	}
+	batch.flush()
+	gl.BindVertexArray(0)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+}
+
+// Delete deletes the OpenGL objects of batch.
+func (batch *SpriteBatch) Delete() {
+	gl.DeleteBuffers(1, &batch.ebo)
+	gl.DeleteBuffers(1, &batch.vbo)
+	gl.DeleteVertexArrays(1, &batch.vao)
+	gl.DeleteProgram(batch.program)
+}
+
+// bindTexture binds the current texture of batch or texture 0, if it is nil.
+func (batch *SpriteBatch) bindTexture() {
+	if batch.texture != nil {
+		gl.BindTexture(gl.TEXTURE_2D, batch.texture.ID)
+	} else {
+		gl.BindTexture(gl.TEXTURE_2D, 0)
+	}
+}
+
+func (batch *SpriteBatch) flush() {
+	if len(batch.vertices) > 0 {
+		gl.BindBuffer(gl.ARRAY_BUFFER, batch.vbo)
+		// orphan the buffer, so that the driver doesn't wait for previous draw calls
+		gl.BufferData(gl.ARRAY_BUFFER, batch.capacity*4*spriteVertexSize*4, nil, gl.STREAM_DRAW)
+		gl.BufferSubData(gl.ARRAY_BUFFER, 0, len(batch.vertices)*4, gl.Ptr(batch.vertices))
+		gl.DrawElements(gl.TRIANGLES, int32(len(batch.vertices)/spriteVertexSize/4*6), gl.UNSIGNED_INT, nil)
+		gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+		batch.vertices = batch.vertices[:0]
+		batch.DrawCalls++
+	}
+}
+
+// useProgram activates program and points the vertex attributes to its locations.
+// Custom programs must have the same inputs as the default program.
+func (batch *SpriteBatch) useProgram(program uint32) {
+	locations, ok := batch.locations[program]
+	if !ok {
+		locations.position = AttribLocation(program, "positionIn")
+		locations.textureCoords = AttribLocation(program, "textureCoordsIn")
+		locations.color = AttribLocation(program, "colorIn")
+		locations.projection = UniformLocation(program, "projection")
+		locations.image = UniformLocation(program, "image")
+		batch.locations[program] = locations
+	}
+	gl.UseProgram(program)
+	gl.UniformMatrix4fv(locations.projection, 1, false, batch.projection.Ptr())
+	gl.Uniform1i(locations.image, 0)
+	if batch.bound != program {
+		if batch.bound != 0 {
+			previous := batch.locations[batch.bound]
+			disableAttribs(previous.position, previous.textureCoords, previous.color)
+		}
+		gl.BindBuffer(gl.ARRAY_BUFFER, batch.vbo)
+		enableAttrib(locations.position, 2, 0)
+		enableAttrib(locations.textureCoords, 2, 2)
+		enableAttrib(locations.color, 4, 4)
+		gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+		batch.bound = program
+	}
+	batch.current = program
+}
+
+func (batch *SpriteBatch) addSprite(sprite *Sprite) {
+	region := sprite.Region
+	if region.Width == 0 && region.Height == 0 {
+		region = Rect{0, 0, 1, 1}
+	}
+	width, height := sprite.Width*sprite.ScaleX, sprite.Height*sprite.ScaleY
+	left, bottom := -sprite.OriginX*width, -sprite.OriginY*height
+	right, top := left+width, bottom+height
+	sin, cos := math.Sincos(float64(sprite.Rotation))
+	s, c := float32(sin), float32(cos)
+	// texture rows start at the top of the image
+	u0, v0 := region.X, region.Y+region.Height
+	u1, v1 := region.X+region.Width, region.Y
+	r, g, b, a := sprite.Color[0], sprite.Color[1], sprite.Color[2], sprite.Color[3]
+	corners := [4][4]float32{{left, bottom, u0, v0}, {right, bottom, u1, v0}, {right, top, u1, v1}, {left, top, u0, v1}}
+	for _, corner := range corners {
+		x := sprite.X + corner[0]*c - corner[1]*s
+		y := sprite.Y + corner[0]*s + corner[1]*c
+		batch.vertices = append(batch.vertices, x, y, corner[2], corner[3], r, g, b, a)
+	}
+}
+
+func enableAttrib(location, size int32, offset int) {
+	if location >= 0 {
+		gl.EnableVertexAttribArray(uint32(location))
+		gl.VertexAttribPointer(uint32(location), size, gl.FLOAT, false, spriteVertexSize*4, gl.PtrOffset(offset*4))
+	}
+}
+
+func disableAttribs(locations ...int32) {
+	for _, location := range locations {
+		if location >= 0 {
+			gl.DisableVertexAttribArray(uint32(location))
+		}
+	}
+}
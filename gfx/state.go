@@ -0,0 +1,89 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package gfx
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// RenderState is the fixed-function state of a draw call concerning depth and
+// face culling. The depth buffer must be requested when the window is created
+// (glfw.DepthBits).
+type RenderState struct {
+	DepthTest  bool
+	DepthWrite bool
+	// comparison of depth test (e.g. gl.LESS)
+	DepthFunc uint32
+	Cull      bool
+	// faces to cull (gl.BACK, gl.FRONT or gl.FRONT_AND_BACK)
+	CullFace uint32
+	// winding of front faces (gl.CCW or gl.CW)
+	FrontFace uint32
+}
+
+// Opaque3D returns the state for solid 3D geometry: depth test with gl.LESS,
+// depth writes and culling of back faces with counter-clockwise front faces.
+func Opaque3D() RenderState {
+	return RenderState{DepthTest: true, DepthWrite: true, DepthFunc: gl.LESS, Cull: true, CullFace: gl.BACK, FrontFace: gl.CCW}
+}
+
+// Flat2D returns the state for 2D geometry: no depth test, no depth writes and no culling.
+func Flat2D() RenderState {
+	return RenderState{DepthFunc: gl.LESS, CullFace: gl.BACK, FrontFace: gl.CCW}
+}
+
+// Apply sets the state in OpenGL.
+func (state *RenderState) Apply() {
+	if state.DepthTest {
+		gl.Enable(gl.DEPTH_TEST)
+		gl.DepthFunc(state.DepthFunc)
+	} else {
+		gl.Disable(gl.DEPTH_TEST)
+	}
+	gl.DepthMask(state.DepthWrite)
+	if state.Cull {
+		gl.Enable(gl.CULL_FACE)
+		gl.CullFace(state.CullFace)
+	} else {
+		gl.Disable(gl.CULL_FACE)
+	}
+	gl.FrontFace(state.FrontFace)
+}
+
+// DepthBits returns the number of bits of the depth buffer of the default framebuffer.
+func DepthBits() int32 {
+	return defaultFramebufferBits(gl.DEPTH, gl.FRAMEBUFFER_ATTACHMENT_DEPTH_SIZE)
+}
+
+// StencilBits returns the number of bits of the stencil buffer of the default framebuffer.
+func StencilBits() int32 {
+	return defaultFramebufferBits(gl.STENCIL, gl.FRAMEBUFFER_ATTACHMENT_STENCIL_SIZE)
+}
+
+// MaxSamples returns the maximum number of samples per pixel of multisampled
+// framebuffers (gl.MAX_SAMPLES).
+func MaxSamples() int {
+	var samples int32
+	gl.GetIntegerv(gl.MAX_SAMPLES, &samples)
+	return int(samples)
+}
+
+// Samples returns the number of samples per pixel of the bound framebuffer. It is 0,
+// if it isn't multisampled.
+func Samples() int {
+	var samples int32
+	gl.GetIntegerv(gl.SAMPLES, &samples)
+	return int(samples)
+}
+
+func defaultFramebufferBits(attachment, parameter uint32) int32 {
+	var bits, framebuffer int32
+	gl.GetIntegerv(gl.DRAW_FRAMEBUFFER_BINDING, &framebuffer)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, 0)
+	gl.GetFramebufferAttachmentParameteriv(gl.DRAW_FRAMEBUFFER, attachment, parameter, &bits)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, uint32(framebuffer))
+	return bits
+}
@@ -0,0 +1,149 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package gfx
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// StreamMode is a strategy to upload vertex data, that changes every frame.
+type StreamMode int
+
+// Stream modes.
+const (
+	// StreamBufferData reallocates the buffer with gl.BufferData every frame.
+	StreamBufferData StreamMode = iota
+	// StreamSubData overwrites a gl.DYNAMIC_DRAW buffer with gl.BufferSubData. The
+	// driver may have to wait until the previous frame has been drawn.
+	StreamSubData
+	// StreamOrphan orphans the buffer (gl.BufferData with nil) before gl.BufferSubData,
+	// so the driver can allocate new memory instead of waiting.
+	StreamOrphan
+	// StreamRing maps one of three sections of a buffer unsynchronized. Fences make
+	// sure the GPU has finished reading a section before it is written again.
+	StreamRing
+	streamModes
+)
+
+// number of sections of the ring buffer (frames in flight)
+const streamSections = 3
+
+// timeout of one wait for a fence in nanoseconds
+const fenceTimeout = 1000000
+
+var streamModeNames = [...]string{"BufferData", "BufferSubData", "orphaning", "mapped ring"}
+
+// StreamBuffer is a vertex buffer for data, that is uploaded every frame.
+type StreamBuffer struct {
+	VBO  uint32
+	Mode StreamMode
+	// maximum number of bytes per frame
+	Size int
+	// number of uploads that had to wait for the GPU (only StreamRing)
+	Stalls  int
+	section int
+	fences  [streamSections]uintptr
+}
+
+// String returns the name of the stream mode.
+func (mode StreamMode) String() string {
+	if mode >= 0 && mode < streamModes {
+		return streamModeNames[mode]
+	}
+	return "unknown"
+}
+
+// Next returns the following stream mode (wraps around).
+func (mode StreamMode) Next() StreamMode {
+	return (mode + 1) % streamModes
+}
+
+// NewStreamBuffer returns a new instance of StreamBuffer for up to size bytes per frame.
+func NewStreamBuffer(mode StreamMode, size int) *StreamBuffer {
+	buffer := &StreamBuffer{Mode: mode, Size: size}
+	gl.GenBuffers(1, &buffer.VBO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, buffer.VBO)
+	switch mode {
+	case StreamSubData:
+		gl.BufferData(gl.ARRAY_BUFFER, size, nil, gl.DYNAMIC_DRAW)
+	case StreamRing:
+		gl.BufferData(gl.ARRAY_BUFFER, size*streamSections, nil, gl.STREAM_DRAW)
+	default:
+		gl.BufferData(gl.ARRAY_BUFFER, size, nil, gl.STREAM_DRAW)
+	}
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	return buffer
+}
+
+// Upload writes data of the current frame (at most Size bytes) and returns its offset
+// in the buffer in bytes. The offset is always 0, except for StreamRing. The buffer
+// is bound to gl.ARRAY_BUFFER afterwards.
+func (buffer *StreamBuffer) Upload(data []float32) int {
+	var offset int
+	size := len(data) * 4
+	gl.BindBuffer(gl.ARRAY_BUFFER, buffer.VBO)
+	if size > 0 {
+		switch buffer.Mode {
+		case StreamBufferData:
+			gl.BufferData(gl.ARRAY_BUFFER, size, gl.Ptr(data), gl.STREAM_DRAW)
+		case StreamSubData:
+			gl.BufferSubData(gl.ARRAY_BUFFER, 0, size, gl.Ptr(data))
+		case StreamOrphan:
+			gl.BufferData(gl.ARRAY_BUFFER, buffer.Size, nil, gl.STREAM_DRAW)
+			gl.BufferSubData(gl.ARRAY_BUFFER, 0, size, gl.Ptr(data))
+		case StreamRing:
+			offset = buffer.section * buffer.Size
+			buffer.waitFence(buffer.section)
+			access := uint32(gl.MAP_WRITE_BIT | gl.MAP_INVALIDATE_RANGE_BIT | gl.MAP_UNSYNCHRONIZED_BIT)
+			ptr := gl.MapBufferRange(gl.ARRAY_BUFFER, offset, size, access)
+			if ptr != nil {
+				mapped := (*[1 << 30]float32)(ptr)[:len(data):len(data)]
+				copy(mapped, data)
+				gl.UnmapBuffer(gl.ARRAY_BUFFER)
+			}
+		}
+	}
+	return offset
+}
+
+// EndFrame must be called after the draw calls reading the data of Upload. In
+// StreamRing mode it inserts a fence and advances to the next section.
+func (buffer *StreamBuffer) EndFrame() {
+	if buffer.Mode == StreamRing {
+		if buffer.fences[buffer.section] != 0 {
+			gl.DeleteSync(buffer.fences[buffer.section])
+		}
+		buffer.fences[buffer.section] = gl.FenceSync(gl.SYNC_GPU_COMMANDS_COMPLETE, 0)
+		buffer.section = (buffer.section + 1) % streamSections
+	}
+}
+
+// Delete deletes the buffer and pending fences.
+func (buffer *StreamBuffer) Delete() {
+	for i, fence := range buffer.fences {
+		if fence != 0 {
+			gl.DeleteSync(fence)
+			buffer.fences[i] = 0
+		}
+	}
+	gl.DeleteBuffers(1, &buffer.VBO)
+}
+
+// waitFence blocks until the GPU has passed the fence of section.
+func (buffer *StreamBuffer) waitFence(section int) {
+	fence := buffer.fences[section]
+	if fence != 0 {
+		result := gl.ClientWaitSync(fence, gl.SYNC_FLUSH_COMMANDS_BIT, fenceTimeout)
+		if result != gl.ALREADY_SIGNALED {
+			buffer.Stalls++
+		}
+		for result == gl.TIMEOUT_EXPIRED {
+			result = gl.ClientWaitSync(fence, gl.SYNC_FLUSH_COMMANDS_BIT, fenceTimeout)
+		}
+		gl.DeleteSync(fence)
+		buffer.fences[section] = 0
+	}
+}
@@ -0,0 +1,99 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package gfx
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"image"
+	"image/draw"
+	// register PNG decoder for LoadTexture
+	_ "image/png"
+	"os"
+)
+
+// Texture is a 2D texture with RGBA pixels.
+type Texture struct {
+	ID     uint32
+	Width  int
+	Height int
+}
+
+// Rect is a rectangle, e.g. a region of a texture in texture coordinates (0 to 1)
+// with origin at the first row of pixels (top of the image).
+type Rect struct {
+	X, Y, Width, Height float32
+}
+
+// NewTexture uploads RGBA pixels (rows from top to bottom) to a new texture. Filter is
+// used for minification and magnification (gl.NEAREST or gl.LINEAR).
+func NewTexture(width, height int, pixels []uint8, filter int32) *Texture {
+	return newTexture(gl.RGBA, width, height, pixels, filter)
+}
+
+// NewSRGBTexture is like NewTexture, but the colors are sRGB and are converted to
+// linear when sampled (alpha is linear). Use it for color textures, not for data
+// like normals.
+func NewSRGBTexture(width, height int, pixels []uint8, filter int32) *Texture {
+	return newTexture(gl.SRGB8_ALPHA8, width, height, pixels, filter)
+}
+
+func newTexture(internalFormat int32, width, height int, pixels []uint8, filter int32) *Texture {
+	texture := &Texture{Width: width, Height: height}
+	gl.GenTextures(1, &texture.ID)
+	gl.BindTexture(gl.TEXTURE_2D, texture.ID)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, internalFormat, int32(width), int32(height), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(pixels))
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, filter)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, filter)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	return texture
+}
+
+// NewTextureFromImage uploads img to a new texture.
+func NewTextureFromImage(img image.Image, filter int32) *Texture {
+	rgba, ok := img.(*image.RGBA)
+	if !ok || rgba.Stride != rgba.Rect.Dx()*4 {
+		rgba = image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+		draw.Draw(rgba, rgba.Rect, img, img.Bounds().Min, draw.Src)
+	}
+	return NewTexture(rgba.Rect.Dx(), rgba.Rect.Dy(), rgba.Pix, filter)
+}
+
+// LoadTexture reads a PNG file and uploads it to a new texture.
+func LoadTexture(path string, filter int32) (*Texture, error) {
+	file, err := os.Open(path)
+	if err == nil {
+		var img image.Image
+		defer file.Close()
+		img, _, err = image.Decode(file)
+		if err == nil {
+			return NewTextureFromImage(img, filter), nil
+		}
+	}
+	return nil, err
+}
+
+// SetWrap sets the wrap mode of both texture coordinates (e.g. gl.REPEAT).
+func (texture *Texture) SetWrap(mode int32) {
+	gl.BindTexture(gl.TEXTURE_2D, texture.ID)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, mode)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, mode)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+}
+
+// GenerateMipmaps generates the mipmap levels and sets trilinear minification.
+func (texture *Texture) GenerateMipmaps() {
+	gl.BindTexture(gl.TEXTURE_2D, texture.ID)
+	gl.GenerateMipmap(gl.TEXTURE_2D)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+}
+
+// Delete deletes the texture.
+func (texture *Texture) Delete() {
+	gl.DeleteTextures(1, &texture.ID)
+}
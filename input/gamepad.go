@@ -0,0 +1,339 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"io/ioutil"
+	"math"
+	"strings"
+)
+
+// Limits of the raw joystick state. Further axes, buttons and hats are ignored.
+const (
+	MaxJoystickAxes    = 16
+	MaxJoystickButtons = 32
+	MaxJoystickHats    = 4
+)
+
+// Gamepad is for storing the state of one joystick. The gamepad state (Buttons and
+// Axes) requires a gamepad mapping, the raw state is available for all joysticks.
+type Gamepad struct {
+	Joystick  glfw.Joystick
+	Name      string
+	GUID      string
+	Connected bool
+	// true, if joystick has a gamepad mapping (otherwise Buttons and Axes are not set)
+	Mapped  bool
+	Buttons [glfw.ButtonLast + 1]bool
+	Axes    [glfw.AxisLast + 1]float32
+	// state of the joystick without mapping and deadzone
+	Raw      JoystickState
+	pressed  [glfw.ButtonLast + 1]bool
+	released [glfw.ButtonLast + 1]bool
+	// true, if the buttons held at disconnection must still be released by Update
+	disconnected bool
+}
+
+// JoystickState is the raw state of a joystick as returned by GetAxes, GetButtons
+// and GetHats of glfw.Joystick.
+type JoystickState struct {
+	AxisCount   int
+	ButtonCount int
+	HatCount    int
+	Axes        [MaxJoystickAxes]float32
+	Buttons     [MaxJoystickButtons]bool
+	Hats        [MaxJoystickHats]glfw.JoystickHatState
+}
+
+// JoystickEvent is a connect or disconnect event of a joystick.
+type JoystickEvent struct {
+	Joystick  glfw.Joystick
+	Connected bool
+}
+
+// GamepadState is the combined state of all gamepads (e.g. for recording).
+type GamepadState struct {
+	// bit i is set, if button i is down on any gamepad
+	Buttons uint32
+	// values returned by Gamepads.Axis
+	Axes [glfw.AxisLast + 1]float32
+	// raw state of the first connected joystick
+	Joystick JoystickState
+}
+
+// Gamepads is for storing the state of all joysticks.
+type Gamepads struct {
+	// axis values below Deadzone are zero, values above are rescaled to range 0 to 1
+	Deadzone float32
+	Pads     [glfw.JoystickLast + 1]Gamepad
+	// connect and disconnect events since last call of EndFrame
+	Events []JoystickEvent
+	// buttons that generate key events
+	KeyMap map[glfw.GamepadButton]glfw.Key
+}
+
+// NewGamepads returns a new instance of Gamepads with a deadzone of 0.15 and the default key map.
+func NewGamepads() *Gamepads {
+	gamepads := new(Gamepads)
+	gamepads.Deadzone = 0.15
+	gamepads.KeyMap = DefaultKeyMap()
+	for i := range gamepads.Pads {
+		gamepads.Pads[i].Joystick = glfw.Joystick1 + glfw.Joystick(i)
+	}
+	return gamepads
+}
+
+// DefaultKeyMap returns a mapping of gamepad buttons to keys. Back is Escape,
+// Start is Enter, A is Space and the directional pad are the arrow keys.
+func DefaultKeyMap() map[glfw.GamepadButton]glfw.Key {
+	keyMap := make(map[glfw.GamepadButton]glfw.Key)
+	keyMap[glfw.ButtonBack] = glfw.KeyEscape
+	keyMap[glfw.ButtonStart] = glfw.KeyEnter
+	keyMap[glfw.ButtonA] = glfw.KeySpace
+	keyMap[glfw.ButtonDpadUp] = glfw.KeyUp
+	keyMap[glfw.ButtonDpadDown] = glfw.KeyDown
+	keyMap[glfw.ButtonDpadLeft] = glfw.KeyLeft
+	keyMap[glfw.ButtonDpadRight] = glfw.KeyRight
+	return keyMap
+}
+
+// Register sets the joystick callback and registers joysticks, that are already connected.
+func (gamepads *Gamepads) Register() {
+	glfw.SetJoystickCallback(gamepads.OnJoystick)
+	for i := range gamepads.Pads {
+		pad := &gamepads.Pads[i]
+		if pad.Joystick.Present() {
+			pad.connect()
+		}
+	}
+}
+
+// OnJoystick is a glfw.JoystickCallback.
+func (gamepads *Gamepads) OnJoystick(joy glfw.Joystick, event glfw.PeripheralEvent) {
+	index := int(joy - glfw.Joystick1)
+	if index >= 0 && index < len(gamepads.Pads) {
+		pad := &gamepads.Pads[index]
+		if event == glfw.Connected {
+			pad.connect()
+			gamepads.Events = append(gamepads.Events, JoystickEvent{joy, true})
+		} else if event == glfw.Disconnected {
+			*pad = Gamepad{Joystick: joy, released: pad.Buttons, disconnected: true}
+			gamepads.Events = append(gamepads.Events, JoystickEvent{joy, false})
+		}
+	}
+}
+
+// Update polls the state of all connected joysticks. For every button in KeyMap, that has
+// been pressed or released, keyCallback is called (keyCallback may be nil). Buttons held
+// on a disconnected gamepad are released. Update should be called once per frame after
+// glfw.PollEvents.
+func (gamepads *Gamepads) Update(window *glfw.Window, keyCallback glfw.KeyCallback) {
+	for i := range gamepads.Pads {
+		pad := &gamepads.Pads[i]
+		disconnected := pad.disconnected
+		pad.disconnected = false
+		if disconnected && keyCallback != nil {
+			gamepads.sendKeys(window, pad, keyCallback)
+		}
+		if pad.Connected {
+			pad.Raw.set(pad.Joystick.GetAxes(), pad.Joystick.GetButtons(), pad.Joystick.GetHats())
+		}
+		if pad.Connected && pad.Mapped {
+			state := pad.Joystick.GetGamepadState()
+			if state != nil {
+				pad.update(state, gamepads.Deadzone)
+				if keyCallback != nil {
+					gamepads.sendKeys(window, pad, keyCallback)
+				}
+			}
+		} else if !disconnected {
+			pad.released = [glfw.ButtonLast + 1]bool{}
+		}
+	}
+}
+
+// EndFrame clears Events. It should be called at the end of each frame, before
+// glfw.PollEvents.
+func (gamepads *Gamepads) EndFrame() {
+	gamepads.Events = gamepads.Events[:0]
+}
+
+// State returns the combined state of all gamepads.
+func (gamepads *Gamepads) State() GamepadState {
+	var state GamepadState
+	for i := range gamepads.Pads {
+		for button, down := range gamepads.Pads[i].Buttons {
+			if down {
+				state.Buttons |= 1 << uint(button)
+			}
+		}
+	}
+	for axis := range state.Axes {
+		state.Axes[axis] = gamepads.Axis(glfw.GamepadAxis(axis))
+	}
+	for i := range gamepads.Pads {
+		if gamepads.Pads[i].Connected {
+			state.Joystick = gamepads.Pads[i].Raw
+			break
+		}
+	}
+	return state
+}
+
+// SetState replaces all gamepads by one gamepad in state (e.g. for replay). It should
+// be called once per frame instead of Update. No keys are sent. The gamepad is
+// mapped and has the raw state of state.Joystick.
+func (gamepads *Gamepads) SetState(state GamepadState) {
+	for i := range gamepads.Pads {
+		pad := &gamepads.Pads[i]
+		if i == 0 {
+			pad.Connected, pad.Mapped = true, true
+			for button := range pad.Buttons {
+				down := state.Buttons&(1<<uint(button)) != 0
+				pad.pressed[button] = down && !pad.Buttons[button]
+				pad.released[button] = !down && pad.Buttons[button]
+				pad.Buttons[button] = down
+			}
+			pad.Axes = state.Axes
+			pad.Raw = state.Joystick
+		} else {
+			*pad = Gamepad{Joystick: pad.Joystick}
+		}
+	}
+}
+
+// ButtonPressed returns true, if button has been pressed on any gamepad during last Update.
+func (gamepads *Gamepads) ButtonPressed(button glfw.GamepadButton) bool {
+	for i := range gamepads.Pads {
+		if gamepads.Pads[i].pressed[button] {
+			return true
+		}
+	}
+	return false
+}
+
+// Axis returns the value of axis of the first connected gamepad, that has this axis
+// outside of the deadzone.
+func (gamepads *Gamepads) Axis(axis glfw.GamepadAxis) float32 {
+	for i := range gamepads.Pads {
+		if value := gamepads.Pads[i].Axes[axis]; value != 0 {
+			return value
+		}
+	}
+	return 0
+}
+
+// ButtonPressed returns true, if button has been pressed during last Update.
+func (pad *Gamepad) ButtonPressed(button glfw.GamepadButton) bool {
+	return pad.pressed[button]
+}
+
+// ButtonReleased returns true, if button has been released during last Update.
+func (pad *Gamepad) ButtonReleased(button glfw.GamepadButton) bool {
+	return pad.released[button]
+}
+
+func (pad *Gamepad) connect() {
+	pad.Connected = true
+	pad.Mapped = pad.Joystick.IsGamepad()
+	pad.GUID = pad.Joystick.GetGUID()
+	if pad.Mapped {
+		pad.Name = pad.Joystick.GetGamepadName()
+	} else {
+		pad.Name = pad.Joystick.GetName()
+	}
+}
+
+// set replaces the state by axes, buttons and hats up to the limits.
+func (state *JoystickState) set(axes []float32, buttons []glfw.Action, hats []glfw.JoystickHatState) {
+	*state = JoystickState{}
+	state.AxisCount = copy(state.Axes[:], axes)
+	state.HatCount = copy(state.Hats[:], hats)
+	for i := 0; i < len(buttons) && i < len(state.Buttons); i++ {
+		state.Buttons[i] = buttons[i] == glfw.Press
+		state.ButtonCount++
+	}
+}
+
+func (pad *Gamepad) update(state *glfw.GamepadState, deadzone float32) {
+	for i, action := range state.Buttons {
+		down := action == glfw.Press
+		pad.pressed[i] = down && !pad.Buttons[i]
+		pad.released[i] = !down && pad.Buttons[i]
+		pad.Buttons[i] = down
+	}
+	pad.Axes[glfw.AxisLeftX], pad.Axes[glfw.AxisLeftY] = radialDeadzone(state.Axes[glfw.AxisLeftX], state.Axes[glfw.AxisLeftY], deadzone)
+	pad.Axes[glfw.AxisRightX], pad.Axes[glfw.AxisRightY] = radialDeadzone(state.Axes[glfw.AxisRightX], state.Axes[glfw.AxisRightY], deadzone)
+	// triggers range from -1 (released) to 1 (fully pressed)
+	pad.Axes[glfw.AxisLeftTrigger] = axialDeadzone((state.Axes[glfw.AxisLeftTrigger]+1)/2, deadzone)
+	pad.Axes[glfw.AxisRightTrigger] = axialDeadzone((state.Axes[glfw.AxisRightTrigger]+1)/2, deadzone)
+}
+
+// sendKeys calls keyCallback in order of the buttons.
+func (gamepads *Gamepads) sendKeys(window *glfw.Window, pad *Gamepad, keyCallback glfw.KeyCallback) {
+	for button := glfw.GamepadButton(0); button <= glfw.ButtonLast; button++ {
+		key, ok := gamepads.KeyMap[button]
+		if !ok {
+			continue
+		}
+		if pad.pressed[button] {
+			keyCallback(window, key, 0, glfw.Press, 0)
+		} else if pad.released[button] {
+			keyCallback(window, key, 0, glfw.Release, 0)
+		}
+	}
+}
+
+// LoadMappings reads SDL_GameControllerDB mappings from file and adds them to GLFW's
+// gamepad mappings. Empty lines and comments (lines starting with #) are ignored.
+func LoadMappings(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		mappings := ParseMappings(data)
+		if len(mappings) > 0 && !glfw.UpdateGamepadMappings(mappings) {
+			err = errors.New("gamepad mappings in " + path + " are not valid")
+		}
+	}
+	return err
+}
+
+// ParseMappings returns the mapping lines of an SDL_GameControllerDB file separated by
+// new lines. Empty lines and comments are removed.
+func ParseMappings(data []byte) string {
+	var builder strings.Builder
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) > 0 && line[0] != '#' {
+			builder.WriteString(line)
+			builder.WriteByte('\n')
+		}
+	}
+	return builder.String()
+}
+
+func radialDeadzone(x, y, deadzone float32) (float32, float32) {
+	length := float32(math.Sqrt(float64(x*x + y*y)))
+	if length <= deadzone {
+		return 0, 0
+	}
+	scaled := axialDeadzone(length, deadzone) / length
+	return x * scaled, y * scaled
+}
+
+func axialDeadzone(value, deadzone float32) float32 {
+	if value <= deadzone {
+		return 0
+	}
+	if value >= 1 {
+		return 1
+	}
+	return (value - deadzone) / (1 - deadzone)
+}
@@ -0,0 +1,158 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package input
+
+import (
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"math"
+	"testing"
+)
+
+func TestParseMappings(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"empty", "", ""},
+		{"comments only", "# Windows\n#a,b\n", ""},
+		{"mappings", "# Windows\nguid1,Pad 1,a:b0,\n\n  guid2,Pad 2,a:b1,  \r\n", "guid1,Pad 1,a:b0,\nguid2,Pad 2,a:b1,\n"},
+		{"no trailing new line", "guid1,Pad 1,a:b0,", "guid1,Pad 1,a:b0,\n"},
+	}
+	for _, test := range tests {
+		if got := ParseMappings([]byte(test.data)); got != test.want {
+			t.Errorf("%s: %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestAxialDeadzone(t *testing.T) {
+	tests := []struct {
+		value, deadzone, want float32
+	}{
+		{0, 0.2, 0},
+		{0.2, 0.2, 0},
+		{-0.5, 0.2, 0},
+		{0.6, 0.2, 0.5},
+		{1, 0.2, 1},
+		{1.5, 0.2, 1},
+		{0.5, 0, 0.5},
+	}
+	for _, test := range tests {
+		if got := axialDeadzone(test.value, test.deadzone); !approxEqual(got, test.want) {
+			t.Errorf("axialDeadzone(%g, %g) = %g, want %g", test.value, test.deadzone, got, test.want)
+		}
+	}
+}
+
+func TestRadialDeadzone(t *testing.T) {
+	tests := []struct {
+		name         string
+		x, y         float32
+		wantX, wantY float32
+	}{
+		{"center", 0, 0, 0, 0},
+		{"inside", 0.1, -0.1, 0, 0},
+		// each axis alone is inside, but the length is outside
+		{"diagonal", 0.3, 0.4, 0.225, 0.3},
+		{"half", -0.6, 0, -0.5, 0},
+		{"full", 0, 1, 0, 1},
+		{"beyond", 0, -1.2, 0, -1},
+	}
+	for _, test := range tests {
+		x, y := radialDeadzone(test.x, test.y, 0.2)
+		if !approxEqual(x, test.wantX) || !approxEqual(y, test.wantY) {
+			t.Errorf("%s: (%g, %g), want (%g, %g)", test.name, x, y, test.wantX, test.wantY)
+		}
+	}
+}
+
+func TestSendKeysOrder(t *testing.T) {
+	gamepads := NewGamepads()
+	pad := &gamepads.Pads[0]
+	for button := range pad.pressed {
+		pad.pressed[button] = true
+	}
+	var keys []glfw.Key
+	for i := 0; i < 10; i++ {
+		keys = keys[:0]
+		gamepads.sendKeys(nil, pad, func(window *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+			keys = append(keys, key)
+		})
+		// A, Back, Start, Up, Right, Down, Left
+		want := []glfw.Key{glfw.KeySpace, glfw.KeyEscape, glfw.KeyEnter, glfw.KeyUp, glfw.KeyRight, glfw.KeyDown, glfw.KeyLeft}
+		if len(keys) != len(want) {
+			t.Fatalf("%d keys, want %d", len(keys), len(want))
+		}
+		for j := range want {
+			if keys[j] != want[j] {
+				t.Fatalf("key %d is %d, want %d", j, keys[j], want[j])
+			}
+		}
+	}
+}
+
+func TestDisconnectReleasesButtons(t *testing.T) {
+	gamepads := NewGamepads()
+	pad := &gamepads.Pads[1]
+	pad.Connected, pad.Mapped = true, true
+	pad.Buttons[glfw.ButtonA] = true
+	pad.Buttons[glfw.ButtonX] = true
+	gamepads.OnJoystick(pad.Joystick, glfw.Disconnected)
+	if len(gamepads.Events) != 1 || gamepads.Events[0].Connected {
+		t.Fatal(gamepads.Events)
+	}
+	var released []glfw.Key
+	callback := func(window *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if action != glfw.Release {
+			t.Errorf("key %d action %d", key, action)
+		}
+		released = append(released, key)
+	}
+	gamepads.Update(nil, callback)
+	// X is not in the key map
+	if len(released) != 1 || released[0] != glfw.KeySpace {
+		t.Error(released)
+	}
+	if !pad.ButtonReleased(glfw.ButtonA) || !pad.ButtonReleased(glfw.ButtonX) || pad.Buttons[glfw.ButtonA] {
+		t.Error("buttons not released")
+	}
+	// events are kept until the end of the frame
+	if len(gamepads.Events) != 1 {
+		t.Error(gamepads.Events)
+	}
+	gamepads.EndFrame()
+	gamepads.Update(nil, callback)
+	if len(released) != 1 || pad.ButtonReleased(glfw.ButtonA) || len(gamepads.Events) != 0 {
+		t.Error(released, gamepads.Events)
+	}
+}
+
+func TestJoystickStateSet(t *testing.T) {
+	axes := make([]float32, MaxJoystickAxes+2)
+	buttons := make([]glfw.Action, MaxJoystickButtons+1)
+	for i := range axes {
+		axes[i] = float32(i)
+	}
+	buttons[1], buttons[2], buttons[MaxJoystickButtons] = glfw.Press, glfw.Repeat, glfw.Press
+	var state JoystickState
+	state.set(axes, buttons, []glfw.JoystickHatState{glfw.HatDown, glfw.HatCentered})
+	if state.AxisCount != MaxJoystickAxes || state.ButtonCount != MaxJoystickButtons || state.HatCount != 2 {
+		t.Fatalf("%d axes, %d buttons, %d hats", state.AxisCount, state.ButtonCount, state.HatCount)
+	}
+	if state.Axes[MaxJoystickAxes-1] != MaxJoystickAxes-1 || state.Buttons[0] || !state.Buttons[1] || state.Buttons[2] || state.Hats[0] != glfw.HatDown {
+		t.Errorf("%+v", state)
+	}
+	// fewer values reset the others
+	state.set(nil, buttons[:1], nil)
+	if state.AxisCount != 0 || state.ButtonCount != 1 || state.Axes[1] != 0 || state.Buttons[1] || state.Hats[0] != glfw.HatCentered {
+		t.Errorf("%+v", state)
+	}
+}
+
+func approxEqual(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-5
+}
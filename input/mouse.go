@@ -0,0 +1,161 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+// Package input tracks the state of input devices by GLFW callbacks.
+package input
+
+import (
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// Mouse is for storing the state of the mouse relative to a window.
+type Mouse struct {
+	// position in window (screen) coordinates
+	X, Y float64
+	// position in framebuffer (pixel) coordinates
+	FramebufferX, FramebufferY float64
+	// position in normalized device coordinates (-1 to 1, y up)
+	NDCX, NDCY float64
+	// motion since last call of EndFrame
+	DeltaX, DeltaY float64
+	// scroll offset since last call of EndFrame
+	ScrollX, ScrollY float64
+	// true, if cursor is inside the window's content area
+	Inside bool
+	// one of glfw.CursorNormal, glfw.CursorHidden or glfw.CursorDisabled
+	CursorMode int
+	// true, if raw mouse motion is used (only in glfw.CursorDisabled mode)
+	RawMotion bool
+	buttons   [glfw.MouseButtonLast + 1]bool
+	pressed   [glfw.MouseButtonLast + 1]bool
+	released  [glfw.MouseButtonLast + 1]bool
+	moved     bool
+}
+
+// NewMouse returns a new instance of Mouse.
+func NewMouse() *Mouse {
+	mouse := new(Mouse)
+	mouse.CursorMode = glfw.CursorNormal
+	return mouse
+}
+
+// Register sets the callbacks of window to the callbacks of mouse.
+func (mouse *Mouse) Register(window *glfw.Window) {
+	window.SetCursorPosCallback(mouse.OnCursorPos)
+	window.SetMouseButtonCallback(mouse.OnMouseButton)
+	window.SetScrollCallback(mouse.OnScroll)
+	window.SetCursorEnterCallback(mouse.OnCursorEnter)
+	mouse.X, mouse.Y = window.GetCursorPos()
+	mouse.updateCoords(window)
+}
+
+// OnCursorPos is a glfw.CursorPosCallback.
+func (mouse *Mouse) OnCursorPos(window *glfw.Window, x, y float64) {
+	if mouse.moved {
+		mouse.DeltaX += x - mouse.X
+		mouse.DeltaY += y - mouse.Y
+	}
+	mouse.X, mouse.Y = x, y
+	mouse.moved = true
+	mouse.updateCoords(window)
+}
+
+// OnMouseButton is a glfw.MouseButtonCallback.
+func (mouse *Mouse) OnMouseButton(window *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+	if button >= 0 && button <= glfw.MouseButtonLast {
+		if action == glfw.Press {
+			mouse.buttons[button] = true
+			mouse.pressed[button] = true
+		} else if action == glfw.Release {
+			mouse.buttons[button] = false
+			mouse.released[button] = true
+		}
+	}
+}
+
+// OnScroll is a glfw.ScrollCallback.
+func (mouse *Mouse) OnScroll(window *glfw.Window, xoff, yoff float64) {
+	mouse.ScrollX += xoff
+	mouse.ScrollY += yoff
+}
+
+// OnCursorEnter is a glfw.CursorEnterCallback.
+func (mouse *Mouse) OnCursorEnter(window *glfw.Window, entered bool) {
+	mouse.Inside = entered
+	// avoid jumps, when cursor reenters at a different position
+	mouse.moved = false
+}
+
+// ButtonDown returns true, if button is held down.
+func (mouse *Mouse) ButtonDown(button glfw.MouseButton) bool {
+	return mouse.buttons[button]
+}
+
+// ButtonPressed returns true, if button has been pressed since last call of EndFrame.
+func (mouse *Mouse) ButtonPressed(button glfw.MouseButton) bool {
+	return mouse.pressed[button]
+}
+
+// ButtonReleased returns true, if button has been released since last call of EndFrame.
+func (mouse *Mouse) ButtonReleased(button glfw.MouseButton) bool {
+	return mouse.released[button]
+}
+
+// Dragging returns true, if button is held down and the mouse has moved since last call of EndFrame.
+func (mouse *Mouse) Dragging(button glfw.MouseButton) bool {
+	return mouse.buttons[button] && (mouse.DeltaX != 0 || mouse.DeltaY != 0)
+}
+
+// EndFrame resets motion, scroll and button transitions. It should be called once per frame
+// before glfw.PollEvents.
+func (mouse *Mouse) EndFrame() {
+	mouse.DeltaX, mouse.DeltaY = 0, 0
+	mouse.ScrollX, mouse.ScrollY = 0, 0
+	for i := range mouse.pressed {
+		mouse.pressed[i] = false
+		mouse.released[i] = false
+	}
+}
+
+// SetCursorMode sets the cursor mode of window. Mode is one of glfw.CursorNormal,
+// glfw.CursorHidden or glfw.CursorDisabled. In disabled mode the cursor is captured
+// and raw mouse motion is used, if supported.
+func (mouse *Mouse) SetCursorMode(window *glfw.Window, mode int) {
+	raw := mode == glfw.CursorDisabled && glfw.RawMouseMotionSupported()
+	window.SetInputMode(glfw.CursorMode, mode)
+	if raw {
+		window.SetInputMode(glfw.RawMouseMotion, glfw.True)
+	} else if mouse.RawMotion {
+		window.SetInputMode(glfw.RawMouseMotion, glfw.False)
+	}
+	mouse.CursorMode = mode
+	mouse.RawMotion = raw
+	// cursor position changes with the mode, this must not count as motion
+	mouse.moved = false
+}
+
+// NextCursorMode switches the cursor mode of window from normal to hidden to disabled and
+// back to normal.
+func (mouse *Mouse) NextCursorMode(window *glfw.Window) {
+	switch mouse.CursorMode {
+	case glfw.CursorNormal:
+		mouse.SetCursorMode(window, glfw.CursorHidden)
+	case glfw.CursorHidden:
+		mouse.SetCursorMode(window, glfw.CursorDisabled)
+	default:
+		mouse.SetCursorMode(window, glfw.CursorNormal)
+	}
+}
+
+func (mouse *Mouse) updateCoords(window *glfw.Window) {
+	width, height := window.GetSize()
+	if width > 0 && height > 0 {
+		fbWidth, fbHeight := window.GetFramebufferSize()
+		mouse.FramebufferX = mouse.X * float64(fbWidth) / float64(width)
+		mouse.FramebufferY = mouse.Y * float64(fbHeight) / float64(height)
+		mouse.NDCX = mouse.X*2/float64(width) - 1
+		mouse.NDCY = 1 - mouse.Y*2/float64(height)
+	}
+}
@@ -0,0 +1,483 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package input
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"io"
+	"math"
+	"os"
+)
+
+// identifies files written by Recorder
+const recordMagic = "GLIR"
+const recordVersion = 4
+
+// EventType is the type of a recorded event.
+type EventType uint8
+
+// Event types.
+const (
+	EventKey EventType = iota + 1
+	EventMouseButton
+	EventCursorPos
+	EventScroll
+	EventCursorEnter
+	// framebuffer size in pixels
+	EventSize
+	// state of the gamepads, recorded when it changes
+	EventGamepad
+	eventEnd
+)
+
+// Event is a recorded input event.
+type Event struct {
+	Frame    uint64
+	Type     EventType
+	Key      glfw.Key
+	Scancode int
+	Action   glfw.Action
+	Mods     glfw.ModifierKey
+	Button   glfw.MouseButton
+	X, Y     float64
+	Entered  bool
+	Width    int
+	Height   int
+	Gamepad  GamepadState
+}
+
+// Callbacks is for storing the callbacks, that receive recorded or replayed events.
+// Nil callbacks are skipped.
+type Callbacks struct {
+	Key         glfw.KeyCallback
+	MouseButton glfw.MouseButtonCallback
+	CursorPos   glfw.CursorPosCallback
+	Scroll      glfw.ScrollCallback
+	CursorEnter glfw.CursorEnterCallback
+	Size        glfw.FramebufferSizeCallback
+}
+
+// Recorder records input events with frame numbers and forwards them to callbacks.
+type Recorder struct {
+	// fixed time step of the recorded frames in seconds
+	Timestep float64
+	// initial framebuffer size
+	Width, Height int
+	Frame         uint64
+	Events        []Event
+	Callbacks     Callbacks
+	// last recorded gamepad state
+	gamepad GamepadState
+}
+
+// Replay is for storing recorded events and replaying them frame by frame.
+type Replay struct {
+	Timestep float64
+	// initial framebuffer size
+	Width, Height int
+	// number of recorded frames
+	Frames uint64
+	// next frame to replay
+	Frame  uint64
+	Events []Event
+	// gamepad state of the last replayed frame
+	Gamepad GamepadState
+	next    int
+}
+
+// MouseCallbacks returns the callbacks of mouse together with key and framebuffer size
+// callbacks.
+func MouseCallbacks(mouse *Mouse, key glfw.KeyCallback, size glfw.FramebufferSizeCallback) Callbacks {
+	return Callbacks{Key: key, MouseButton: mouse.OnMouseButton, CursorPos: mouse.OnCursorPos, Scroll: mouse.OnScroll, CursorEnter: mouse.OnCursorEnter, Size: size}
+}
+
+// NewRecorder returns a new instance of Recorder.
+func NewRecorder(window *glfw.Window, timestep float64, callbacks Callbacks) *Recorder {
+	recorder := new(Recorder)
+	recorder.Timestep = timestep
+	recorder.Width, recorder.Height = window.GetFramebufferSize()
+	recorder.Callbacks = callbacks
+	return recorder
+}
+
+// Register sets the callbacks of window to the callbacks of recorder.
+func (recorder *Recorder) Register(window *glfw.Window) {
+	window.SetKeyCallback(recorder.OnKey)
+	window.SetMouseButtonCallback(recorder.OnMouseButton)
+	window.SetCursorPosCallback(recorder.OnCursorPos)
+	window.SetScrollCallback(recorder.OnScroll)
+	window.SetCursorEnterCallback(recorder.OnCursorEnter)
+	window.SetFramebufferSizeCallback(recorder.OnSize)
+}
+
+// NextFrame increments the frame number. It should be called once per frame after glfw.PollEvents.
+func (recorder *Recorder) NextFrame() {
+	recorder.Frame++
+}
+
+// OnKey is a glfw.KeyCallback.
+func (recorder *Recorder) OnKey(window *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+	recorder.Events = append(recorder.Events, Event{Frame: recorder.Frame, Type: EventKey, Key: key, Scancode: scancode, Action: action, Mods: mods})
+	recorder.Callbacks.dispatch(window, &recorder.Events[len(recorder.Events)-1])
+}
+
+// OnMouseButton is a glfw.MouseButtonCallback.
+func (recorder *Recorder) OnMouseButton(window *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+	recorder.Events = append(recorder.Events, Event{Frame: recorder.Frame, Type: EventMouseButton, Button: button, Action: action, Mods: mods})
+	recorder.Callbacks.dispatch(window, &recorder.Events[len(recorder.Events)-1])
+}
+
+// OnCursorPos is a glfw.CursorPosCallback.
+func (recorder *Recorder) OnCursorPos(window *glfw.Window, x, y float64) {
+	recorder.Events = append(recorder.Events, Event{Frame: recorder.Frame, Type: EventCursorPos, X: x, Y: y})
+	recorder.Callbacks.dispatch(window, &recorder.Events[len(recorder.Events)-1])
+}
+
+// OnScroll is a glfw.ScrollCallback.
+func (recorder *Recorder) OnScroll(window *glfw.Window, xoff, yoff float64) {
+	recorder.Events = append(recorder.Events, Event{Frame: recorder.Frame, Type: EventScroll, X: xoff, Y: yoff})
+	recorder.Callbacks.dispatch(window, &recorder.Events[len(recorder.Events)-1])
+}
+
+// OnCursorEnter is a glfw.CursorEnterCallback.
+func (recorder *Recorder) OnCursorEnter(window *glfw.Window, entered bool) {
+	recorder.Events = append(recorder.Events, Event{Frame: recorder.Frame, Type: EventCursorEnter, Entered: entered})
+	recorder.Callbacks.dispatch(window, &recorder.Events[len(recorder.Events)-1])
+}
+
+// OnSize is a glfw.FramebufferSizeCallback. The framebuffer size is recorded, not
+// the window size, which differs on high DPI displays.
+func (recorder *Recorder) OnSize(window *glfw.Window, width, height int) {
+	recorder.Events = append(recorder.Events, Event{Frame: recorder.Frame, Type: EventSize, Width: width, Height: height})
+	recorder.Callbacks.dispatch(window, &recorder.Events[len(recorder.Events)-1])
+}
+
+// RecordGamepad records state, if it differs from the last recorded state. It should
+// be called once per frame after Gamepads.Update.
+func (recorder *Recorder) RecordGamepad(state GamepadState) {
+	if state != recorder.gamepad {
+		recorder.Events = append(recorder.Events, Event{Frame: recorder.Frame, Type: EventGamepad, Gamepad: state})
+		recorder.gamepad = state
+	}
+}
+
+// Save writes the recorded events to file.
+func (recorder *Recorder) Save(path string) error {
+	file, err := os.Create(path)
+	if err == nil {
+		err = recorder.Write(file)
+		errClose := file.Close()
+		if err == nil {
+			err = errClose
+		}
+	}
+	return err
+}
+
+// Write writes the recorded events to w. The frame numbers are delta encoded
+// and integers are variable-length encoded.
+func (recorder *Recorder) Write(w io.Writer) error {
+	writer := bufio.NewWriter(w)
+	enc := encoder{writer: writer}
+	enc.bytes([]byte(recordMagic))
+	enc.uvarint(recordVersion)
+	enc.float(recorder.Timestep)
+	enc.uvarint(uint64(recorder.Width))
+	enc.uvarint(uint64(recorder.Height))
+	frame := uint64(0)
+	for i := range recorder.Events {
+		event := &recorder.Events[i]
+		enc.uvarint(event.Frame - frame)
+		enc.bytes([]byte{byte(event.Type)})
+		frame = event.Frame
+		switch event.Type {
+		case EventKey:
+			enc.varint(int64(event.Key))
+			enc.varint(int64(event.Scancode))
+			enc.bytes([]byte{byte(event.Action), byte(event.Mods)})
+		case EventMouseButton:
+			enc.bytes([]byte{byte(event.Button), byte(event.Action), byte(event.Mods)})
+		case EventCursorPos, EventScroll:
+			enc.float(event.X)
+			enc.float(event.Y)
+		case EventCursorEnter:
+			if event.Entered {
+				enc.bytes([]byte{1})
+			} else {
+				enc.bytes([]byte{0})
+			}
+		case EventSize:
+			enc.uvarint(uint64(event.Width))
+			enc.uvarint(uint64(event.Height))
+		case EventGamepad:
+			enc.uvarint(uint64(event.Gamepad.Buttons))
+			for _, axis := range event.Gamepad.Axes {
+				enc.float(float64(axis))
+			}
+			enc.joystick(&event.Gamepad.Joystick)
+		}
+	}
+	// end marker with total number of frames
+	enc.uvarint(recorder.Frame - frame)
+	enc.bytes([]byte{byte(eventEnd)})
+	if enc.err == nil {
+		enc.err = writer.Flush()
+	}
+	return enc.err
+}
+
+// LoadReplay reads recorded events from file.
+func LoadReplay(path string) (*Replay, error) {
+	var replay *Replay
+	file, err := os.Open(path)
+	if err == nil {
+		defer file.Close()
+		replay, err = ReadReplay(file)
+	}
+	return replay, err
+}
+
+// ReadReplay reads recorded events from r.
+func ReadReplay(r io.Reader) (*Replay, error) {
+	replay := new(Replay)
+	dec := decoder{reader: bufio.NewReader(r)}
+	magic := dec.bytes(len(recordMagic))
+	version := dec.uvarint()
+	if dec.err == nil && (string(magic) != recordMagic || version != recordVersion) {
+		return nil, errors.New("input record has wrong format or version")
+	}
+	replay.Timestep = dec.float()
+	replay.Width = int(dec.uvarint())
+	replay.Height = int(dec.uvarint())
+	frame := uint64(0)
+	for dec.err == nil {
+		var event Event
+		frame += dec.uvarint()
+		event.Frame = frame
+		event.Type = EventType(dec.byte())
+		switch event.Type {
+		case EventKey:
+			event.Key = glfw.Key(dec.varint())
+			event.Scancode = int(dec.varint())
+			event.Action = glfw.Action(dec.byte())
+			event.Mods = glfw.ModifierKey(dec.byte())
+		case EventMouseButton:
+			event.Button = glfw.MouseButton(dec.byte())
+			event.Action = glfw.Action(dec.byte())
+			event.Mods = glfw.ModifierKey(dec.byte())
+		case EventCursorPos, EventScroll:
+			event.X = dec.float()
+			event.Y = dec.float()
+		case EventCursorEnter:
+			event.Entered = dec.byte() != 0
+		case EventSize:
+			event.Width = int(dec.uvarint())
+			event.Height = int(dec.uvarint())
+		case EventGamepad:
+			event.Gamepad.Buttons = uint32(dec.uvarint())
+			for i := range event.Gamepad.Axes {
+				event.Gamepad.Axes[i] = float32(dec.float())
+			}
+			dec.joystick(&event.Gamepad.Joystick)
+		case eventEnd:
+			replay.Frames = frame
+			return replay, dec.err
+		default:
+			if dec.err == nil {
+				dec.err = errors.New("input record has unknown event type")
+			}
+		}
+		if dec.err == nil {
+			replay.Events = append(replay.Events, event)
+		}
+	}
+	if dec.err == io.EOF {
+		dec.err = io.ErrUnexpectedEOF
+	}
+	return nil, dec.err
+}
+
+// Dispatch passes all events of the current frame to callbacks, updates Gamepad and
+// increments the frame number. Window (may be nil) is resized to the recorded
+// framebuffer sizes. Dispatch should be called once per frame instead of
+// glfw.PollEvents.
+func (replay *Replay) Dispatch(window *glfw.Window, callbacks Callbacks) {
+	for replay.next < len(replay.Events) && replay.Events[replay.next].Frame == replay.Frame {
+		event := &replay.Events[replay.next]
+		if event.Type == EventGamepad {
+			replay.Gamepad = event.Gamepad
+		} else {
+			if event.Type == EventSize && window != nil {
+				SetFramebufferSize(window, event.Width, event.Height)
+			}
+			callbacks.dispatch(window, event)
+		}
+		replay.next++
+	}
+	replay.Frame++
+}
+
+// SetFramebufferSize resizes window, so that its framebuffer has width x height
+// pixels. The window size differs from the framebuffer size on high DPI displays.
+func SetFramebufferSize(window *glfw.Window, width, height int) {
+	windowWidth, windowHeight := window.GetSize()
+	framebufferWidth, framebufferHeight := window.GetFramebufferSize()
+	if framebufferWidth > 0 && framebufferHeight > 0 {
+		width = (width*windowWidth + framebufferWidth/2) / framebufferWidth
+		height = (height*windowHeight + framebufferHeight/2) / framebufferHeight
+	}
+	window.SetSize(width, height)
+}
+
+// Done returns true, if all recorded frames have been replayed.
+func (replay *Replay) Done() bool {
+	return replay.Frame >= replay.Frames
+}
+
+func (callbacks *Callbacks) dispatch(window *glfw.Window, event *Event) {
+	switch event.Type {
+	case EventKey:
+		if callbacks.Key != nil {
+			callbacks.Key(window, event.Key, event.Scancode, event.Action, event.Mods)
+		}
+	case EventMouseButton:
+		if callbacks.MouseButton != nil {
+			callbacks.MouseButton(window, event.Button, event.Action, event.Mods)
+		}
+	case EventCursorPos:
+		if callbacks.CursorPos != nil {
+			callbacks.CursorPos(window, event.X, event.Y)
+		}
+	case EventScroll:
+		if callbacks.Scroll != nil {
+			callbacks.Scroll(window, event.X, event.Y)
+		}
+	case EventCursorEnter:
+		if callbacks.CursorEnter != nil {
+			callbacks.CursorEnter(window, event.Entered)
+		}
+	case EventSize:
+		if callbacks.Size != nil {
+			callbacks.Size(window, event.Width, event.Height)
+		}
+	}
+}
+
+type encoder struct {
+	writer *bufio.Writer
+	buffer [binary.MaxVarintLen64]byte
+	err    error
+}
+
+func (enc *encoder) bytes(data []byte) {
+	if enc.err == nil {
+		_, enc.err = enc.writer.Write(data)
+	}
+}
+
+func (enc *encoder) uvarint(value uint64) {
+	n := binary.PutUvarint(enc.buffer[:], value)
+	enc.bytes(enc.buffer[:n])
+}
+
+func (enc *encoder) varint(value int64) {
+	n := binary.PutVarint(enc.buffer[:], value)
+	enc.bytes(enc.buffer[:n])
+}
+
+func (enc *encoder) float(value float64) {
+	binary.LittleEndian.PutUint64(enc.buffer[:8], math.Float64bits(value))
+	enc.bytes(enc.buffer[:8])
+}
+
+// joystick writes the counts, the axes, the buttons as bits and the hats.
+func (enc *encoder) joystick(state *JoystickState) {
+	var buttons uint64
+	for i, down := range state.Buttons[:state.ButtonCount] {
+		if down {
+			buttons |= 1 << uint(i)
+		}
+	}
+	enc.bytes([]byte{byte(state.AxisCount), byte(state.ButtonCount), byte(state.HatCount)})
+	for _, axis := range state.Axes[:state.AxisCount] {
+		enc.float(float64(axis))
+	}
+	enc.uvarint(buttons)
+	for _, hat := range state.Hats[:state.HatCount] {
+		enc.uvarint(uint64(hat))
+	}
+}
+
+type decoder struct {
+	reader *bufio.Reader
+	err    error
+}
+
+func (dec *decoder) bytes(n int) []byte {
+	data := make([]byte, n)
+	if dec.err == nil {
+		_, dec.err = io.ReadFull(dec.reader, data)
+	}
+	return data
+}
+
+func (dec *decoder) byte() byte {
+	var value byte
+	if dec.err == nil {
+		value, dec.err = dec.reader.ReadByte()
+	}
+	return value
+}
+
+func (dec *decoder) uvarint() uint64 {
+	var value uint64
+	if dec.err == nil {
+		value, dec.err = binary.ReadUvarint(dec.reader)
+	}
+	return value
+}
+
+func (dec *decoder) varint() int64 {
+	var value int64
+	if dec.err == nil {
+		value, dec.err = binary.ReadVarint(dec.reader)
+	}
+	return value
+}
+
+func (dec *decoder) joystick(state *JoystickState) {
+	state.AxisCount = int(dec.byte())
+	state.ButtonCount = int(dec.byte())
+	state.HatCount = int(dec.byte())
+	if state.AxisCount > MaxJoystickAxes || state.ButtonCount > MaxJoystickButtons || state.HatCount > MaxJoystickHats {
+		if dec.err == nil {
+			dec.err = errors.New("input record has too many joystick axes, buttons or hats")
+		}
+		*state = JoystickState{}
+		return
+	}
+	for i := range state.Axes[:state.AxisCount] {
+		state.Axes[i] = float32(dec.float())
+	}
+	buttons := dec.uvarint()
+	for i := range state.Buttons[:state.ButtonCount] {
+		state.Buttons[i] = buttons&(1<<uint(i)) != 0
+	}
+	for i := range state.Hats[:state.HatCount] {
+		state.Hats[i] = glfw.JoystickHatState(dec.uvarint())
+	}
+}
+
+func (dec *decoder) float() float64 {
+	var value float64
+	if dec.err == nil {
+		value = math.Float64frombits(binary.LittleEndian.Uint64(dec.bytes(8)))
+	}
+	return value
+}
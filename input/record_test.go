@@ -0,0 +1,171 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package input
+
+import (
+	"bytes"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"io"
+	"testing"
+)
+
+// newTestRecorder returns a recorder with events of all types in several frames.
+func newTestRecorder() *Recorder {
+	recorder := &Recorder{Timestep: 1.0 / 60.0, Width: 300, Height: 200}
+	recorder.OnKey(nil, glfw.KeyA, 30, glfw.Press, glfw.ModShift)
+	recorder.OnCursorPos(nil, 10.5, -3.25)
+	recorder.NextFrame()
+	recorder.OnMouseButton(nil, glfw.MouseButtonRight, glfw.Release, glfw.ModControl)
+	recorder.RecordGamepad(GamepadState{Buttons: 1<<uint(glfw.ButtonA) | 1<<uint(glfw.ButtonDpadLeft), Axes: [6]float32{0.25, -1, 0, 0.125, 0, 1}})
+	recorder.NextFrame()
+	joystick := JoystickState{AxisCount: 3, ButtonCount: 12, HatCount: 1}
+	joystick.Axes[0], joystick.Axes[2] = -0.5, 1
+	joystick.Buttons[0], joystick.Buttons[11] = true, true
+	joystick.Hats[0] = glfw.HatLeft
+	recorder.RecordGamepad(GamepadState{Buttons: 1 << uint(glfw.ButtonA), Axes: [6]float32{0.25}, Joystick: joystick})
+	recorder.NextFrame()
+	recorder.OnScroll(nil, 0, -2)
+	recorder.OnCursorEnter(nil, true)
+	recorder.OnSize(nil, 640, 480)
+	// unchanged, not recorded
+	recorder.RecordGamepad(recorder.gamepad)
+	recorder.RecordGamepad(GamepadState{})
+	recorder.NextFrame()
+	recorder.NextFrame()
+	return recorder
+}
+
+func TestRecordRoundTrip(t *testing.T) {
+	recorder := newTestRecorder()
+	var buffer bytes.Buffer
+	if err := recorder.Write(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	replay, err := ReadReplay(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replay.Timestep != recorder.Timestep || replay.Width != 300 || replay.Height != 200 || replay.Frames != 5 {
+		t.Error(replay.Timestep, replay.Width, replay.Height, replay.Frames)
+	}
+	if len(replay.Events) != len(recorder.Events) || len(replay.Events) != 9 {
+		t.Fatalf("%d events, want %d", len(replay.Events), len(recorder.Events))
+	}
+	for i := range recorder.Events {
+		if replay.Events[i] != recorder.Events[i] {
+			t.Errorf("event %d: %+v, want %+v", i, replay.Events[i], recorder.Events[i])
+		}
+	}
+}
+
+func TestReplayDispatch(t *testing.T) {
+	var buffer bytes.Buffer
+	newTestRecorder().Write(&buffer)
+	replay, _ := ReadReplay(&buffer)
+	var keys, sizes int
+	callbacks := Callbacks{
+		Key: func(window *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+			keys++
+		},
+		Size: func(window *glfw.Window, width, height int) { sizes++ },
+	}
+	axes := []float32{0, 0.25, 0.25, 0}
+	for frame := 0; !replay.Done(); frame++ {
+		replay.Dispatch(nil, callbacks)
+		if frame < len(axes) && replay.Gamepad.Axes[0] != axes[frame] {
+			t.Errorf("frame %d: axis %g, want %g", frame, replay.Gamepad.Axes[0], axes[frame])
+		}
+	}
+	if keys != 1 || sizes != 1 || replay.Frame != 5 {
+		t.Error(keys, sizes, replay.Frame)
+	}
+}
+
+func TestReplayResize(t *testing.T) {
+	recorder := &Recorder{Timestep: 1.0 / 60.0, Width: 300, Height: 200}
+	recorder.NextFrame()
+	recorder.OnSize(nil, 640, 480)
+	recorder.NextFrame()
+	recorder.NextFrame()
+	recorder.OnSize(nil, 0, 0)
+	recorder.OnSize(nil, 1280, 960)
+	recorder.NextFrame()
+	var buffer bytes.Buffer
+	recorder.Write(&buffer)
+	replay, err := ReadReplay(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type size struct{ frame, width, height int }
+	var sizes []size
+	callbacks := Callbacks{Size: func(window *glfw.Window, width, height int) {
+		sizes = append(sizes, size{int(replay.Frame), width, height})
+	}}
+	for !replay.Done() {
+		replay.Dispatch(nil, callbacks)
+	}
+	want := []size{{1, 640, 480}, {3, 0, 0}, {3, 1280, 960}}
+	if replay.Width != 300 || replay.Height != 200 || len(sizes) != len(want) {
+		t.Fatalf("%dx%d, %v", replay.Width, replay.Height, sizes)
+	}
+	for i := range want {
+		if sizes[i] != want[i] {
+			t.Errorf("resize %d: %v, want %v", i, sizes[i], want[i])
+		}
+	}
+}
+
+func TestReadReplayVersion(t *testing.T) {
+	var buffer bytes.Buffer
+	newTestRecorder().Write(&buffer)
+	data := buffer.Bytes()
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"magic", append([]byte("XXXX"), data[len(recordMagic):]...)},
+		{"old version", append([]byte(recordMagic+string(rune(recordVersion-1))), data[len(recordMagic)+1:]...)},
+		{"new version", append([]byte(recordMagic+string(rune(recordVersion+1))), data[len(recordMagic)+1:]...)},
+	}
+	for _, test := range tests {
+		if replay, err := ReadReplay(bytes.NewReader(test.data)); err == nil || replay != nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+}
+
+func TestReadReplayTruncated(t *testing.T) {
+	var buffer bytes.Buffer
+	newTestRecorder().Write(&buffer)
+	data := buffer.Bytes()
+	for n := 0; n < len(data); n++ {
+		replay, err := ReadReplay(bytes.NewReader(data[:n]))
+		if err == nil || replay != nil {
+			t.Fatalf("%d of %d bytes: no error", n, len(data))
+		}
+		if n > len(recordMagic) && err != io.ErrUnexpectedEOF {
+			t.Errorf("%d of %d bytes: %v", n, len(data), err)
+		}
+	}
+}
+
+func TestGamepadStateRoundTrip(t *testing.T) {
+	gamepads := NewGamepads()
+	state := GamepadState{Buttons: 1 << uint(glfw.ButtonB), Axes: [6]float32{0, 0.5, -0.5, 0, 1, 0}}
+	state.Joystick.set([]float32{0.5, -1}, []glfw.Action{glfw.Release, glfw.Press}, []glfw.JoystickHatState{glfw.HatUp})
+	gamepads.SetState(state)
+	if gamepads.State() != state || !gamepads.ButtonPressed(glfw.ButtonB) || gamepads.Pads[0].Raw.Axes[1] != -1 {
+		t.Error(gamepads.State())
+	}
+	gamepads.SetState(state)
+	if gamepads.ButtonPressed(glfw.ButtonB) || gamepads.Axis(glfw.AxisLeftY) != 0.5 {
+		t.Error("button pressed again")
+	}
+	gamepads.SetState(GamepadState{})
+	if !gamepads.Pads[0].ButtonReleased(glfw.ButtonB) {
+		t.Error("button not released")
+	}
+}
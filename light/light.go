@@ -0,0 +1,140 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+// Package light provides directional, point and spot lights and a Blinn-Phong
+// program, that takes the lights as uniform array.
+package light
+
+import (
+	"github.com/vbsw/opengl-go-example/scene"
+	"github.com/vbsw/opengl-go-example/vmath"
+	"math"
+)
+
+// Kind is the type of a light. The values are used in the shader.
+type Kind int32
+
+// Kinds of lights.
+const (
+	// Directional light has a direction, but no position (e.g. sun).
+	Directional Kind = iota
+	// Point light shines in all directions and is attenuated by distance.
+	Point
+	// Spot light is a point light restricted to a cone.
+	Spot
+)
+
+// Light is a light source. Position and direction are relative to the node the
+// light is attached to (see Transform).
+type Light struct {
+	Kind      Kind
+	Color     vmath.Vec3
+	Intensity float32
+	// position of point and spot lights
+	Position vmath.Vec3
+	// normalized direction the light shines to (directional and spot lights)
+	Direction vmath.Vec3
+	// constant, linear and quadratic attenuation of point and spot lights
+	Attenuation vmath.Vec3
+	// half angles of the cone of spot lights in radians: full intensity inside the
+	// inner angle, falling off to zero at the outer angle
+	InnerAngle, OuterAngle float32
+}
+
+// NewDirectional returns a new directional light.
+func NewDirectional(direction, color vmath.Vec3, intensity float32) *Light {
+	return &Light{Kind: Directional, Color: color, Intensity: intensity, Direction: direction.Normalize()}
+}
+
+// NewPoint returns a new point light, whose light fades out at distance radius.
+func NewPoint(position, color vmath.Vec3, intensity, radius float32) *Light {
+	return &Light{Kind: Point, Color: color, Intensity: intensity, Position: position, Attenuation: AttenuationForRadius(radius)}
+}
+
+// NewSpot returns a new spot light. The cone is given by inner and outer half
+// angles in radians.
+func NewSpot(position, direction, color vmath.Vec3, intensity, radius, inner, outer float32) *Light {
+	spot := NewPoint(position, color, intensity, radius)
+	spot.Kind = Spot
+	spot.Direction = direction.Normalize()
+	spot.InnerAngle, spot.OuterAngle = inner, outer
+	return spot
+}
+
+// AttenuationForRadius returns constant, linear and quadratic attenuation, that
+// reduces the light to about 1% at distance radius.
+func AttenuationForRadius(radius float32) vmath.Vec3 {
+	if radius <= 0 {
+		return vmath.Vec3{1, 0, 0}
+	}
+	return vmath.Vec3{1, 4.5 / radius, 75 / (radius * radius)}
+}
+
+// Radiance returns color times intensity, which is the value uploaded to the shader.
+func (light *Light) Radiance() vmath.Vec3 {
+	return light.Color.Scale(light.Intensity)
+}
+
+// AttenuationAt returns the attenuation factor at distance. It is 1 for directional
+// lights.
+func (light *Light) AttenuationAt(distance float32) float32 {
+	if light.Kind == Directional {
+		return 1
+	}
+	a := light.Attenuation
+	return 1 / (a[0] + a[1]*distance + a[2]*distance*distance)
+}
+
+// ConeAt returns the falloff factor of a spot light in direction (normalized, from
+// the light to the lit point). It is 1 for other lights.
+func (light *Light) ConeAt(direction vmath.Vec3) float32 {
+	if light.Kind != Spot {
+		return 1
+	}
+	inner, outer := light.ConeCos()
+	return smoothstep(outer, inner, direction.Dot(light.Direction))
+}
+
+// ConeCos returns the cosines of the inner and outer angle.
+func (light *Light) ConeCos() (float32, float32) {
+	return float32(math.Cos(float64(light.InnerAngle))), float32(math.Cos(float64(light.OuterAngle)))
+}
+
+// Transform returns the light with position and direction transformed by world.
+func (light Light) Transform(world vmath.Mat4) Light {
+	light.Position = world.TransformPoint(light.Position)
+	if light.Kind != Point {
+		light.Direction = world.TransformDir(light.Direction).Normalize()
+	}
+	return light
+}
+
+// Collect appends the lights of a scene draw list in world space to lights. Lights
+// of nodes must be of type *Light, others are ignored.
+func Collect(items []scene.LightItem, lights []Light) []Light {
+	for _, item := range items {
+		if light, ok := item.Light.(*Light); ok {
+			lights = append(lights, light.Transform(item.World))
+		}
+	}
+	return lights
+}
+
+// smoothstep is GLSL's smoothstep.
+func smoothstep(edge0, edge1, x float32) float32 {
+	if edge0 == edge1 {
+		if x < edge0 {
+			return 0
+		}
+		return 1
+	}
+	t := (x - edge0) / (edge1 - edge0)
+	if t < 0 {
+		t = 0
+	} else if t > 1 {
+		t = 1
+	}
+	return t * t * (3 - 2*t)
+}
@@ -0,0 +1,108 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package light
+
+import (
+	"github.com/vbsw/opengl-go-example/scene"
+	"github.com/vbsw/opengl-go-example/vmath"
+	"math"
+	"testing"
+)
+
+func TestAttenuation(t *testing.T) {
+	point := NewPoint(vmath.Vec3{}, vmath.Vec3{1, 1, 1}, 1, 10)
+	tests := []struct {
+		name     string
+		light    *Light
+		distance float32
+		min, max float32
+	}{
+		{"point at light", point, 0, 1, 1},
+		{"point half radius", point, 5, 0.03, 0.1},
+		{"point at radius", point, 10, 0.005, 0.015},
+		{"directional", NewDirectional(vmath.Vec3{0, -1, 0}, vmath.Vec3{1, 1, 1}, 1), 1000, 1, 1},
+		{"infinite radius", NewPoint(vmath.Vec3{}, vmath.Vec3{1, 1, 1}, 1, 0), 1000, 1, 1},
+	}
+	for _, test := range tests {
+		if got := test.light.AttenuationAt(test.distance); got < test.min || got > test.max {
+			t.Errorf("%s: got %v, want %v to %v", test.name, got, test.min, test.max)
+		}
+	}
+	if point.AttenuationAt(2) <= point.AttenuationAt(3) {
+		t.Error("attenuation must decrease with distance")
+	}
+}
+
+func TestCone(t *testing.T) {
+	spot := NewSpot(vmath.Vec3{}, vmath.Vec3{0, -2, 0}, vmath.Vec3{1, 1, 1}, 1, 10, math.Pi/8, math.Pi/4)
+	tests := []struct {
+		name  string
+		angle float64
+		want  float32
+	}{
+		{"center", 0, 1},
+		{"inner", math.Pi / 10, 1},
+		{"outer", math.Pi / 3, 0},
+		{"behind", math.Pi, 0},
+	}
+	for _, test := range tests {
+		direction := vmath.Vec3{float32(math.Sin(test.angle)), -float32(math.Cos(test.angle)), 0}
+		if got := spot.ConeAt(direction); !vmath.ApproxEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+	between := vmath.Vec3{float32(math.Sin(3 * math.Pi / 16)), -float32(math.Cos(3 * math.Pi / 16)), 0}
+	if got := spot.ConeAt(between); got <= 0 || got >= 1 {
+		t.Errorf("between inner and outer: %v", got)
+	}
+	hard := NewSpot(vmath.Vec3{}, vmath.Vec3{0, -1, 0}, vmath.Vec3{1, 1, 1}, 1, 10, math.Pi/4, math.Pi/4)
+	if got := hard.ConeAt(vmath.Vec3{0, -1, 0}); got != 1 {
+		t.Errorf("equal angles: %v", got)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	root := scene.NewNode("root")
+	root.SetPosition(vmath.Vec3{0, 5, 0})
+	node := scene.NewNode("spot")
+	node.SetRotation(vmath.QuatAxisAngle(vmath.Vec3{0, 0, 1}, math.Pi/2))
+	node.Light = NewSpot(vmath.Vec3{1, 0, 0}, vmath.Vec3{1, 0, 0}, vmath.Vec3{1, 1, 1}, 1, 10, 0.2, 0.4)
+	root.Add(node)
+	other := scene.NewNode("other")
+	other.Light = "not a light"
+	root.Add(other)
+
+	var list scene.DrawList
+	list.Collect(root)
+	lights := Collect(list.Lights, nil)
+	if len(lights) != 1 {
+		t.Fatalf("got %d lights, want 1", len(lights))
+	}
+	if !lights[0].Position.ApproxEqual(vmath.Vec3{0, 6, 0}) {
+		t.Errorf("position: %v", lights[0].Position)
+	}
+	if !lights[0].Direction.ApproxEqual(vmath.Vec3{0, 1, 0}) {
+		t.Errorf("direction: %v", lights[0].Direction)
+	}
+	if node.Light.(*Light).Position != (vmath.Vec3{1, 0, 0}) {
+		t.Error("light of node modified")
+	}
+}
+
+func BenchmarkCollect(b *testing.B) {
+	root := scene.NewNode("root")
+	for i := 0; i < 16; i++ {
+		node := scene.NewNode("light")
+		node.Light = NewPoint(vmath.Vec3{float32(i), 0, 0}, vmath.Vec3{1, 1, 1}, 1, 10)
+		root.Add(node)
+	}
+	var list scene.DrawList
+	list.Collect(root)
+	var lights []Light
+	for i := 0; i < b.N; i++ {
+		lights = Collect(list.Lights, lights[:0])
+	}
+}
@@ -0,0 +1,316 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package light
+
+import (
+	"fmt"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/vbsw/opengl-go-example/gfx"
+	"github.com/vbsw/opengl-go-example/vmath"
+)
+
+// VertexShader transforms position and normal to world space.
+const VertexShader = `#version 130
+
+in vec3 positionIn;
+in vec3 normalIn;
+uniform mat4 projection;
+uniform mat4 view;
+uniform mat4 model;
+uniform mat3 normalMatrix;
+out vec3 worldPosition;
+out vec3 worldNormal;
+
+void main() {
+	vec4 position = model * vec4(positionIn, 1.0);
+	gl_Position = projection * view * position;
+	worldPosition = position.xyz;
+	worldNormal = normalMatrix * normalIn;
+}
+`
+
+// LightsShader declares the uniform array of lights set by Uniforms and the function
+// lightDirection. It requires MAX_LIGHTS to be defined.
+const LightsShader = `
+struct Light {
+	int kind;
+	vec3 color;
+	vec3 position;
+	vec3 direction;
+	vec3 attenuation;
+	// cosine of inner and outer angle
+	vec2 cone;
+};
+
+uniform Light lights[MAX_LIGHTS];
+uniform int lightCount;
+uniform vec3 ambient;
+
+// lightDirection returns the normalized direction from position to light i and its
+// attenuation and cone falloff in factor.
+vec3 lightDirection(int i, vec3 position, out float factor) {
+	factor = 1.0;
+	if (lights[i].kind == 0) {
+		return -lights[i].direction;
+	}
+	vec3 toLight = lights[i].position - position;
+	float distance = length(toLight);
+	vec3 a = lights[i].attenuation;
+	toLight /= distance;
+	factor = 1.0 / (a.x + a.y * distance + a.z * distance * distance);
+	if (lights[i].kind == 2) {
+		factor *= smoothstep(lights[i].cone.y, lights[i].cone.x, dot(-toLight, lights[i].direction));
+	}
+	return toLight;
+}
+`
+
+// FragmentShader is Blinn-Phong shading with ambient, diffuse and specular terms.
+// It follows LightsShader. With SHADOWS defined one directional light is shadowed
+// by cascaded shadow maps and one spot light by a shadow map.
+const FragmentShader = `
+in vec3 worldPosition;
+in vec3 worldNormal;
+uniform vec3 cameraPosition;
+uniform vec3 diffuse;
+uniform vec3 specular;
+uniform float shininess;
+uniform vec3 emissive;
+out vec4 color;
+
+#ifdef SHADOWS
+uniform mat4 view;
+uniform sampler2DShadow cascadeMaps[MAX_CASCADES];
+uniform mat4 cascadeMatrices[MAX_CASCADES];
+// far view distance of each cascade
+uniform float cascadeSplits[MAX_CASCADES];
+uniform int cascadeCount;
+// index of the light with cascaded shadow maps or -1
+uniform int cascadeLight;
+uniform sampler2DShadow spotMap;
+uniform mat4 spotMatrix;
+// index of the spot light with shadow map or -1
+uniform int spotLight;
+uniform float depthBias;
+uniform float slopeBias;
+// PCF samples (2 * pcfRadius + 1)^2 texels, each filtered bilinear by the hardware
+uniform int pcfRadius;
+
+float pcf(sampler2DShadow map, mat4 matrix, float bias) {
+	vec4 lightSpace = matrix * vec4(worldPosition, 1.0);
+	vec3 p = lightSpace.xyz / lightSpace.w * 0.5 + 0.5;
+	if (p.z > 1.0 || any(lessThan(p.xy, vec2(0.0))) || any(greaterThan(p.xy, vec2(1.0)))) {
+		return 1.0;
+	}
+	vec2 texel = 1.0 / vec2(textureSize(map, 0));
+	float sum = 0.0;
+	for (int x = -pcfRadius; x <= pcfRadius; x++) {
+		for (int y = -pcfRadius; y <= pcfRadius; y++) {
+			sum += texture(map, vec3(p.xy + vec2(x, y) * texel, p.z - bias));
+		}
+	}
+	float n = float(2 * pcfRadius + 1);
+	return sum / (n * n);
+}
+
+// shadow returns 0 (in shadow) to 1 (lit). Samplers in arrays must be indexed with
+// constants in GLSL 1.30.
+float shadow(int light, vec3 normal, vec3 toLight) {
+	float bias = depthBias + slopeBias * (1.0 - max(dot(normal, toLight), 0.0));
+	if (light == cascadeLight) {
+		float depth = -(view * vec4(worldPosition, 1.0)).z;
+		if (depth < cascadeSplits[0]) {
+			return pcf(cascadeMaps[0], cascadeMatrices[0], bias);
+		} else if (cascadeCount > 1 && depth < cascadeSplits[1]) {
+			return pcf(cascadeMaps[1], cascadeMatrices[1], bias);
+		} else if (cascadeCount > 2 && depth < cascadeSplits[2]) {
+			return pcf(cascadeMaps[2], cascadeMatrices[2], bias);
+		} else if (cascadeCount > 3 && depth < cascadeSplits[3]) {
+			return pcf(cascadeMaps[3], cascadeMatrices[3], bias);
+		}
+	} else if (light == spotLight) {
+		return pcf(spotMap, spotMatrix, bias);
+	}
+	return 1.0;
+}
+#endif
+
+void main() {
+	vec3 normal = normalize(worldNormal);
+	if (!gl_FrontFacing) {
+		normal = -normal;
+	}
+	vec3 toCamera = normalize(cameraPosition - worldPosition);
+	vec3 result = ambient * diffuse + emissive;
+	for (int i = 0; i < lightCount; i++) {
+		float factor;
+		vec3 toLight = lightDirection(i, worldPosition, factor);
+#ifdef SHADOWS
+		factor *= shadow(i, normal, toLight);
+#endif
+		float diffuseFactor = max(dot(normal, toLight), 0.0);
+		float specularFactor = 0.0;
+		if (diffuseFactor > 0.0) {
+			vec3 halfway = normalize(toLight + toCamera);
+			specularFactor = pow(max(dot(normal, halfway), 0.0), shininess);
+		}
+		result += lights[i].color * factor * (diffuse * diffuseFactor + specular * specularFactor);
+	}
+	color = vec4(result, 1.0);
+}
+`
+
+// Program is the Blinn-Phong program with its locations.
+type Program struct {
+	ID                                              uint32
+	Lights                                          *Uniforms
+	PositionLocation, NormalLocation                int32
+	ProjectionLocation, ViewLocation, ModelLocation int32
+	NormalMatrixLocation, CameraPositionLocation    int32
+	DiffuseLocation, SpecularLocation               int32
+	ShininessLocation, EmissiveLocation             int32
+	// locations of shadow uniforms, if created with NewShadowedProgram
+	shadows *shadowLocations
+}
+
+// Uniforms are the locations of the uniforms declared by LightsShader.
+type Uniforms struct {
+	// size of the uniform array of lights
+	MaxLights int
+	ambient   int32
+	count     int32
+	lights    []lightLocations
+}
+
+type lightLocations struct {
+	kind, color, position, direction, attenuation, cone int32
+}
+
+// Material is a Blinn-Phong material. It implements scene.Material.
+type Material struct {
+	Program   *Program
+	Diffuse   vmath.Vec3
+	Specular  vmath.Vec3
+	Emissive  vmath.Vec3
+	Shininess float32
+}
+
+// NewProgram compiles a program with maxLights lights. The attributes positionIn
+// and normalIn have the locations 0 and 1.
+func NewProgram(maxLights int) (*Program, error) {
+	return newProgram(maxLights, "")
+}
+
+// NewShadowedProgram compiles a program with maxLights lights, that takes shadow
+// maps from Shadows.
+func NewShadowedProgram(maxLights int) (*Program, error) {
+	program, err := newProgram(maxLights, fmt.Sprintf("#define SHADOWS\n#define MAX_CASCADES %d\n", MaxCascades))
+	if err == nil {
+		program.shadows = newShadowLocations(program.ID)
+	}
+	return program, err
+}
+
+func newProgram(maxLights int, defines string) (*Program, error) {
+	header := fmt.Sprintf("#version 130\n#define MAX_LIGHTS %d\n", maxLights) + defines
+	id, err := gfx.NewProgramWithAttributes(VertexShader, header+LightsShader+FragmentShader, "positionIn", "normalIn")
+	if err != nil {
+		return nil, err
+	}
+	program := &Program{ID: id, Lights: NewUniforms(id, maxLights)}
+	program.PositionLocation = gfx.AttribLocation(id, "positionIn")
+	program.NormalLocation = gfx.AttribLocation(id, "normalIn")
+	program.ProjectionLocation = gfx.UniformLocation(id, "projection")
+	program.ViewLocation = gfx.UniformLocation(id, "view")
+	program.ModelLocation = gfx.UniformLocation(id, "model")
+	program.NormalMatrixLocation = gfx.UniformLocation(id, "normalMatrix")
+	program.CameraPositionLocation = gfx.UniformLocation(id, "cameraPosition")
+	program.DiffuseLocation = gfx.UniformLocation(id, "diffuse")
+	program.SpecularLocation = gfx.UniformLocation(id, "specular")
+	program.ShininessLocation = gfx.UniformLocation(id, "shininess")
+	program.EmissiveLocation = gfx.UniformLocation(id, "emissive")
+	return program, nil
+}
+
+// NewUniforms returns the locations of the uniforms of LightsShader in program.
+func NewUniforms(program uint32, maxLights int) *Uniforms {
+	uniforms := &Uniforms{MaxLights: maxLights, lights: make([]lightLocations, maxLights)}
+	uniforms.ambient = gfx.UniformLocation(program, "ambient")
+	uniforms.count = gfx.UniformLocation(program, "lightCount")
+	for i := range uniforms.lights {
+		locations := &uniforms.lights[i]
+		prefix := fmt.Sprintf("lights[%d].", i)
+		locations.kind = gfx.UniformLocation(program, prefix+"kind")
+		locations.color = gfx.UniformLocation(program, prefix+"color")
+		locations.position = gfx.UniformLocation(program, prefix+"position")
+		locations.direction = gfx.UniformLocation(program, prefix+"direction")
+		locations.attenuation = gfx.UniformLocation(program, prefix+"attenuation")
+		locations.cone = gfx.UniformLocation(program, prefix+"cone")
+	}
+	return uniforms
+}
+
+// Use makes the program current.
+func (program *Program) Use() {
+	gl.UseProgram(program.ID)
+}
+
+// SetCamera uploads projection, view and the camera position of the current program.
+func (program *Program) SetCamera(projection, view vmath.Mat4) {
+	position := vmath.Vec3{}
+	if inverse, ok := view.Inverse(); ok {
+		position = inverse.Translation()
+	}
+	gl.UniformMatrix4fv(program.ProjectionLocation, 1, false, projection.Ptr())
+	gl.UniformMatrix4fv(program.ViewLocation, 1, false, view.Ptr())
+	gl.Uniform3f(program.CameraPositionLocation, position[0], position[1], position[2])
+}
+
+// SetLights uploads the ambient color and lights in world space to the current
+// program. Lights beyond MaxLights are ignored.
+func (program *Program) SetLights(ambient vmath.Vec3, lights []Light) {
+	program.Lights.Set(ambient, lights)
+}
+
+// Set uploads the ambient color and lights in world space to the current program.
+// Lights beyond MaxLights are ignored.
+func (uniforms *Uniforms) Set(ambient vmath.Vec3, lights []Light) {
+	if len(lights) > uniforms.MaxLights {
+		lights = lights[:uniforms.MaxLights]
+	}
+	gl.Uniform3f(uniforms.ambient, ambient[0], ambient[1], ambient[2])
+	gl.Uniform1i(uniforms.count, int32(len(lights)))
+	for i := range lights {
+		light := &lights[i]
+		locations := &uniforms.lights[i]
+		radiance := light.Radiance()
+		inner, outer := light.ConeCos()
+		gl.Uniform1i(locations.kind, int32(light.Kind))
+		gl.Uniform3f(locations.color, radiance[0], radiance[1], radiance[2])
+		gl.Uniform3f(locations.position, light.Position[0], light.Position[1], light.Position[2])
+		gl.Uniform3f(locations.direction, light.Direction[0], light.Direction[1], light.Direction[2])
+		gl.Uniform3f(locations.attenuation, light.Attenuation[0], light.Attenuation[1], light.Attenuation[2])
+		gl.Uniform2f(locations.cone, inner, outer)
+	}
+}
+
+// Delete deletes the program.
+func (program *Program) Delete() {
+	gl.DeleteProgram(program.ID)
+}
+
+// Apply sets the model matrix, normal matrix and material uniforms of the current
+// program.
+func (material *Material) Apply(model vmath.Mat4) {
+	program := material.Program
+	normalMatrix := model.NormalMatrix()
+	gl.UniformMatrix4fv(program.ModelLocation, 1, false, model.Ptr())
+	gl.UniformMatrix3fv(program.NormalMatrixLocation, 1, false, normalMatrix.Ptr())
+	gl.Uniform3f(program.DiffuseLocation, material.Diffuse[0], material.Diffuse[1], material.Diffuse[2])
+	gl.Uniform3f(program.SpecularLocation, material.Specular[0], material.Specular[1], material.Specular[2])
+	gl.Uniform3f(program.EmissiveLocation, material.Emissive[0], material.Emissive[1], material.Emissive[2])
+	gl.Uniform1f(program.ShininessLocation, material.Shininess)
+}
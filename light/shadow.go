@@ -0,0 +1,89 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package light
+
+import (
+	"github.com/vbsw/opengl-go-example/vmath"
+	"math"
+)
+
+// CascadeSplits returns the far distances of count cascades between near and far.
+// Lambda blends between uniform (0) and logarithmic (1) distribution.
+func CascadeSplits(near, far float32, count int, lambda float32) []float32 {
+	splits := make([]float32, count)
+	for i := range splits {
+		t := float64(i+1) / float64(count)
+		logarithmic := float64(near) * math.Pow(float64(far/near), t)
+		uniform := float64(near) + float64(far-near)*t
+		splits[i] = float32(float64(lambda)*logarithmic + (1-float64(lambda))*uniform)
+	}
+	// exact, despite rounding errors
+	splits[count-1] = far
+	return splits
+}
+
+// FrustumCorners returns the corners of the part of the view frustum between the
+// distances sliceNear and sliceFar in world space. Near and far are the planes of
+// the perspective projection. The first four corners are the near ones.
+func FrustumCorners(projection, view vmath.Mat4, near, far, sliceNear, sliceFar float32) [8]vmath.Vec3 {
+	var corners [8]vmath.Vec3
+	inverse, _ := projection.Mul(view).Inverse()
+	ndc := [4]vmath.Vec2{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}}
+	for i, xy := range ndc {
+		nearCorner := inverse.TransformPoint(vmath.Vec3{xy[0], xy[1], -1})
+		farCorner := inverse.TransformPoint(vmath.Vec3{xy[0], xy[1], 1})
+		// view depth changes linearly along the edges of the frustum
+		corners[i] = nearCorner.Lerp(farCorner, (sliceNear-near)/(far-near))
+		corners[i+4] = nearCorner.Lerp(farCorner, (sliceFar-near)/(far-near))
+	}
+	return corners
+}
+
+// DirectionalShadowMatrix returns the orthographic projection * view of a
+// directional light, that covers the corners. The covered area is a sphere, so its
+// size doesn't change when the camera rotates, and is moved in steps of texels of a
+// shadow map of size x size to avoid flickering edges. Casters up to casterDistance
+// in front of the sphere are included.
+func DirectionalShadowMatrix(direction vmath.Vec3, corners [8]vmath.Vec3, size int, casterDistance float32) vmath.Mat4 {
+	var center vmath.Vec3
+	for _, corner := range corners {
+		center = center.Add(corner)
+	}
+	center = center.Scale(1.0 / float32(len(corners)))
+	var radius float32
+	for _, corner := range corners {
+		radius = float32(math.Max(float64(radius), float64(corner.Sub(center).Len())))
+	}
+	// steps of 1/16 unit, so rounding errors don't change the size
+	radius = float32(math.Ceil(float64(radius)*16) / 16)
+	direction = direction.Normalize()
+	eye := center.Sub(direction.Scale(radius + casterDistance))
+	view := vmath.LookAt(eye, center, upVector(direction))
+	projection := vmath.Ortho(-radius, radius, -radius, radius, 0, 2*radius+casterDistance)
+	matrix := projection.Mul(view)
+
+	// snap origin to texels
+	origin := matrix.TransformPoint(vmath.Vec3{})
+	half := float32(size) / 2
+	offsetX := float32(math.Round(float64(origin[0]*half)))/half - origin[0]
+	offsetY := float32(math.Round(float64(origin[1]*half)))/half - origin[1]
+	return vmath.Translate(vmath.Vec3{offsetX, offsetY, 0}).Mul(matrix)
+}
+
+// SpotShadowMatrix returns the perspective projection * view of a spot light. The
+// field of view is twice the outer angle.
+func SpotShadowMatrix(light *Light, near, far float32) vmath.Mat4 {
+	view := vmath.LookAt(light.Position, light.Position.Add(light.Direction), upVector(light.Direction))
+	return vmath.Perspective(2*light.OuterAngle, 1, near, far).Mul(view)
+}
+
+// upVector returns an up vector, that is not parallel to direction.
+func upVector(direction vmath.Vec3) vmath.Vec3 {
+	if math.Abs(float64(direction.Normalize()[1])) > 0.99 {
+		return vmath.Vec3{0, 0, 1}
+	}
+	return vmath.Vec3{0, 1, 0}
+}
@@ -0,0 +1,130 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package light
+
+import (
+	"github.com/vbsw/opengl-go-example/vmath"
+	"math"
+	"testing"
+)
+
+func TestCascadeSplits(t *testing.T) {
+	tests := []struct {
+		name   string
+		lambda float32
+		want   []float32
+	}{
+		{"uniform", 0, []float32{25.075, 50.05, 75.025, 100}},
+		{"logarithmic", 1, []float32{1, 10, 100}},
+		{"one cascade", 0.5, []float32{100}},
+	}
+	for _, test := range tests {
+		splits := CascadeSplits(0.1, 100, len(test.want), test.lambda)
+		for i := range test.want {
+			if math.Abs(float64(splits[i]-test.want[i])) > 1e-3 {
+				t.Errorf("%s: got %v, want %v", test.name, splits, test.want)
+				break
+			}
+		}
+	}
+	splits := CascadeSplits(0.5, 40, 4, 0.75)
+	for i := 1; i < len(splits); i++ {
+		if splits[i] <= splits[i-1] {
+			t.Errorf("splits not increasing: %v", splits)
+		}
+	}
+}
+
+func TestFrustumCorners(t *testing.T) {
+	projection := vmath.Perspective(math.Pi/2, 1, 1, 100)
+	view := vmath.LookAt(vmath.Vec3{0, 0, 5}, vmath.Vec3{}, vmath.Vec3{0, 1, 0})
+	corners := FrustumCorners(projection, view, 1, 100, 2, 10)
+	// 90 degrees field of view: half width equals distance
+	tests := []struct {
+		name string
+		got  vmath.Vec3
+		want vmath.Vec3
+	}{
+		{"near bottom left", corners[0], vmath.Vec3{-2, -2, 3}},
+		{"near top right", corners[2], vmath.Vec3{2, 2, 3}},
+		{"far bottom right", corners[5], vmath.Vec3{10, -10, -5}},
+		{"far top left", corners[7], vmath.Vec3{-10, 10, -5}},
+	}
+	for _, test := range tests {
+		if !approxEqualVec3(test.got, test.want, 1e-3) {
+			t.Errorf("%s: got %v, want %v", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestDirectionalShadowMatrix(t *testing.T) {
+	projection := vmath.Perspective(1, 1.5, 0.1, 100)
+	view := vmath.LookAt(vmath.Vec3{3, 4, 8}, vmath.Vec3{}, vmath.Vec3{0, 1, 0})
+	corners := FrustumCorners(projection, view, 0.1, 100, 5, 20)
+	directions := []vmath.Vec3{{-0.3, -1, -0.5}, {0, -1, 0}, {1, 0, 0}}
+	for _, direction := range directions {
+		matrix := DirectionalShadowMatrix(direction, corners, 1024, 10)
+		for _, corner := range corners {
+			p := matrix.TransformPoint(corner)
+			for _, c := range p {
+				// texel snapping may move the area by one texel
+				if c < -1-2.0/1024 || c > 1+2.0/1024 {
+					t.Errorf("direction %v: corner %v outside: %v", direction, corner, p)
+				}
+			}
+		}
+		// casters in front of the slice are inside, too
+		caster := matrix.TransformPoint(corners[0].Sub(direction.Normalize().Scale(9)))
+		if caster[2] < -1 {
+			t.Errorf("direction %v: caster outside: %v", direction, caster)
+		}
+	}
+	// moving the camera by less than a texel must not move the shadow map by a fraction of a texel
+	a := DirectionalShadowMatrix(vmath.Vec3{0, -1, 0}, corners, 1024, 10)
+	var moved [8]vmath.Vec3
+	for i, corner := range corners {
+		moved[i] = corner.Add(vmath.Vec3{0.003, 0, 0.002})
+	}
+	b := DirectionalShadowMatrix(vmath.Vec3{0, -1, 0}, moved, 1024, 10)
+	shift := b.TransformPoint(vmath.Vec3{}).Sub(a.TransformPoint(vmath.Vec3{})).Scale(512)
+	for _, c := range shift.Vec2() {
+		if math.Abs(float64(c)-math.Round(float64(c))) > 1e-2 {
+			t.Errorf("shift is not whole texels: %v", shift)
+		}
+	}
+}
+
+func TestSpotShadowMatrix(t *testing.T) {
+	spot := NewSpot(vmath.Vec3{0, 5, 0}, vmath.Vec3{0, -1, 0}, vmath.Vec3{1, 1, 1}, 1, 10, 0.3, 0.5)
+	matrix := SpotShadowMatrix(spot, 0.5, 20)
+	center := matrix.TransformPoint(vmath.Vec3{0, 0, 0})
+	if !approxEqualVec3(vmath.Vec3{center[0], center[1], 0}, vmath.Vec3{}, 1e-4) || center[2] <= -1 || center[2] >= 1 {
+		t.Errorf("point on axis: %v", center)
+	}
+	// on the edge of the outer cone
+	edge := matrix.TransformPoint(vmath.Vec3{float32(math.Tan(0.5)) * 5, 0, 0})
+	if math.Abs(math.Abs(float64(edge[0]))+math.Abs(float64(edge[1]))-1) > 1e-3 {
+		t.Errorf("point on cone: %v", edge)
+	}
+}
+
+func approxEqualVec3(a, b vmath.Vec3, epsilon float64) bool {
+	for i := range a {
+		if math.Abs(float64(a[i]-b[i])) > epsilon {
+			return false
+		}
+	}
+	return true
+}
+
+func BenchmarkDirectionalShadowMatrix(b *testing.B) {
+	projection := vmath.Perspective(1, 1.5, 0.1, 100)
+	view := vmath.LookAt(vmath.Vec3{3, 4, 8}, vmath.Vec3{}, vmath.Vec3{0, 1, 0})
+	for i := 0; i < b.N; i++ {
+		corners := FrustumCorners(projection, view, 0.1, 100, 5, 20)
+		DirectionalShadowMatrix(vmath.Vec3{-0.3, -1, -0.5}, corners, 2048, 20)
+	}
+}
@@ -0,0 +1,263 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package light
+
+import (
+	"fmt"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/vbsw/opengl-go-example/gfx"
+	"github.com/vbsw/opengl-go-example/scene"
+	"github.com/vbsw/opengl-go-example/vmath"
+)
+
+// MaxCascades is the maximum number of cascades of the directional shadow.
+const MaxCascades = 4
+
+// ShadowTextureUnit is the first texture unit used for shadow maps. The cascades
+// use the units ShadowTextureUnit to ShadowTextureUnit+MaxCascades-1 and the spot
+// light the unit ShadowTextureUnit+MaxCascades, even if fewer cascades are used.
+const ShadowTextureUnit = 8
+
+const depthVertexShader = `#version 130
+
+in vec3 positionIn;
+uniform mat4 lightMatrix;
+uniform mat4 model;
+
+void main() {
+	gl_Position = lightMatrix * model * vec4(positionIn, 1.0);
+}
+`
+
+const depthFragmentShader = `#version 130
+
+void main() {
+}
+`
+
+// Shadows renders the shadow maps of the first directional light (cascaded) and the
+// first spot light of a list of lights.
+type Shadows struct {
+	// if false, Render renders nothing and SetUniforms disables shadows
+	Enabled bool
+	// cascades cover the view frustum up to this distance
+	Distance float32
+	// distribution of cascades between uniform (0) and logarithmic (1)
+	Lambda float32
+	// casters in this distance in front of a cascade throw shadows into it
+	CasterDistance float32
+	// near and far plane of the spot light shadow
+	SpotNear, SpotFar float32
+	// constant and slope scaled depth bias against shadow acne
+	DepthBias, SlopeBias float32
+	// radius of the PCF kernel in texels (0 is one sample)
+	PCFRadius       int
+	size            int
+	cascadeMaps     []*gfx.Framebuffer
+	spotMap         *gfx.Framebuffer
+	cascadeMatrices [MaxCascades]vmath.Mat4
+	splits          [MaxCascades]float32
+	spotMatrix      vmath.Mat4
+	// indices of the shadowed lights or -1
+	cascadeLight, spotLight int
+	depthProgram            uint32
+	lightMatrixLocation     int32
+	modelLocation           int32
+}
+
+type shadowLocations struct {
+	cascadeMatrices, cascadeSplits, cascadeCount, cascadeLight int32
+	spotMatrix, spotLight, depthBias, slopeBias, pcfRadius     int32
+}
+
+// NewShadows returns shadow maps of size x size texels and cascades cascades.
+func NewShadows(size, cascades int) (*Shadows, error) {
+	if cascades < 1 || cascades > MaxCascades {
+		return nil, fmt.Errorf("shadows: %d cascades not in range 1 to %d", cascades, MaxCascades)
+	}
+	shadows := &Shadows{Enabled: true, Distance: 30, Lambda: 0.75, CasterDistance: 20, SpotNear: 0.5, SpotFar: 30, DepthBias: 0.0005, SlopeBias: 0.002, PCFRadius: 1, size: size}
+	var err error
+	shadows.depthProgram, err = gfx.NewProgramWithAttributes(depthVertexShader, depthFragmentShader, "positionIn")
+	if err != nil {
+		return nil, err
+	}
+	shadows.lightMatrixLocation = gfx.UniformLocation(shadows.depthProgram, "lightMatrix")
+	shadows.modelLocation = gfx.UniformLocation(shadows.depthProgram, "model")
+	for i := 0; i <= cascades && err == nil; i++ {
+		var shadowMap *gfx.Framebuffer
+		shadowMap, err = newShadowMap(size)
+		if err == nil && i < cascades {
+			shadows.cascadeMaps = append(shadows.cascadeMaps, shadowMap)
+		} else if err == nil {
+			shadows.spotMap = shadowMap
+		}
+	}
+	if err != nil {
+		shadows.Delete()
+		return nil, err
+	}
+	return shadows, nil
+}
+
+// Cascades returns the number of cascades.
+func (shadows *Shadows) Cascades() int {
+	return len(shadows.cascadeMaps)
+}
+
+// CascadeMap returns the depth texture of cascade index.
+func (shadows *Shadows) CascadeMap(index int) uint32 {
+	return shadows.cascadeMaps[index].DepthTexture
+}
+
+// SpotMap returns the depth texture of the spot light.
+func (shadows *Shadows) SpotMap() uint32 {
+	return shadows.spotMap.DepthTexture
+}
+
+// Render renders the depth of the items into the shadow maps. Lights are in world
+// space. Projection, view, near and far are the ones of the camera. Afterwards the
+// default framebuffer is bound, but the viewport is the one of the shadow maps.
+func (shadows *Shadows) Render(lights []Light, items []scene.DrawItem, projection, view vmath.Mat4, near, far float32) {
+	shadows.cascadeLight, shadows.spotLight = -1, -1
+	if !shadows.Enabled {
+		return
+	}
+	for i := range lights {
+		if lights[i].Kind == Directional && shadows.cascadeLight < 0 {
+			shadows.cascadeLight = i
+		} else if lights[i].Kind == Spot && shadows.spotLight < 0 {
+			shadows.spotLight = i
+		}
+	}
+	state := gfx.Opaque3D()
+	// one-sided geometry (e.g. planes) must cast shadows, too
+	state.Cull = false
+	state.Apply()
+	gl.UseProgram(shadows.depthProgram)
+
+	if shadows.cascadeLight >= 0 {
+		distance := shadows.Distance
+		if distance > far {
+			distance = far
+		}
+		splits := CascadeSplits(near, distance, len(shadows.cascadeMaps), shadows.Lambda)
+		sliceNear := near
+		for i, shadowMap := range shadows.cascadeMaps {
+			corners := FrustumCorners(projection, view, near, far, sliceNear, splits[i])
+			shadows.splits[i] = splits[i]
+			shadows.cascadeMatrices[i] = DirectionalShadowMatrix(lights[shadows.cascadeLight].Direction, corners, shadows.size, shadows.CasterDistance)
+			shadows.renderDepth(shadowMap, shadows.cascadeMatrices[i], items)
+			sliceNear = splits[i]
+		}
+	}
+	if shadows.spotLight >= 0 {
+		shadows.spotMatrix = SpotShadowMatrix(&lights[shadows.spotLight], shadows.SpotNear, shadows.SpotFar)
+		shadows.renderDepth(shadows.spotMap, shadows.spotMatrix, items)
+	}
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+}
+
+// SetUniforms binds the shadow maps and uploads the shadow uniforms to program, which
+// must be current and created with NewShadowedProgram. The light indices refer to
+// the lights passed to Render and must match the ones passed to SetLights.
+func (shadows *Shadows) SetUniforms(program *Program) {
+	locations := program.shadows
+	gl.UniformMatrix4fv(locations.cascadeMatrices, MaxCascades, false, shadows.cascadeMatrices[0].Ptr())
+	gl.Uniform1fv(locations.cascadeSplits, MaxCascades, &shadows.splits[0])
+	gl.Uniform1i(locations.cascadeCount, int32(len(shadows.cascadeMaps)))
+	gl.Uniform1i(locations.cascadeLight, int32(shadows.cascadeLight))
+	gl.UniformMatrix4fv(locations.spotMatrix, 1, false, shadows.spotMatrix.Ptr())
+	gl.Uniform1i(locations.spotLight, int32(shadows.spotLight))
+	gl.Uniform1f(locations.depthBias, shadows.DepthBias)
+	gl.Uniform1f(locations.slopeBias, shadows.SlopeBias)
+	gl.Uniform1i(locations.pcfRadius, int32(shadows.PCFRadius))
+	for i, shadowMap := range shadows.cascadeMaps {
+		gl.ActiveTexture(gl.TEXTURE0 + ShadowTextureUnit + uint32(i))
+		gl.BindTexture(gl.TEXTURE_2D, shadowMap.DepthTexture)
+	}
+	gl.ActiveTexture(gl.TEXTURE0 + ShadowTextureUnit + MaxCascades)
+	gl.BindTexture(gl.TEXTURE_2D, shadows.spotMap.DepthTexture)
+	gl.ActiveTexture(gl.TEXTURE0)
+}
+
+// SetCompare enables or disables depth comparison of the shadow maps. Comparison
+// must be disabled to show the depth (e.g. on a debug quad) with a sampler2D.
+func (shadows *Shadows) SetCompare(compare bool) {
+	mode := int32(gl.NONE)
+	if compare {
+		mode = gl.COMPARE_REF_TO_TEXTURE
+	}
+	for _, shadowMap := range shadows.cascadeMaps {
+		gl.BindTexture(gl.TEXTURE_2D, shadowMap.DepthTexture)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_COMPARE_MODE, mode)
+	}
+	gl.BindTexture(gl.TEXTURE_2D, shadows.spotMap.DepthTexture)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_COMPARE_MODE, mode)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+}
+
+// Delete deletes shadow maps and depth program.
+func (shadows *Shadows) Delete() {
+	for _, shadowMap := range shadows.cascadeMaps {
+		shadowMap.Delete()
+	}
+	shadows.cascadeMaps = nil
+	if shadows.spotMap != nil {
+		shadows.spotMap.Delete()
+		shadows.spotMap = nil
+	}
+	gl.DeleteProgram(shadows.depthProgram)
+}
+
+func (shadows *Shadows) renderDepth(shadowMap *gfx.Framebuffer, lightMatrix vmath.Mat4, items []scene.DrawItem) {
+	shadowMap.Bind()
+	gl.Clear(gl.DEPTH_BUFFER_BIT)
+	gl.UniformMatrix4fv(shadows.lightMatrixLocation, 1, false, lightMatrix.Ptr())
+	for i := range items {
+		gl.UniformMatrix4fv(shadows.modelLocation, 1, false, items[i].World.Ptr())
+		items[i].Mesh.Draw()
+	}
+}
+
+// newShadowMap returns a depth-only framebuffer, whose texture compares depth with
+// linear filtering (hardware PCF). Outside of the map is lit.
+func newShadowMap(size int) (*gfx.Framebuffer, error) {
+	shadowMap, err := gfx.NewFramebuffer(gfx.FramebufferConfig{Width: size, Height: size, Depth: gfx.DepthOnlyTexture})
+	if err == nil {
+		border := [4]float32{1, 1, 1, 1}
+		gl.BindTexture(gl.TEXTURE_2D, shadowMap.DepthTexture)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_BORDER)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_BORDER)
+		gl.TexParameterfv(gl.TEXTURE_2D, gl.TEXTURE_BORDER_COLOR, &border[0])
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_COMPARE_MODE, gl.COMPARE_REF_TO_TEXTURE)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_COMPARE_FUNC, gl.LEQUAL)
+		gl.BindTexture(gl.TEXTURE_2D, 0)
+	}
+	return shadowMap, err
+}
+
+func newShadowLocations(program uint32) *shadowLocations {
+	locations := &shadowLocations{
+		cascadeMatrices: gfx.UniformLocation(program, "cascadeMatrices"),
+		cascadeSplits:   gfx.UniformLocation(program, "cascadeSplits"),
+		cascadeCount:    gfx.UniformLocation(program, "cascadeCount"),
+		cascadeLight:    gfx.UniformLocation(program, "cascadeLight"),
+		spotMatrix:      gfx.UniformLocation(program, "spotMatrix"),
+		spotLight:       gfx.UniformLocation(program, "spotLight"),
+		depthBias:       gfx.UniformLocation(program, "depthBias"),
+		slopeBias:       gfx.UniformLocation(program, "slopeBias"),
+		pcfRadius:       gfx.UniformLocation(program, "pcfRadius"),
+	}
+	gl.UseProgram(program)
+	for i := 0; i < MaxCascades; i++ {
+		gl.Uniform1i(gfx.UniformLocation(program, fmt.Sprintf("cascadeMaps[%d]", i)), ShadowTextureUnit+int32(i))
+	}
+	gl.Uniform1i(gfx.UniformLocation(program, "spotMap"), ShadowTextureUnit+MaxCascades)
+	gl.UseProgram(0)
+	return locations
+}
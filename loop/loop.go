@@ -0,0 +1,197 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+// Package loop runs an application with a fixed simulation time step separated from rendering.
+package loop
+
+import (
+	"github.com/vbsw/opengl-go-example/stats"
+	"time"
+)
+
+// tolerance for rounding errors in time calculation (in seconds)
+const epsilon = 1e-9
+
+// Application is the interface of the hooks called by Loop.
+type Application interface {
+	// Init is called once before the first frame.
+	Init() error
+	// Update advances the simulation by dt seconds. It is called zero or more times per frame.
+	Update(dt float64)
+	// Render draws the frame. Alpha (0 to 1) is the fraction of a time step, that has not been
+	// simulated yet. It can be used to interpolate between the last two simulated states.
+	Render(alpha float64)
+	// Shutdown is called once after the last frame, if Init succeeded.
+	Shutdown()
+}
+
+// Window is the interface of the window the loop renders to (e.g. *glfw.Window).
+type Window interface {
+	ShouldClose() bool
+	SwapBuffers()
+}
+
+// Clock returns the current time in seconds.
+type Clock interface {
+	Now() float64
+}
+
+// ClockFunc is a function used as Clock (e.g. glfw.GetTime).
+type ClockFunc func() float64
+
+// FixedClock is a clock, that advances by Step seconds every time Now is called.
+// With Step equal to the time step of the loop, every frame has exactly one update.
+type FixedClock struct {
+	Time float64
+	Step float64
+}
+
+// Loop is for storing the state of the application loop.
+type Loop struct {
+	// simulation time step in seconds
+	Timestep float64
+	// frame times above MaxFrameTime are clamped to avoid an endless catching up
+	MaxFrameTime float64
+	// maximum frames per second, 0 means unlimited
+	FrameCap float64
+	// speed of simulation time relative to real time
+	TimeScale float64
+	Paused    bool
+	Clock     Clock
+	// WallClock measures real time for FrameCap, because Clock may be simulated (e.g.
+	// FixedClock); default is based on time.Now
+	WallClock Clock
+	// Sleep is used to limit the frame rate (default is time.Sleep)
+	Sleep func(seconds float64)
+	// PollEvents is called after every frame (e.g. glfw.PollEvents)
+	PollEvents func()
+	// Stats receives the timing of every frame, if not nil (measured in real time)
+	Stats *stats.Stats
+	// number of rendered frames
+	Frame uint64
+	// number of simulated time steps
+	Updates uint64
+	// simulated time in seconds
+	Time        float64
+	accumulator float64
+	frameStart  float64
+	// wall clock time at the start of the frame
+	wallStart float64
+	started   bool
+	step      bool
+}
+
+// Now returns the time returned by function f.
+func (f ClockFunc) Now() float64 {
+	return f()
+}
+
+// Now returns the current time and advances it by Step.
+func (clock *FixedClock) Now() float64 {
+	now := clock.Time
+	clock.Time += clock.Step
+	return now
+}
+
+// New returns a new instance of Loop with time step timestep (in seconds) and a clock
+// based on time.Now.
+func New(timestep float64) *Loop {
+	loop := new(Loop)
+	loop.Timestep = timestep
+	loop.MaxFrameTime = 0.25
+	loop.TimeScale = 1
+	loop.Clock = newSystemClock()
+	loop.WallClock = loop.Clock
+	loop.Sleep = sleep
+	return loop
+}
+
+// Run calls Init, then renders frames until window should close and then calls Shutdown.
+func (loop *Loop) Run(window Window, app Application) error {
+	err := app.Init()
+	if err == nil {
+		var previous time.Time
+		defer app.Shutdown()
+		for !window.ShouldClose() {
+			start := time.Now()
+			if loop.FrameCap > 0 {
+				loop.wallStart = loop.WallClock.Now()
+			}
+			loop.Tick(app)
+			cpu := time.Since(start)
+			window.SwapBuffers()
+			if loop.Stats != nil && !previous.IsZero() {
+				swap := time.Since(start) - cpu
+				loop.Stats.Add(stats.Frame{CPU: cpu.Seconds(), Swap: swap.Seconds(), Interval: start.Sub(previous).Seconds()})
+			}
+			previous = start
+			if loop.PollEvents != nil {
+				loop.PollEvents()
+			}
+			loop.limitFrameRate()
+		}
+	}
+	return err
+}
+
+// Tick advances the simulation by the time passed since the last call of Tick and renders one frame.
+func (loop *Loop) Tick(app Application) {
+	now := loop.Clock.Now()
+	if loop.started {
+		frameTime := now - loop.frameStart
+		if frameTime > loop.MaxFrameTime {
+			frameTime = loop.MaxFrameTime
+		}
+		if !loop.Paused {
+			loop.accumulator += frameTime * loop.TimeScale
+		} else if loop.step {
+			loop.accumulator += loop.Timestep
+			loop.step = false
+		}
+	}
+	loop.frameStart = now
+	loop.started = true
+	for loop.accumulator+epsilon >= loop.Timestep {
+		app.Update(loop.Timestep)
+		loop.accumulator -= loop.Timestep
+		loop.Time += loop.Timestep
+		loop.Updates++
+	}
+	if loop.accumulator < 0 {
+		loop.accumulator = 0
+	}
+	app.Render(loop.accumulator / loop.Timestep)
+	loop.Frame++
+}
+
+// Step simulates exactly one time step in the next frame, if the loop is paused.
+func (loop *Loop) Step() {
+	loop.step = true
+}
+
+// TogglePause pauses or resumes the simulation.
+func (loop *Loop) TogglePause() {
+	loop.Paused = !loop.Paused
+}
+
+func (loop *Loop) limitFrameRate() {
+	if loop.FrameCap > 0 {
+		remaining := loop.wallStart + 1/loop.FrameCap - loop.WallClock.Now()
+		if remaining > 0 {
+			loop.Sleep(remaining)
+		}
+	}
+}
+
+func newSystemClock() ClockFunc {
+	start := time.Now()
+	return func() float64 {
+		return time.Since(start).Seconds()
+	}
+}
+
+func sleep(seconds float64) {
+	time.Sleep(time.Duration(seconds * float64(time.Second)))
+}
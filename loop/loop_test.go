@@ -0,0 +1,121 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package loop
+
+import (
+	"testing"
+)
+
+type testApp struct {
+	updates int
+	alphas  []float64
+}
+
+type testWindow struct {
+	frames int
+}
+
+func (app *testApp) Init() error          { return nil }
+func (app *testApp) Update(dt float64)    { app.updates++ }
+func (app *testApp) Render(alpha float64) { app.alphas = append(app.alphas, alpha) }
+func (app *testApp) Shutdown()            {}
+
+func (window *testWindow) ShouldClose() bool {
+	window.frames--
+	return window.frames < 0
+}
+
+func (window *testWindow) SwapBuffers() {
+}
+
+func TestFixedClock(t *testing.T) {
+	loop := New(0.01)
+	loop.Clock = &FixedClock{Step: 0.01}
+	app := new(testApp)
+	err := loop.Run(&testWindow{frames: 10}, app)
+	if err != nil {
+		t.Error(err)
+	} else if loop.Frame != 10 || app.updates != 9 {
+		t.Error(loop.Frame, app.updates)
+	}
+}
+
+func TestAccumulator(t *testing.T) {
+	loop := New(0.1)
+	clock := &FixedClock{Step: 0.25}
+	loop.Clock = clock
+	app := new(testApp)
+	for i := 0; i < 3; i++ {
+		loop.Tick(app)
+	}
+	// 0.5 seconds simulated in steps of 0.1
+	if app.updates != 5 {
+		t.Error(app.updates)
+	}
+	if alpha := app.alphas[1]; alpha < 0.49 || alpha > 0.51 {
+		t.Error(alpha)
+	}
+}
+
+func TestPauseAndStep(t *testing.T) {
+	loop := New(0.1)
+	loop.Clock = &FixedClock{Step: 0.1}
+	app := new(testApp)
+	loop.Paused = true
+	loop.Tick(app)
+	loop.Tick(app)
+	if app.updates != 0 {
+		t.Error(app.updates)
+	}
+	loop.Step()
+	loop.Tick(app)
+	loop.Tick(app)
+	if app.updates != 1 {
+		t.Error(app.updates)
+	}
+}
+
+func TestTimeScaleAndClamp(t *testing.T) {
+	loop := New(0.1)
+	loop.Clock = &FixedClock{Step: 1}
+	loop.TimeScale = 0.5
+	app := new(testApp)
+	loop.Tick(app)
+	loop.Tick(app)
+	// frame time is clamped to 0.25, scaled to 0.125
+	if app.updates != 1 {
+		t.Error(app.updates)
+	}
+}
+
+func TestFrameCap(t *testing.T) {
+	var slept float64
+	loop := New(0.1)
+	loop.WallClock = &FixedClock{Step: 0.001}
+	loop.FrameCap = 100
+	loop.Sleep = func(seconds float64) { slept += seconds }
+	loop.Run(&testWindow{frames: 1}, new(testApp))
+	if slept < 0.0089 || slept > 0.0091 {
+		t.Error(slept)
+	}
+}
+
+func TestFixedClockWithFrameCap(t *testing.T) {
+	loop := New(0.01)
+	clock := &FixedClock{Step: 0.01}
+	loop.Clock = clock
+	loop.WallClock = &FixedClock{Step: 0.001}
+	loop.FrameCap = 100
+	loop.Sleep = func(seconds float64) {}
+	app := new(testApp)
+	err := loop.Run(&testWindow{frames: 10}, app)
+	// the frame cap doesn't advance the simulated clock
+	if err != nil {
+		t.Error(err)
+	} else if loop.Frame != 10 || app.updates != 9 || clock.Time < 0.0999 || clock.Time > 0.1001 {
+		t.Error(loop.Frame, app.updates, clock.Time)
+	}
+}
@@ -6,84 +6,368 @@
 // +build !texture
 // +build !texture2
 // +build !texture3
+// +build !camera
+// +build !cube
+// +build !instanced
+// +build !sprite
+// +build !stream
+// +build !framebuffer
+// +build !post
+// +build !light
+// +build !pbr
+// +build !material
+// +build !transparency
 
 // Package opengl-go-example opens a window showing graphics rendered in OpenGL. It is programmed using GLFW.
 package main
 
 import (
 	"errors"
+	"flag"
 	"fmt"
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/vbsw/opengl-go-example/gfx"
+	"github.com/vbsw/opengl-go-example/input"
+	"github.com/vbsw/opengl-go-example/loop"
+	"github.com/vbsw/opengl-go-example/stats"
+	"github.com/vbsw/opengl-go-example/vmath"
 	"github.com/vbsw/shaders"
+	"image"
+	"image/png"
+	"math"
+	"os"
 	"runtime"
 )
 
+// SDL_GameControllerDB file, that is loaded, if it exists
+const mappingsFile = "gamecontrollerdb.txt"
+
+// simulation time step in seconds
+const timestep = 1.0 / 60.0
+
+var recordFile = flag.String("record", "", "record input events to file")
+var replayFile = flag.String("replay", "", "replay input events from file")
+var goldenFile = flag.String("golden", "", "compare last replayed frame with PNG file (written, if it doesn't exist)")
+var headless = flag.Bool("headless", false, "hide window")
+var frameCap = flag.Float64("fps", 0, "maximum frames per second (0 is unlimited)")
+var statsFile = flag.String("stats", "", "write frame timings to file on exit (.json or .csv)")
+var samples = flag.Int("samples", 4, "samples per pixel of multisample anti-aliasing (0 disables it)")
+var msaaTarget = flag.String("msaa", "window", "multisample target: window (default framebuffer) or framebuffer (resolved with BlitFramebuffer)")
+
+// title is updated with frame statistics in this interval (seconds)
+const titleInterval = 0.5
+
+// window title without statistics
+const title = "OpenGL Example"
+
+var mouse *input.Mouse
+var gamepads *input.Gamepads
+var mainLoop *loop.Loop
+var showGraph bool
+
+// true, if multisample anti-aliasing is enabled (key M)
+var msaa bool
+
+type example struct {
+	window *glfw.Window
+	shader *shaders.Shader
+	vbos   []uint32
+	vaos   []uint32
+	graph  *gfx.FrameGraph
+	// multisampled framebuffer, if -msaa is framebuffer
+	msaaFramebuffer *gfx.Framebuffer
+	// samples per pixel used
+	samples int
+	// time of last title update
+	titleTime float64
+	current   transform
+	// state of the previous time step, used for interpolation
+	previous transform
+	// true, if next frame is compared with golden image
+	capture bool
+	err     error
+}
+
+type transform struct {
+	x, y, angle, scale float64
+}
+
 func init() {
 	runtime.LockOSThread()
 }
 
 func main() {
-	err := glfw.Init()
+	flag.Parse()
+	if err := run(); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
+
+// run opens the window and runs the loop. The deferred calls run before main exits
+// with an error code.
+func run() error {
+	replay, err := loadReplay()
 
+	if err == nil && *msaaTarget != "window" && *msaaTarget != "framebuffer" {
+		err = fmt.Errorf("unknown multisample target %q", *msaaTarget)
+	}
+	if err == nil {
+		err = glfw.Init()
+
+		if err == nil {
+			defer glfw.Terminate()
+			// before gamepads are registered, so that connected gamepads use them
+			err = loadMappings()
+		}
+	}
 	if err == nil {
 		var window *glfw.Window
-		defer glfw.Terminate()
-		window, err = glfw.CreateWindow(300, 300, "OpenGL Example", nil, nil)
+		width, height := 300, 300
+
+		if replay != nil {
+			width, height = replay.Width, replay.Height
+		}
+		if *headless {
+			glfw.WindowHint(glfw.Visible, glfw.False)
+		}
+		if *msaaTarget == "window" && *samples > 1 {
+			glfw.WindowHint(glfw.Samples, *samples)
+		}
+		window, err = glfw.CreateWindow(width, height, title, nil, nil)
 
 		if err == nil {
 			defer window.Destroy()
-			window.SetKeyCallback(onKey)
-			window.SetSizeCallback(onResize)
+			if replay != nil {
+				// the recorded size is the framebuffer size
+				input.SetFramebufferSize(window, width, height)
+			}
+			var recorder *input.Recorder
+			app := &example{window: window}
+			mouse = input.NewMouse()
+			gamepads = input.NewGamepads()
+			callbacks := input.MouseCallbacks(mouse, onKey, onResize)
+			keyCallback := callbacks.Key
+			mainLoop = loop.New(timestep)
+			mainLoop.Clock = loop.ClockFunc(glfw.GetTime)
+			mainLoop.FrameCap = *frameCap
+			mainLoop.Stats = stats.New(stats.DefaultCapacity)
+
+			if replay == nil {
+				if len(*recordFile) > 0 {
+					recorder = input.NewRecorder(window, timestep, callbacks)
+					recorder.Register(window)
+					keyCallback = recorder.OnKey
+					// one time step per frame, independent of real time
+					mainLoop.Clock = &loop.FixedClock{Step: timestep}
+				} else {
+					window.SetKeyCallback(onKey)
+					window.SetFramebufferSizeCallback(onResize)
+					mouse.Register(window)
+				}
+				gamepads.Register()
+				mainLoop.PollEvents = func() {
+					gamepads.EndFrame()
+					glfw.PollEvents()
+					gamepads.Update(window, keyCallback)
+
+					if recorder != nil {
+						recorder.RecordGamepad(gamepads.State())
+						recorder.NextFrame()
+					}
+				}
+			} else {
+				mainLoop.Timestep = replay.Timestep
+				mainLoop.Clock = &loop.FixedClock{Step: replay.Timestep}
+				mainLoop.FrameCap = 0
+				mainLoop.PollEvents = func() {
+					replay.Dispatch(window, callbacks)
+					gamepads.SetState(replay.Gamepad)
+
+					if replay.Done() && !app.capture {
+						if len(*goldenFile) > 0 {
+							app.capture = true
+						} else {
+							window.SetShouldClose(true)
+						}
+					}
+				}
+			}
 			window.MakeContextCurrent()
 			err = gl.Init()
 
 			if err == nil {
-				shader := shaders.NewPrimitiveShader()
-				err = initShaderProgram(shader)
+				err = mainLoop.Run(window, app)
 
 				if err == nil {
-					defer gl.DeleteShader(shader.VertexShaderID)
-					defer gl.DeleteShader(shader.FragmentShaderID)
-					defer gl.DeleteProgram(shader.ProgramID)
-					vbos := newVBOs(1)
-					defer gl.DeleteBuffers(int32(len(vbos)), &vbos[0])
-					vaos := newVAOs(1)
-					defer gl.DeleteVertexArrays(int32(len(vaos)), &vaos[0])
-
-					bindObjects(shader, vaos, vbos)
-					gl.UseProgram(shader.ProgramID)
-
-					// transparency
-					// gl.Enable(gl.BLEND);
-					// gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA);
-
-					// wireframe mode
-					// gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
-
-					for !window.ShouldClose() {
-						gl.ClearColor(0, 0, 0, 0)
-						gl.Clear(gl.COLOR_BUFFER_BIT)
-
-						for _, vao := range vaos {
-							gl.BindVertexArray(vao)
-							gl.DrawArrays(gl.TRIANGLES, 0, 3)
-						}
-						window.SwapBuffers()
-						glfw.PollEvents()
-					}
+					err = app.err
+				}
+				if err == nil && recorder != nil {
+					err = recorder.Save(*recordFile)
+				}
+				if err == nil && len(*statsFile) > 0 {
+					err = mainLoop.Stats.Save(*statsFile)
 				}
 			}
 		}
 	}
-	if err != nil {
-		fmt.Println(err.Error())
+	return err
+}
+
+// Init is called by loop.
+func (app *example) Init() error {
+	app.shader = shaders.NewPrimitiveShader()
+	err := initShaderProgram(app.shader)
+
+	if err == nil {
+		app.graph, err = gfx.NewFrameGraph()
+	}
+	if err == nil {
+		err = app.initMSAA()
+	}
+	if err == nil {
+		app.vbos = newVBOs(1)
+		app.vaos = newVAOs(1)
+		app.current.scale = 1
+		app.previous = app.current
+
+		bindObjects(app.shader, app.vaos, app.vbos)
+		gl.UseProgram(app.shader.ProgramID)
+
+		// transparency
+		// gl.Enable(gl.BLEND);
+		// gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA);
+
+		// wireframe mode
+		// gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
+	}
+	return err
+}
+
+// Update is called by loop. Left mouse button pans, right mouse button rotates, wheel zooms.
+func (app *example) Update(dt float64) {
+	app.previous = app.current
+	app.current = updateTransform(app.window, app.current, dt)
+	// mouse motion is consumed
+	mouse.EndFrame()
+}
+
+// Render is called by loop.
+func (app *example) Render(alpha float64) {
+	width, height := app.window.GetFramebufferSize()
+	offscreen := msaa && app.msaaFramebuffer != nil
+
+	// minimized window
+	if width == 0 || height == 0 {
+		return
+	}
+
+	if offscreen {
+		if err := app.msaaFramebuffer.Resize(width, height); err != nil {
+			app.err = err
+			app.window.SetShouldClose(true)
+			return
+		}
+		app.msaaFramebuffer.Bind()
+	}
+	if msaa {
+		gl.Enable(gl.MULTISAMPLE)
+	} else {
+		gl.Disable(gl.MULTISAMPLE)
+	}
+	draw(app.shader, app.vaos, app.previous.lerp(app.current, alpha))
+
+	if offscreen {
+		app.msaaFramebuffer.Resolve(nil, width, height)
+		gfx.BindDefault(width, height)
+	}
+
+	if showGraph {
+		app.graph.Draw(mainLoop.Stats)
+	}
+	if now := glfw.GetTime(); now-app.titleTime >= titleInterval {
+		app.window.SetTitle(title + " - " + app.msaaString() + " - " + mainLoop.Stats.Summary().String())
+		app.titleTime = now
+	}
+
+	if app.capture {
+		app.err = checkGolden(app.window, *goldenFile)
+		app.window.SetShouldClose(true)
 	}
 }
 
+// Shutdown is called by loop.
+func (app *example) Shutdown() {
+	app.graph.Delete()
+	if app.msaaFramebuffer != nil {
+		app.msaaFramebuffer.Delete()
+	}
+	gl.DeleteVertexArrays(int32(len(app.vaos)), &app.vaos[0])
+	gl.DeleteBuffers(int32(len(app.vbos)), &app.vbos[0])
+	gl.DeleteProgram(app.shader.ProgramID)
+	gl.DeleteShader(app.shader.FragmentShaderID)
+	gl.DeleteShader(app.shader.VertexShaderID)
+}
+
+// initMSAA checks the number of samples against gl.MAX_SAMPLES and creates the
+// multisampled framebuffer, if -msaa is framebuffer.
+func (app *example) initMSAA() error {
+	var err error
+	app.samples = *samples
+
+	if maxSamples := gfx.MaxSamples(); app.samples > maxSamples {
+		fmt.Printf("warning: %d samples not supported, using %d (GL_MAX_SAMPLES)\n", app.samples, maxSamples)
+		app.samples = maxSamples
+	}
+	if *msaaTarget == "window" {
+		// the window may have fewer samples than requested
+		app.samples = gfx.Samples()
+	} else if app.samples > 1 {
+		width, height := app.window.GetFramebufferSize()
+		config := gfx.FramebufferConfig{Width: width, Height: height, Colors: []int32{gl.RGBA8}, Samples: app.samples}
+		app.msaaFramebuffer, err = gfx.NewFramebuffer(config)
+	}
+	msaa = app.samples > 1
+	return err
+}
+
+// msaaString returns the state of multisample anti-aliasing for the title.
+func (app *example) msaaString() string {
+	if msaa && app.samples > 1 {
+		return fmt.Sprintf("MSAA %dx %s", app.samples, *msaaTarget)
+	}
+	return "MSAA off"
+}
+
+func (t transform) lerp(next transform, alpha float64) transform {
+	t.x += (next.x - t.x) * alpha
+	t.y += (next.y - t.y) * alpha
+	t.angle += (next.angle - t.angle) * alpha
+	t.scale += (next.scale - t.scale) * alpha
+	return t
+}
+
 func onKey(window *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
-	if key == glfw.KeyEscape && action == glfw.Press {
-		window.SetShouldClose(true)
+	if action == glfw.Press {
+		switch key {
+		case glfw.KeyEscape:
+			window.SetShouldClose(true)
+		case glfw.KeyC:
+			mouse.NextCursorMode(window)
+		case glfw.KeyF:
+			showGraph = !showGraph
+		case glfw.KeyM:
+			msaa = !msaa
+		case glfw.KeyP:
+			mainLoop.TogglePause()
+		case glfw.KeyN:
+			mainLoop.Step()
+		case glfw.KeyEqual, glfw.KeyKPAdd:
+			mainLoop.TimeScale *= 2
+		case glfw.KeyMinus, glfw.KeyKPSubtract:
+			mainLoop.TimeScale /= 2
+		}
 	}
 }
 
@@ -104,6 +388,7 @@ func initShaderProgram(shader *shaders.Shader) error {
 			if err == nil {
 				shader.PositionLocation = gl.GetAttribLocation(shader.ProgramID, shader.PositionAttribute)
 				shader.ColorLocation = gl.GetAttribLocation(shader.ProgramID, shader.ColorAttribute)
+				shader.ModelLocation = gl.GetUniformLocation(shader.ProgramID, shader.ModelUniform)
 
 			} else {
 				gl.DeleteShader(shader.VertexShaderID)
@@ -220,3 +505,131 @@ func bindObjects(shader *shaders.Shader, vaos, vbos []uint32) {
 	// color
 	gl.VertexAttribPointer(uint32(shader.ColorLocation), 4, gl.FLOAT, false, 7*4, gl.PtrOffset(3*4))
 }
+
+func loadReplay() (*input.Replay, error) {
+	if len(*replayFile) > 0 {
+		return input.LoadReplay(*replayFile)
+	}
+	return nil, nil
+}
+
+func loadMappings() error {
+	if _, err := os.Stat(mappingsFile); err == nil {
+		return input.LoadMappings(mappingsFile)
+	}
+	return nil
+}
+
+func updateTransform(window *glfw.Window, t transform, dt float64) transform {
+	width, height := window.GetSize()
+	if width > 0 && height > 0 {
+		if mouse.ButtonDown(glfw.MouseButtonLeft) {
+			t.x += mouse.DeltaX * 2 / float64(width)
+			t.y -= mouse.DeltaY * 2 / float64(height)
+		}
+		if mouse.ButtonDown(glfw.MouseButtonRight) {
+			t.angle -= mouse.DeltaX * math.Pi / float64(width)
+		}
+	}
+	t.x += float64(gamepads.Axis(glfw.AxisLeftX)) * 1.2 * dt
+	t.y -= float64(gamepads.Axis(glfw.AxisLeftY)) * 1.2 * dt
+	t.angle -= float64(gamepads.Axis(glfw.AxisRightX)) * 3 * dt
+	t.scale *= math.Pow(1.1, mouse.ScrollY)
+	t.scale *= math.Pow(3, float64(gamepads.Axis(glfw.AxisRightTrigger)-gamepads.Axis(glfw.AxisLeftTrigger))*dt)
+	return t
+}
+
+// newModelMatrix returns the model matrix: scale, then rotate around z, then translate.
+func newModelMatrix(t transform) vmath.Mat4 {
+	translation := vmath.Translate(vmath.Vec3{float32(t.x), float32(t.y), 0})
+	rotation := vmath.RotateZ(float32(t.angle))
+	scale := vmath.Scale(vmath.Vec3{float32(t.scale), float32(t.scale), 1})
+	return translation.Mul(rotation).Mul(scale)
+}
+
+func draw(shader *shaders.Shader, vaos []uint32, t transform) {
+	model := newModelMatrix(t)
+	gl.UniformMatrix4fv(shader.ModelLocation, 1, false, model.Ptr())
+
+	gl.ClearColor(0, 0, 0, 0)
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+
+	for _, vao := range vaos {
+		gl.BindVertexArray(vao)
+		gl.DrawArrays(gl.TRIANGLES, 0, 3)
+	}
+}
+
+// checkGolden compares the back buffer with the PNG image in file. If file does not exist,
+// the back buffer is written to it.
+func checkGolden(window *glfw.Window, path string) error {
+	width, height := window.GetFramebufferSize()
+	img := readPixels(width, height)
+	file, err := os.Open(path)
+
+	if err == nil {
+		var golden image.Image
+		golden, err = png.Decode(file)
+		file.Close()
+
+		if err == nil {
+			if n := countDifferentPixels(img, golden, 2); n > 0 {
+				err = fmt.Errorf("golden image %s differs in %d pixels", path, n)
+			}
+		}
+	} else if os.IsNotExist(err) {
+		file, err = os.Create(path)
+
+		if err == nil {
+			err = png.Encode(file, img)
+			errClose := file.Close()
+			if err == nil {
+				err = errClose
+			}
+		}
+	}
+	return err
+}
+
+func readPixels(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	gl.ReadBuffer(gl.BACK)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	gl.ReadPixels(0, 0, int32(width), int32(height), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+	// OpenGL's origin is bottom left
+	for y := 0; y < height/2; y++ {
+		top := img.Pix[y*img.Stride : (y+1)*img.Stride]
+		bottom := img.Pix[(height-1-y)*img.Stride : (height-y)*img.Stride]
+		for i := range top {
+			top[i], bottom[i] = bottom[i], top[i]
+		}
+	}
+	return img
+}
+
+// countDifferentPixels returns the number of pixels, whose color channels differ by more than tolerance.
+func countDifferentPixels(img *image.RGBA, golden image.Image, tolerance int) int {
+	if img.Bounds().Size() != golden.Bounds().Size() {
+		return img.Bounds().Dx() * img.Bounds().Dy()
+	}
+	var n int
+	offset := golden.Bounds().Min
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			r1, g1, b1, a1 := img.At(x, y).RGBA()
+			r2, g2, b2, a2 := golden.At(x+offset.X, y+offset.Y).RGBA()
+			if channelDiff(r1, r2) > tolerance || channelDiff(g1, g2) > tolerance || channelDiff(b1, b2) > tolerance || channelDiff(a1, a2) > tolerance {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func channelDiff(a, b uint32) int {
+	diff := int(a>>8) - int(b>>8)
+	if diff < 0 {
+		return -diff
+	}
+	return diff
+}
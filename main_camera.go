@@ -0,0 +1,257 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+// +build camera
+
+package main
+
+import (
+	"fmt"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/vbsw/opengl-go-example/camera"
+	"github.com/vbsw/opengl-go-example/gfx"
+	"github.com/vbsw/opengl-go-example/input"
+	"github.com/vbsw/opengl-go-example/loop"
+	"github.com/vbsw/opengl-go-example/scene"
+	"github.com/vbsw/opengl-go-example/vmath"
+	"github.com/vbsw/shaders"
+	"runtime"
+)
+
+// world units per pixel in 2D mode
+const scale2D = 60
+
+// rotation speed of the turntable in radians per second
+const turnSpeed = 0.5
+
+var mouse *input.Mouse
+var orbit *camera.Orbit
+var fly *camera.Fly
+var ortho *camera.Ortho2D
+var activeCamera camera.Camera
+
+type example struct {
+	window    *glfw.Window
+	shader    *shaders.Shader
+	vbos      []uint32
+	vaos      []uint32
+	root      *scene.Node
+	turntable *scene.Node
+	drawList  scene.DrawList
+}
+
+// primitiveMaterial draws with the primitive shader.
+type primitiveMaterial struct {
+	shader *shaders.Shader
+}
+
+func init() {
+	runtime.LockOSThread()
+}
+
+func main() {
+	err := glfw.Init()
+
+	if err == nil {
+		var window *glfw.Window
+		defer glfw.Terminate()
+		window, err = glfw.CreateWindow(600, 400, "OpenGL Example", nil, nil)
+
+		if err == nil {
+			defer window.Destroy()
+			width, height := window.GetSize()
+			orbit = camera.NewOrbit(vmath.Vec3{0, 0.5, 0}, 6, width, height)
+			fly = camera.NewFly(vmath.Vec3{0, 1, 6}, width, height)
+			ortho = camera.NewOrtho2D(width, height)
+			activeCamera = orbit
+			mouse = input.NewMouse()
+			mouse.Register(window)
+			window.SetKeyCallback(onKey)
+			window.SetSizeCallback(onResize)
+			window.MakeContextCurrent()
+			err = gl.Init()
+
+			if err == nil {
+				mainLoop := loop.New(1.0 / 60.0)
+				mainLoop.Clock = loop.ClockFunc(glfw.GetTime)
+				mainLoop.PollEvents = glfw.PollEvents
+				err = mainLoop.Run(window, &example{window: window})
+			}
+		}
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+	}
+}
+
+// Init is called by loop.
+func (app *example) Init() error {
+	var err error
+	app.shader = shaders.NewPrimitiveShader()
+	app.shader.ProgramID, err = gfx.NewProgram(app.shader.VertexShaderStr(), app.shader.FragmentShaderStr())
+
+	if err == nil {
+		app.shader.PositionLocation = gl.GetAttribLocation(app.shader.ProgramID, app.shader.PositionAttribute)
+		app.shader.ColorLocation = gl.GetAttribLocation(app.shader.ProgramID, app.shader.ColorAttribute)
+		app.shader.ProjectionLocation = gl.GetUniformLocation(app.shader.ProgramID, app.shader.ProjectionUniform)
+		app.shader.ModelLocation = gl.GetUniformLocation(app.shader.ProgramID, app.shader.ModelUniform)
+		app.vbos = newVBOs(2)
+		app.vaos = newVAOs(2)
+		grid := &gfx.Mesh{VAO: app.vaos[0], Mode: gl.LINES}
+		triangle := &gfx.Mesh{VAO: app.vaos[1], Mode: gl.TRIANGLES}
+		grid.Count = bindObjects(app.shader, grid.VAO, app.vbos[0], newGridVertices(5))
+		triangle.Count = bindObjects(app.shader, triangle.VAO, app.vbos[1], newTriangleVertices())
+		app.newScene(grid, triangle)
+	}
+	return err
+}
+
+// Update is called by loop.
+func (app *example) Update(dt float64) {
+	switch activeCamera {
+	case orbit:
+		orbit.HandleMouse(app.window, mouse)
+	case fly:
+		fly.HandleMouse(app.window, mouse)
+		fly.HandleKeys(app.window, float32(dt))
+	case ortho:
+		ortho.HandleMouse(app.window, mouse)
+	}
+	app.turntable.Rotate(vmath.QuatAxisAngle(vmath.Vec3{0, 1, 0}, float32(dt*turnSpeed)))
+	mouse.EndFrame()
+}
+
+// Render is called by loop.
+func (app *example) Render(alpha float64) {
+	if activeCamera == ortho {
+		app.root.SetScale(vmath.Vec3{scale2D, scale2D, scale2D})
+	} else {
+		app.root.SetScale(vmath.Vec3{1, 1, 1})
+	}
+	gl.ClearColor(0, 0, 0, 0)
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+
+	gl.UseProgram(app.shader.ProgramID)
+	camera.SetUniforms(activeCamera, app.shader.ProjectionLocation, -1)
+
+	app.drawList.Reset()
+	app.drawList.Collect(app.root)
+	app.drawList.Draw()
+	gl.BindVertexArray(0)
+}
+
+// Shutdown is called by loop.
+func (app *example) Shutdown() {
+	gl.DeleteVertexArrays(int32(len(app.vaos)), &app.vaos[0])
+	gl.DeleteBuffers(int32(len(app.vbos)), &app.vbos[0])
+	gl.DeleteProgram(app.shader.ProgramID)
+}
+
+// newScene builds the scene graph: the grid and a turntable with three triangles,
+// each one carrying a small triangle as child.
+func (app *example) newScene(grid, triangle *gfx.Mesh) {
+	app.root = scene.NewNode("root")
+	app.root.Material = &primitiveMaterial{app.shader}
+	gridNode := scene.NewNode("grid")
+	gridNode.Mesh = grid
+	app.root.Add(gridNode)
+	app.turntable = scene.NewNode("turntable")
+	app.root.Add(app.turntable)
+	positions := []vmath.Vec3{{0, 0, 0}, {2, 0, -2}, {-2, 0, -3}}
+	for i, position := range positions {
+		node := scene.NewNode(fmt.Sprintf("triangle%d", i))
+		node.Mesh = triangle
+		node.SetPosition(position)
+		child := scene.NewNode(fmt.Sprintf("triangle%d.child", i))
+		child.Mesh = triangle
+		child.SetPosition(vmath.Vec3{0, 1, 0})
+		child.SetScale(vmath.Vec3{0.3, 0.3, 0.3})
+		child.SetRotation(vmath.QuatAxisAngle(vmath.Vec3{0, 1, 0}, vmath.Radians(90)))
+		node.Add(child)
+		app.turntable.Add(node)
+	}
+}
+
+// Apply sets the model matrix of the primitive shader.
+func (material *primitiveMaterial) Apply(model vmath.Mat4) {
+	gl.UniformMatrix4fv(material.shader.ModelLocation, 1, false, model.Ptr())
+}
+
+func onKey(window *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+	if action == glfw.Press {
+		switch key {
+		case glfw.KeyEscape:
+			window.SetShouldClose(true)
+		case glfw.Key1:
+			activeCamera = orbit
+		case glfw.Key2:
+			activeCamera = fly
+		case glfw.Key3:
+			activeCamera = ortho
+		case glfw.KeyC:
+			mouse.NextCursorMode(window)
+		}
+	}
+}
+
+func onResize(w *glfw.Window, width, height int) {
+	gl.Viewport(0, 0, int32(width), int32(height))
+	orbit.Resize(width, height)
+	fly.Resize(width, height)
+	ortho.Resize(width, height)
+}
+
+func newVBOs(n int) []uint32 {
+	vbos := make([]uint32, n)
+	gl.GenBuffers(int32(len(vbos)), &vbos[0])
+	return vbos
+}
+
+func newVAOs(n int) []uint32 {
+	vaos := make([]uint32, n)
+	gl.GenVertexArrays(int32(len(vaos)), &vaos[0])
+	return vaos
+}
+
+// bindObjects uploads vertices (x, y, z, r, g, b, a) and returns the number of vertices.
+func bindObjects(shader *shaders.Shader, vao, vbo uint32, vertices []float32) int32 {
+	gl.BindVertexArray(vao)
+	gl.EnableVertexAttribArray(uint32(shader.PositionLocation))
+	gl.EnableVertexAttribArray(uint32(shader.ColorLocation))
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices), gl.STATIC_DRAW)
+	// position
+	gl.VertexAttribPointer(uint32(shader.PositionLocation), 3, gl.FLOAT, false, 7*4, gl.PtrOffset(0))
+	// color
+	gl.VertexAttribPointer(uint32(shader.ColorLocation), 4, gl.FLOAT, false, 7*4, gl.PtrOffset(3*4))
+	gl.BindVertexArray(0)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	return int32(len(vertices) / 7)
+}
+
+// newGridVertices returns lines on the ground plane (y = 0) from -size to size.
+func newGridVertices(size int) []float32 {
+	var vertices []float32
+	extent := float32(size)
+	for i := -size; i <= size; i++ {
+		pos := float32(i)
+		vertices = append(vertices,
+			pos, 0, -extent, 0.4, 0.4, 0.4, 1,
+			pos, 0, extent, 0.4, 0.4, 0.4, 1,
+			-extent, 0, pos, 0.4, 0.4, 0.4, 1,
+			extent, 0, pos, 0.4, 0.4, 0.4, 1)
+	}
+	return vertices
+}
+
+// newTriangleVertices returns an upright triangle standing on the origin.
+func newTriangleVertices() []float32 {
+	return []float32{
+		0, 1, 0, 1, 0, 0, 1,
+		0.8, 0, 0, 0, 1, 0, 1,
+		-0.8, 0, 0, 0, 0, 1, 1}
+}
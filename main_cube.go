@@ -0,0 +1,279 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+// +build cube
+
+package main
+
+import (
+	"fmt"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/vbsw/opengl-go-example/camera"
+	"github.com/vbsw/opengl-go-example/gfx"
+	"github.com/vbsw/opengl-go-example/input"
+	"github.com/vbsw/opengl-go-example/loop"
+	"github.com/vbsw/opengl-go-example/mesh"
+	"github.com/vbsw/opengl-go-example/scene"
+	"github.com/vbsw/opengl-go-example/vmath"
+	"github.com/vbsw/shaders"
+	"runtime"
+)
+
+// number of cubes per row and column
+const cubesPerRow = 5
+
+var mouse *input.Mouse
+var orbit *camera.Orbit
+
+// state is shared by all cubes and changed with keys D, B and W
+var state gfx.RenderState
+
+// index of the shape drawn on all nodes, changed with key S
+var shape int
+
+type example struct {
+	window   *glfw.Window
+	shader   *shaders.Shader
+	vbo      uint32
+	vao      uint32
+	shapes   []*gfx.Mesh
+	shape    int
+	root     *scene.Node
+	cubes    []spinningCube
+	drawList scene.DrawList
+}
+
+type spinningCube struct {
+	node *scene.Node
+	axis vmath.Vec3
+	// radians per second
+	speed float32
+}
+
+// cubeMaterial draws with the primitive shader and a render state.
+type cubeMaterial struct {
+	shader *shaders.Shader
+	state  *gfx.RenderState
+}
+
+func init() {
+	runtime.LockOSThread()
+}
+
+func main() {
+	err := glfw.Init()
+
+	if err == nil {
+		var window *glfw.Window
+		defer glfw.Terminate()
+		glfw.WindowHint(glfw.DepthBits, 24)
+		glfw.WindowHint(glfw.StencilBits, 8)
+		window, err = glfw.CreateWindow(600, 400, "OpenGL Example", nil, nil)
+
+		if err == nil {
+			defer window.Destroy()
+			width, height := window.GetSize()
+			orbit = camera.NewOrbit(vmath.Vec3{0, 0, 0}, 9, width, height)
+			orbit.Pitch = vmath.Radians(-25)
+			state = gfx.Opaque3D()
+			mouse = input.NewMouse()
+			mouse.Register(window)
+			window.SetKeyCallback(onKey)
+			window.SetSizeCallback(onResize)
+			window.MakeContextCurrent()
+			err = gl.Init()
+
+			if err == nil {
+				mainLoop := loop.New(1.0 / 60.0)
+				mainLoop.Clock = loop.ClockFunc(glfw.GetTime)
+				mainLoop.PollEvents = glfw.PollEvents
+				err = mainLoop.Run(window, &example{window: window})
+			}
+		}
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+	}
+}
+
+// Init is called by loop.
+func (app *example) Init() error {
+	var err error
+	app.shader = shaders.NewPrimitiveShader()
+	app.shader.ProgramID, err = gfx.NewProgram(app.shader.VertexShaderStr(), app.shader.FragmentShaderStr())
+
+	if err == nil {
+		if gfx.DepthBits() == 0 {
+			fmt.Println("warning: default framebuffer has no depth buffer")
+		}
+		app.shader.PositionLocation = gl.GetAttribLocation(app.shader.ProgramID, app.shader.PositionAttribute)
+		app.shader.ColorLocation = gl.GetAttribLocation(app.shader.ProgramID, app.shader.ColorAttribute)
+		app.shader.ProjectionLocation = gl.GetUniformLocation(app.shader.ProgramID, app.shader.ProjectionUniform)
+		app.shader.ModelLocation = gl.GetUniformLocation(app.shader.ProgramID, app.shader.ModelUniform)
+		gl.GenBuffers(1, &app.vbo)
+		gl.GenVertexArrays(1, &app.vao)
+		cube := &gfx.Mesh{VAO: app.vao, Mode: gl.TRIANGLES}
+		cube.Count = bindObjects(app.shader, app.vao, app.vbo, newCubeVertices())
+		app.shapes = append([]*gfx.Mesh{cube}, app.newShapes()...)
+		app.newScene(cube)
+	}
+	return err
+}
+
+// Update is called by loop.
+func (app *example) Update(dt float64) {
+	orbit.HandleMouse(app.window, mouse)
+	if app.shape != shape {
+		app.shape = shape % len(app.shapes)
+		shape = app.shape
+		for _, cube := range app.cubes {
+			cube.node.Mesh = app.shapes[app.shape]
+		}
+	}
+	for _, cube := range app.cubes {
+		cube.node.Rotate(vmath.QuatAxisAngle(cube.axis, cube.speed*float32(dt)))
+	}
+	mouse.EndFrame()
+}
+
+// Render is called by loop.
+func (app *example) Render(alpha float64) {
+	gl.ClearColor(0, 0, 0, 0)
+	// depth writes must be enabled to clear the depth buffer
+	gl.DepthMask(true)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+	gl.UseProgram(app.shader.ProgramID)
+	camera.SetUniforms(orbit, app.shader.ProjectionLocation, -1)
+
+	app.drawList.Reset()
+	app.drawList.Collect(app.root)
+	app.drawList.Draw()
+	gl.BindVertexArray(0)
+}
+
+// Shutdown is called by loop.
+func (app *example) Shutdown() {
+	for _, shape := range app.shapes[1:] {
+		shape.Delete()
+	}
+	gl.DeleteVertexArrays(1, &app.vao)
+	gl.DeleteBuffers(1, &app.vbo)
+	gl.DeleteProgram(app.shader.ProgramID)
+}
+
+// newShapes returns procedural meshes. The primitive shader has no normals, so they
+// are shown as colors.
+func (app *example) newShapes() []*gfx.Mesh {
+	var shapes []*gfx.Mesh
+	generated := []*mesh.Mesh{
+		mesh.UVSphere(1, 24, 12),
+		mesh.Icosphere(1, 2),
+		mesh.Cylinder(0.8, 2, 24, 1, true),
+		mesh.Cone(1, 2, 24, 1, true),
+		mesh.Torus(0.8, 0.35, 32, 16),
+		mesh.Capsule(0.6, 1, 24, 6, 1),
+		mesh.Plane(2, 2, 4, 4),
+	}
+	for _, m := range generated {
+		position := gfx.VertexAttribute{Location: app.shader.PositionLocation, Size: 3, Offset: mesh.PositionOffset}
+		normal := gfx.VertexAttribute{Location: app.shader.ColorLocation, Size: 3, Offset: mesh.NormalOffset}
+		shapes = append(shapes, gfx.NewMesh(gl.TRIANGLES, m.VertexData(), mesh.VertexSize, m.Indices, position, normal))
+	}
+	return shapes
+}
+
+// newScene places the cubes on a grid. Every cube spins around its own axis.
+func (app *example) newScene(cube *gfx.Mesh) {
+	app.root = scene.NewNode("root")
+	app.root.Material = &cubeMaterial{app.shader, &state}
+	for row := 0; row < cubesPerRow; row++ {
+		for col := 0; col < cubesPerRow; col++ {
+			node := scene.NewNode(fmt.Sprintf("cube%d.%d", row, col))
+			node.Mesh = cube
+			node.SetPosition(vmath.Vec3{float32(col-cubesPerRow/2) * 1.8, 0, float32(row-cubesPerRow/2) * 1.8})
+			node.SetScale(vmath.Vec3{0.6, 0.6, 0.6})
+			axis := vmath.Vec3{float32(row + 1), float32(col + 1), float32((row + col) % 3)}.Normalize()
+			app.cubes = append(app.cubes, spinningCube{node, axis, 0.5 + float32(row*cubesPerRow+col)*0.1})
+			app.root.Add(node)
+		}
+	}
+}
+
+// Apply sets the render state and the model matrix of the primitive shader.
+func (material *cubeMaterial) Apply(model vmath.Mat4) {
+	material.state.Apply()
+	gl.UniformMatrix4fv(material.shader.ModelLocation, 1, false, model.Ptr())
+}
+
+func onKey(window *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+	if action == glfw.Press {
+		switch key {
+		case glfw.KeyEscape:
+			window.SetShouldClose(true)
+		case glfw.KeyD:
+			state.DepthTest = !state.DepthTest
+		case glfw.KeyB:
+			state.Cull = !state.Cull
+		case glfw.KeyW:
+			if state.FrontFace == gl.CCW {
+				state.FrontFace = gl.CW
+			} else {
+				state.FrontFace = gl.CCW
+			}
+		case glfw.KeyS:
+			shape++
+		case glfw.KeyC:
+			mouse.NextCursorMode(window)
+		}
+	}
+}
+
+func onResize(w *glfw.Window, width, height int) {
+	gl.Viewport(0, 0, int32(width), int32(height))
+	orbit.Resize(width, height)
+}
+
+// bindObjects uploads vertices (x, y, z, r, g, b, a) and returns the number of vertices.
+func bindObjects(shader *shaders.Shader, vao, vbo uint32, vertices []float32) int32 {
+	gl.BindVertexArray(vao)
+	gl.EnableVertexAttribArray(uint32(shader.PositionLocation))
+	gl.EnableVertexAttribArray(uint32(shader.ColorLocation))
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices), gl.STATIC_DRAW)
+	// position
+	gl.VertexAttribPointer(uint32(shader.PositionLocation), 3, gl.FLOAT, false, 7*4, gl.PtrOffset(0))
+	// color
+	gl.VertexAttribPointer(uint32(shader.ColorLocation), 4, gl.FLOAT, false, 7*4, gl.PtrOffset(3*4))
+	gl.BindVertexArray(0)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	return int32(len(vertices) / 7)
+}
+
+// newCubeVertices returns a cube from -1 to 1 with a different color on every face.
+// Faces are counter-clockwise seen from outside.
+func newCubeVertices() []float32 {
+	var vertices []float32
+	normals := []vmath.Vec3{{1, 0, 0}, {-1, 0, 0}, {0, 1, 0}, {0, -1, 0}, {0, 0, 1}, {0, 0, -1}}
+	colors := []vmath.Vec4{{1, 0, 0, 1}, {0, 1, 1, 1}, {0, 1, 0, 1}, {1, 0, 1, 1}, {0, 0, 1, 1}, {1, 1, 0, 1}}
+	for i, normal := range normals {
+		v := vmath.Vec3{0, 1, 0}
+		if normal[1] != 0 {
+			v = vmath.Vec3{0, 0, 1}
+		}
+		// u x v = normal
+		u := v.Cross(normal)
+		corners := []vmath.Vec3{
+			normal.Sub(u).Sub(v), normal.Add(u).Sub(v), normal.Add(u).Add(v),
+			normal.Sub(u).Sub(v), normal.Add(u).Add(v), normal.Sub(u).Add(v)}
+		for _, corner := range corners {
+			c := colors[i]
+			vertices = append(vertices, corner[0], corner[1], corner[2], c[0], c[1], c[2], c[3])
+		}
+	}
+	return vertices
+}
@@ -0,0 +1,263 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+// +build framebuffer
+
+package main
+
+import (
+	"fmt"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/vbsw/opengl-go-example/gfx"
+	"github.com/vbsw/opengl-go-example/loop"
+	"github.com/vbsw/opengl-go-example/vmath"
+	"github.com/vbsw/shaders"
+	"runtime"
+	"unsafe"
+)
+
+var framebuffer *gfx.Framebuffer
+var windowWidth, windowHeight int
+
+// true, if the framebuffer is copied with BlitFramebuffer instead of drawn on a quad
+var blit bool
+
+type example struct {
+	primitiveShader *shaders.Shader
+	textureShader   *shaders.Shader
+	vbos            []uint32
+	vaos            []uint32
+	// rotation of triangle and quad in radians
+	angle, previousAngle float64
+}
+
+func init() {
+	runtime.LockOSThread()
+}
+
+func main() {
+	err := glfw.Init()
+
+	if err == nil {
+		var window *glfw.Window
+		defer glfw.Terminate()
+		window, err = glfw.CreateWindow(400, 400, "OpenGL Example", nil, nil)
+
+		if err == nil {
+			defer window.Destroy()
+			window.SetKeyCallback(onKey)
+			window.SetFramebufferSizeCallback(onResize)
+			window.MakeContextCurrent()
+			windowWidth, windowHeight = window.GetFramebufferSize()
+			err = gl.Init()
+
+			if err == nil {
+				mainLoop := loop.New(1.0 / 60.0)
+				mainLoop.Clock = loop.ClockFunc(glfw.GetTime)
+				mainLoop.PollEvents = glfw.PollEvents
+				err = mainLoop.Run(window, new(example))
+			}
+		}
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+	}
+}
+
+// Init is called by loop.
+func (app *example) Init() error {
+	var err error
+	app.primitiveShader = shaders.NewPrimitiveShader()
+	app.textureShader = shaders.NewTextureShader()
+	err = initShaderProgram(app.primitiveShader)
+
+	if err == nil {
+		err = initShaderProgram(app.textureShader)
+
+		if err == nil {
+			config := gfx.FramebufferConfig{Width: windowWidth, Height: windowHeight, Colors: []int32{gl.RGBA8}, Depth: gfx.DepthRenderbuffer}
+			framebuffer, err = gfx.NewFramebuffer(config)
+
+			if err == nil {
+				app.vbos = newVBOs(3)
+				app.vaos = newVAOs(2)
+				bindPrimitiveObjects(app.primitiveShader, app.vaos, app.vbos)
+				bindTextureObjects(app.textureShader, app.vaos[1:], app.vbos[1:])
+			} else {
+				gl.DeleteProgram(app.textureShader.ProgramID)
+				gl.DeleteProgram(app.primitiveShader.ProgramID)
+			}
+		} else {
+			gl.DeleteProgram(app.primitiveShader.ProgramID)
+		}
+	}
+	return err
+}
+
+// Update is called by loop.
+func (app *example) Update(dt float64) {
+	app.previousAngle = app.angle
+	app.angle += dt
+}
+
+// Render is called by loop.
+func (app *example) Render(alpha float64) {
+	angle := float32(app.previousAngle + (app.angle-app.previousAngle)*alpha)
+
+	// triangle into framebuffer
+	framebuffer.Bind()
+	gl.ClearColor(0.1, 0.1, 0.3, 1)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	model := vmath.RotateZ(angle)
+	gl.UseProgram(app.primitiveShader.ProgramID)
+	gl.UniformMatrix4fv(app.primitiveShader.ModelLocation, 1, false, model.Ptr())
+	gl.BindVertexArray(app.vaos[0])
+	gl.DrawArrays(gl.TRIANGLES, 0, 3)
+
+	gfx.BindDefault(windowWidth, windowHeight)
+	gl.ClearColor(0, 0, 0, 0)
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+
+	if blit {
+		framebuffer.BlitToScreen(windowWidth, windowHeight)
+	} else {
+		// framebuffer texture on a quad rotating around the y axis
+		model = vmath.RotateY(angle * 0.5).Mul(vmath.Scale(vmath.Vec3{0.8, 0.8, 0.8}))
+		gl.UseProgram(app.textureShader.ProgramID)
+		gl.UniformMatrix4fv(app.textureShader.ModelLocation, 1, false, model.Ptr())
+		gl.BindVertexArray(app.vaos[1])
+		gl.ActiveTexture(gl.TEXTURE0)
+		gl.BindTexture(gl.TEXTURE_2D, framebuffer.Texture(0))
+		gl.DrawElements(gl.TRIANGLES, 6, gl.UNSIGNED_INT, unsafe.Pointer(nil))
+		gl.BindTexture(gl.TEXTURE_2D, 0)
+	}
+	gl.BindVertexArray(0)
+}
+
+// Shutdown is called by loop.
+func (app *example) Shutdown() {
+	framebuffer.Delete()
+	gl.DeleteVertexArrays(int32(len(app.vaos)), &app.vaos[0])
+	gl.DeleteBuffers(int32(len(app.vbos)), &app.vbos[0])
+	gl.DeleteProgram(app.textureShader.ProgramID)
+	gl.DeleteProgram(app.primitiveShader.ProgramID)
+}
+
+func onKey(window *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+	if action == glfw.Press {
+		switch key {
+		case glfw.KeyEscape:
+			window.SetShouldClose(true)
+		case glfw.KeyB:
+			blit = !blit
+		}
+	}
+}
+
+// onResize resizes the framebuffer with the window (size in pixels).
+func onResize(w *glfw.Window, width, height int) {
+	if width > 0 && height > 0 {
+		windowWidth, windowHeight = width, height
+		err := framebuffer.Resize(width, height)
+
+		if err != nil {
+			fmt.Println(err.Error())
+			w.SetShouldClose(true)
+		}
+	}
+}
+
+func initShaderProgram(shader *shaders.Shader) error {
+	var err error
+	shader.ProgramID, err = gfx.NewProgram(shader.VertexShaderStr(), shader.FragmentShaderStr())
+
+	if err == nil {
+		shader.PositionLocation = gl.GetAttribLocation(shader.ProgramID, shader.PositionAttribute)
+		shader.ModelLocation = gl.GetUniformLocation(shader.ProgramID, shader.ModelUniform)
+
+		if shader.ColorAttribute != nil {
+			shader.ColorLocation = gl.GetAttribLocation(shader.ProgramID, shader.ColorAttribute)
+		}
+		if shader.CoordsAttribute != nil {
+			shader.CoordsLocation = gl.GetAttribLocation(shader.ProgramID, shader.CoordsAttribute)
+			shader.TextureLocation = gl.GetUniformLocation(shader.ProgramID, shader.TextureUniform)
+		}
+	}
+	return err
+}
+
+func newVBOs(n int) []uint32 {
+	vbos := make([]uint32, n)
+	gl.GenBuffers(int32(len(vbos)), &vbos[0])
+	return vbos
+}
+
+func newVAOs(n int) []uint32 {
+	vaos := make([]uint32, n)
+	gl.GenVertexArrays(int32(len(vaos)), &vaos[0])
+	return vaos
+}
+
+func bindPrimitiveObjects(shader *shaders.Shader, vaos, vbos []uint32) {
+	// x, y, z, r, g, b, a (one triangle)
+	vertices := []float32{
+		0.0, 0.8, 0.0, 1.0, 0.0, 0.0, 1.0,
+		0.7, -0.4, 0.0, 0.0, 1.0, 0.0, 1.0,
+		-0.7, -0.4, 0.0, 0.0, 0.0, 1.0, 1.0,
+	}
+	gl.BindVertexArray(vaos[0])
+	gl.EnableVertexAttribArray(uint32(shader.PositionLocation))
+	gl.EnableVertexAttribArray(uint32(shader.ColorLocation))
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbos[0])
+	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices), gl.STATIC_DRAW)
+	// position
+	gl.VertexAttribPointer(uint32(shader.PositionLocation), 3, gl.FLOAT, false, 7*4, gl.PtrOffset(0))
+	// color
+	gl.VertexAttribPointer(uint32(shader.ColorLocation), 4, gl.FLOAT, false, 7*4, gl.PtrOffset(3*4))
+	gl.BindVertexArray(0)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+}
+
+// bindTextureObjects sets up a quad from -1 to 1 showing the whole texture.
+func bindTextureObjects(shader *shaders.Shader, vaos, bufferObjs []uint32) {
+	// x, y, z, x_tex, y_tex (two triangles)
+	vertices := []float32{
+		1.0, 1.0, 0.0, 1.0, 1.0,
+		1.0, -1.0, 0.0, 1.0, 0.0,
+		-1.0, 1.0, 0.0, 0.0, 1.0,
+		-1.0, -1.0, 0.0, 0.0, 0.0,
+	}
+	// indexed drawing
+	indices := []uint32{
+		0, 1, 2,
+		2, 1, 3,
+	}
+
+	gl.BindVertexArray(vaos[0])
+
+	// vertex buffer object (VBO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, bufferObjs[0])
+	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices), gl.STATIC_DRAW)
+
+	// element buffer object (EBO), stored in the vertex array object
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, bufferObjs[1])
+	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(indices)*4, gl.Ptr(indices), gl.STATIC_DRAW)
+
+	// position
+	gl.VertexAttribPointer(uint32(shader.PositionLocation), 3, gl.FLOAT, false, 5*4, gl.PtrOffset(0))
+	gl.EnableVertexAttribArray(uint32(shader.PositionLocation))
+	// texture coordinates
+	gl.VertexAttribPointer(uint32(shader.CoordsLocation), 2, gl.FLOAT, false, 5*4, gl.PtrOffset(3*4))
+	gl.EnableVertexAttribArray(uint32(shader.CoordsLocation))
+
+	gl.UseProgram(shader.ProgramID)
+	gl.Uniform1i(shader.TextureLocation, 0)
+	gl.UseProgram(0)
+
+	gl.BindVertexArray(0)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+}
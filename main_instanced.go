@@ -0,0 +1,366 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+// +build instanced
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/vbsw/opengl-go-example/camera"
+	"github.com/vbsw/opengl-go-example/gfx"
+	"github.com/vbsw/opengl-go-example/input"
+	"github.com/vbsw/opengl-go-example/loop"
+	"github.com/vbsw/opengl-go-example/stats"
+	"github.com/vbsw/opengl-go-example/vmath"
+	"math"
+	"math/rand"
+	"runtime"
+)
+
+// The same shader source is used for instanced and naive drawing. With INSTANCED
+// defined, model, color and uv rectangle are per-instance attributes, otherwise
+// they are uniforms set before every draw call.
+const vertexShader = `
+in vec3 positionIn;
+in vec2 uvIn;
+#ifdef INSTANCED
+in mat4 instanceModel;
+in vec4 instanceColor;
+in vec4 instanceUVRect;
+#else
+uniform mat4 instanceModel;
+uniform vec4 instanceColor;
+uniform vec4 instanceUVRect;
+#endif
+uniform mat4 viewProjection;
+out vec4 fragmentColor;
+out vec2 fragmentUV;
+
+void main() {
+	gl_Position = viewProjection * instanceModel * vec4(positionIn, 1.0);
+	fragmentColor = instanceColor;
+	fragmentUV = instanceUVRect.xy + uvIn * instanceUVRect.zw;
+}
+`
+
+const fragmentShader = `
+in vec4 fragmentColor;
+in vec2 fragmentUV;
+uniform sampler2D image;
+out vec4 color;
+
+void main() {
+	color = texture(image, fragmentUV) * fragmentColor;
+}
+`
+
+// the textured quad of the texture examples: x, y, z, u, v (two triangles)
+var quadVertices = []float32{
+	0.5, 0.5, 0.0, 1.0, 1.0,
+	0.5, 0.0, 0.0, 1.0, 0.0,
+	0.0, 0.5, 0.0, 0.0, 1.0,
+	0.0, 0.0, 0.0, 0.0, 0.0,
+}
+
+var quadIndices = []uint32{
+	0, 1, 2,
+	2, 1, 3,
+}
+
+const quadVertexSize = 5
+
+// the atlas has atlasTiles x atlasTiles tiles of tileSize x tileSize texels
+const atlasTiles = 4
+const tileSize = 64
+
+// model (16), color (4), uv rectangle (4)
+const instanceSize = 24
+
+// title is updated with frame statistics in this interval (seconds)
+const titleInterval = 0.5
+
+// number of frames measured per method with -benchmark
+const benchmarkFrames = 300
+
+const title = "OpenGL Example"
+
+var instanceCount = flag.Int("instances", 100000, "number of quads")
+var naive = flag.Bool("naive", false, "start with one draw call per quad")
+var statsFile = flag.String("stats", "", "write frame timings to file on exit (.json or .csv)")
+var benchmark = flag.Bool("benchmark", false, "measure instanced and naive drawing and print the results")
+
+var mouse *input.Mouse
+var orbit *camera.Orbit
+var mainLoop *loop.Loop
+
+type example struct {
+	window *glfw.Window
+	// instanced and naive program
+	programs [2]uint32
+	// quad with attribute locations of instanced and naive program
+	meshes    [2]*gfx.Mesh
+	atlas     *gfx.Texture
+	instances *gfx.InstanceBuffer
+	data      []float32
+	state     gfx.RenderState
+	titleTime float64
+	// frames rendered with the current method
+	frames  int
+	results []string
+	// uniform locations of the naive program
+	modelLocation  int32
+	colorLocation  int32
+	uvRectLocation int32
+}
+
+func init() {
+	runtime.LockOSThread()
+}
+
+func main() {
+	flag.Parse()
+	err := glfw.Init()
+
+	if err == nil {
+		var window *glfw.Window
+		defer glfw.Terminate()
+		glfw.WindowHint(glfw.DepthBits, 24)
+		window, err = glfw.CreateWindow(800, 600, title, nil, nil)
+
+		if err == nil {
+			defer window.Destroy()
+			width, height := window.GetSize()
+			side := float32(math.Cbrt(float64(*instanceCount)))
+			if side < 1 {
+				side = 1
+			}
+			orbit = camera.NewOrbit(vmath.Vec3{0, 0, 0}, side*1.5, width, height)
+			orbit.Far = side * 4
+			orbit.MaxDistance = side * 3
+			mouse = input.NewMouse()
+			mouse.Register(window)
+			window.SetKeyCallback(onKey)
+			window.SetSizeCallback(onResize)
+			window.MakeContextCurrent()
+			// don't limit frame rate to compare performance
+			glfw.SwapInterval(0)
+			err = gl.Init()
+
+			if err == nil {
+				mainLoop = loop.New(1.0 / 60.0)
+				mainLoop.Clock = loop.ClockFunc(glfw.GetTime)
+				mainLoop.PollEvents = glfw.PollEvents
+				mainLoop.Stats = stats.New(stats.DefaultCapacity)
+				if *benchmark {
+					mainLoop.Stats = stats.New(benchmarkFrames)
+				}
+				app := &example{window: window}
+				err = mainLoop.Run(window, app)
+
+				for _, result := range app.results {
+					fmt.Println(result)
+				}
+				if err == nil && len(*statsFile) > 0 {
+					err = mainLoop.Stats.Save(*statsFile)
+				}
+			}
+		}
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+	}
+}
+
+// Init is called by loop.
+func (app *example) Init() error {
+	var err error
+	app.programs[0], err = gfx.NewProgram("#version 130\n#define INSTANCED\n"+vertexShader, "#version 130\n"+fragmentShader)
+
+	if err == nil {
+		app.programs[1], err = gfx.NewProgram("#version 130\n"+vertexShader, "#version 130\n"+fragmentShader)
+
+		if err == nil {
+			for i, program := range app.programs {
+				position := gfx.VertexAttribute{Location: gfx.AttribLocation(program, "positionIn"), Size: 3, Offset: 0}
+				uv := gfx.VertexAttribute{Location: gfx.AttribLocation(program, "uvIn"), Size: 2, Offset: 3}
+				app.meshes[i] = gfx.NewMesh(gl.TRIANGLES, quadVertices, quadVertexSize, quadIndices, position, uv)
+			}
+			app.atlas = newAtlas()
+			program := app.programs[0]
+			model := gfx.VertexAttribute{Location: gfx.AttribLocation(program, "instanceModel"), Size: 16, Offset: 0}
+			color := gfx.VertexAttribute{Location: gfx.AttribLocation(program, "instanceColor"), Size: 4, Offset: 16}
+			uvRect := gfx.VertexAttribute{Location: gfx.AttribLocation(program, "instanceUVRect"), Size: 4, Offset: 20}
+			app.instances = gfx.NewInstanceBuffer(app.meshes[0], instanceSize, model, color, uvRect)
+			app.data = newInstanceData(*instanceCount)
+			app.instances.Update(app.data, gl.STATIC_DRAW)
+
+			app.modelLocation = gfx.UniformLocation(app.programs[1], "instanceModel")
+			app.colorLocation = gfx.UniformLocation(app.programs[1], "instanceColor")
+			app.uvRectLocation = gfx.UniformLocation(app.programs[1], "instanceUVRect")
+			app.state = gfx.Opaque3D()
+			// quads are seen from both sides
+			app.state.Cull = false
+		}
+	}
+	return err
+}
+
+// Update is called by loop.
+func (app *example) Update(dt float64) {
+	orbit.HandleMouse(app.window, mouse)
+	mouse.EndFrame()
+}
+
+// Render is called by loop.
+func (app *example) Render(alpha float64) {
+	viewProjection := camera.ViewProjection(orbit)
+	gl.ClearColor(0.1, 0.1, 0.15, 1)
+	gl.DepthMask(true)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	app.state.Apply()
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, app.atlas.ID)
+
+	if *naive {
+		gl.UseProgram(app.programs[1])
+		gl.UniformMatrix4fv(gfx.UniformLocation(app.programs[1], "viewProjection"), 1, false, viewProjection.Ptr())
+		app.drawNaive()
+	} else {
+		gl.UseProgram(app.programs[0])
+		gl.UniformMatrix4fv(gfx.UniformLocation(app.programs[0], "viewProjection"), 1, false, viewProjection.Ptr())
+		app.meshes[0].DrawInstanced(app.instances.Count)
+	}
+	gl.BindVertexArray(0)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	app.frames++
+	if *benchmark && app.frames == benchmarkFrames+1 {
+		app.nextMethod()
+	}
+	if now := glfw.GetTime(); now-app.titleTime >= titleInterval {
+		app.window.SetTitle(fmt.Sprintf("%s - %d quads %s - %s", title, app.instances.Count, method(), mainLoop.Stats.Summary().String()))
+		app.titleTime = now
+	}
+}
+
+// Shutdown is called by loop.
+func (app *example) Shutdown() {
+	app.instances.Delete()
+	app.atlas.Delete()
+	app.meshes[0].Delete()
+	app.meshes[1].Delete()
+	gl.DeleteProgram(app.programs[0])
+	gl.DeleteProgram(app.programs[1])
+}
+
+// nextMethod stores the result of the current draw method and switches to the
+// other one. The window closes after both methods have been measured.
+func (app *example) nextMethod() {
+	summary := mainLoop.Stats.Summary()
+	app.results = append(app.results, fmt.Sprintf("%-10s %s", method()+":", summary.String()))
+	if len(app.results) == 2 {
+		app.window.SetShouldClose(true)
+	} else {
+		*naive = !*naive
+		mainLoop.Stats = stats.New(benchmarkFrames)
+		app.frames = 0
+	}
+}
+
+// method returns the name of the current draw method.
+func method() string {
+	if *naive {
+		return "naive"
+	}
+	return "instanced"
+}
+
+// drawNaive draws every quad with its own draw call. The values of the instance
+// attributes are set as uniforms.
+func (app *example) drawNaive() {
+	for i := 0; i < len(app.data); i += instanceSize {
+		gl.UniformMatrix4fv(app.modelLocation, 1, false, &app.data[i])
+		gl.Uniform4fv(app.colorLocation, 1, &app.data[i+16])
+		gl.Uniform4fv(app.uvRectLocation, 1, &app.data[i+20])
+		app.meshes[1].Draw()
+	}
+}
+
+// newInstanceData returns quads on a lattice with random rotation, scale, tint and
+// atlas tile. The uv rectangle of an instance is its tile.
+func newInstanceData(count int) []float32 {
+	random := rand.New(rand.NewSource(1))
+	side := int(math.Ceil(math.Cbrt(float64(count))))
+	center := float32(side-1) / 2
+	data := make([]float32, 0, count*instanceSize)
+	// the quad is centered before it is scaled and rotated
+	centerQuad := vmath.Translate(vmath.Vec3{-0.25, -0.25, 0})
+	for i := 0; i < count; i++ {
+		position := vmath.Vec3{float32(i%side) - center, float32(i/side%side) - center, float32(i/(side*side)) - center}
+		axis := vmath.Vec3{random.Float32() - 0.5, random.Float32() - 0.5, random.Float32() - 0.5}
+		rotation := vmath.QuatAxisAngle(axis.Normalize(), random.Float32()*2*math.Pi)
+		scale := 1 + random.Float32()*0.6
+		model := vmath.Translate(position).Mul(rotation.Mat4()).Mul(vmath.Scale(vmath.Vec3{scale, scale, 1})).Mul(centerQuad)
+		tile := random.Intn(atlasTiles * atlasTiles)
+		data = append(data, model[:]...)
+		data = append(data, 0.6+random.Float32()*0.4, 0.6+random.Float32()*0.4, 0.6+random.Float32()*0.4, 1)
+		data = append(data, float32(tile%atlasTiles)/atlasTiles, float32(tile/atlasTiles)/atlasTiles, 1.0/atlasTiles, 1.0/atlasTiles)
+	}
+	return data
+}
+
+// newAtlas returns a texture of atlasTiles x atlasTiles tiles. Every tile has its
+// own color and stripes, a white border and a dark square in its first corner, so
+// a wrong uv rectangle shows.
+func newAtlas() *gfx.Texture {
+	const size = atlasTiles * tileSize
+	pixels := make([]uint8, size*size*4)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			tile := y/tileSize*atlasTiles + x/tileSize
+			tx, ty := x%tileSize, y%tileSize
+			color := [4]uint8{uint8(60 + 195*(tile&1)), uint8(60 + 195*(tile>>1&1)), uint8(60 + 195*(tile>>2&1)), 255}
+			// horizontal or diagonal stripes, their width depends on the tile
+			stripe := ty
+			if tile>>3&1 == 1 {
+				stripe = tx + ty
+			}
+			if stripe/(2+tile%atlasTiles*2)%2 == 0 {
+				color[0], color[1], color[2] = color[0]/2, color[1]/2, color[2]/2
+			}
+			if tx < 3 || ty < 3 || tx >= tileSize-3 || ty >= tileSize-3 {
+				color = [4]uint8{255, 255, 255, 255}
+			} else if tx < 16 && ty < 16 {
+				color = [4]uint8{20, 20, 20, 255}
+			}
+			copy(pixels[(y*size+x)*4:], color[:])
+		}
+	}
+	atlas := gfx.NewTexture(size, size, pixels, gl.LINEAR)
+	atlas.GenerateMipmaps()
+	return atlas
+}
+
+func onKey(window *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+	if action == glfw.Press {
+		switch key {
+		case glfw.KeyEscape:
+			window.SetShouldClose(true)
+		case glfw.KeyI:
+			*naive = !*naive
+		case glfw.KeyC:
+			mouse.NextCursorMode(window)
+		}
+	}
+}
+
+func onResize(w *glfw.Window, width, height int) {
+	gl.Viewport(0, 0, int32(width), int32(height))
+	orbit.Resize(width, height)
+}
@@ -0,0 +1,360 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+// +build light
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/vbsw/opengl-go-example/camera"
+	"github.com/vbsw/opengl-go-example/gfx"
+	"github.com/vbsw/opengl-go-example/input"
+	"github.com/vbsw/opengl-go-example/light"
+	"github.com/vbsw/opengl-go-example/loop"
+	"github.com/vbsw/opengl-go-example/mesh"
+	"github.com/vbsw/opengl-go-example/scene"
+	"github.com/vbsw/opengl-go-example/vmath"
+	"github.com/vbsw/shaders"
+	"math"
+	"runtime"
+	"unsafe"
+)
+
+var pointLightCount = flag.Int("lights", 4, "number of point lights")
+var shadowSize = flag.Int("shadowsize", 2048, "size of the shadow maps in texels")
+var cascades = flag.Int("cascades", 3, "number of shadow cascades of the sun (1 to 4)")
+var depthBias = flag.Float64("bias", 0.0005, "constant depth bias of the shadow maps")
+
+var mouse *input.Mouse
+var orbit *camera.Orbit
+
+// toggled with keys 1, 2 and 3
+var showSun, showPoints, showSpot = true, true, true
+
+var shadows *light.Shadows
+
+// shadow map shown on the debug quad: 0 is none, then the cascades and the spot map
+var debugView int
+
+type example struct {
+	window  *glfw.Window
+	program *light.Program
+	// shows a shadow map in the corner
+	debugShader *shaders.Shader
+	debugVAO    uint32
+	debugVBOs   []uint32
+	meshes      []*gfx.Mesh
+	root        *scene.Node
+	shapes      *scene.Node
+	sun         *scene.Node
+	points      *scene.Node
+	spot        *scene.Node
+	drawList    scene.DrawList
+	lights      []light.Light
+	time        float64
+}
+
+func init() {
+	runtime.LockOSThread()
+}
+
+func main() {
+	flag.Parse()
+	err := glfw.Init()
+
+	if err == nil {
+		var window *glfw.Window
+		defer glfw.Terminate()
+		glfw.WindowHint(glfw.DepthBits, 24)
+		glfw.WindowHint(glfw.Samples, 4)
+		window, err = glfw.CreateWindow(800, 500, "OpenGL Example", nil, nil)
+
+		if err == nil {
+			defer window.Destroy()
+			width, height := window.GetSize()
+			orbit = camera.NewOrbit(vmath.Vec3{0, 0.5, 0}, 12, width, height)
+			orbit.Pitch = vmath.Radians(-30)
+			mouse = input.NewMouse()
+			mouse.Register(window)
+			window.SetKeyCallback(onKey)
+			window.SetSizeCallback(onResize)
+			window.MakeContextCurrent()
+			err = gl.Init()
+
+			if err == nil {
+				mainLoop := loop.New(1.0 / 60.0)
+				mainLoop.Clock = loop.ClockFunc(glfw.GetTime)
+				mainLoop.PollEvents = glfw.PollEvents
+				err = mainLoop.Run(window, &example{window: window})
+			}
+		}
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+	}
+}
+
+// Init is called by loop.
+func (app *example) Init() error {
+	var err error
+	// point lights, sun and spot light
+	app.program, err = light.NewShadowedProgram(*pointLightCount + 2)
+
+	if err == nil {
+		shadows, err = light.NewShadows(*shadowSize, *cascades)
+
+		if err == nil {
+			shadows.DepthBias = float32(*depthBias)
+			app.debugShader = shaders.NewTextureShader()
+			app.debugShader.ProgramID, err = gfx.NewProgram(app.debugShader.VertexShaderStr(), app.debugShader.FragmentShaderStr())
+
+			if err == nil {
+				app.newDebugQuad()
+				app.newScene()
+				updateTitle(app.window)
+			} else {
+				shadows.Delete()
+				app.program.Delete()
+			}
+		} else {
+			app.program.Delete()
+		}
+	}
+	return err
+}
+
+// Update is called by loop.
+func (app *example) Update(dt float64) {
+	orbit.HandleMouse(app.window, mouse)
+	app.time += dt
+	app.sun.Visible, app.points.Visible, app.spot.Visible = showSun, showPoints, showSpot
+	app.points.SetRotation(vmath.QuatAxisAngle(vmath.Vec3{0, 1, 0}, float32(app.time*0.4)))
+	app.spot.SetRotation(vmath.QuatAxisAngle(vmath.Vec3{0, 0, 1}, float32(math.Sin(app.time)*0.5)))
+	for _, shape := range app.shapes.Children() {
+		shape.Rotate(vmath.QuatAxisAngle(vmath.Vec3{0, 1, 0}, float32(dt)))
+	}
+	mouse.EndFrame()
+}
+
+// Render is called by loop.
+func (app *example) Render(alpha float64) {
+	app.drawList.Reset()
+	app.drawList.Collect(app.root)
+	app.lights = light.Collect(app.drawList.Lights, app.lights[:0])
+	shadows.Render(app.lights, app.drawList.Items, orbit.Projection(), orbit.View(), orbit.Near, orbit.Far)
+
+	width, height := app.window.GetFramebufferSize()
+	gfx.BindDefault(width, height)
+	gl.ClearColor(0.05, 0.05, 0.08, 1)
+	state := gfx.Opaque3D()
+	state.Apply()
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+	app.program.Use()
+	app.program.SetCamera(orbit.Projection(), orbit.View())
+	app.program.SetLights(vmath.Vec3{0.08, 0.08, 0.1}, app.lights)
+	shadows.SetUniforms(app.program)
+	app.drawList.Draw()
+
+	if debugView > 0 {
+		app.drawDebugQuad(width, height)
+	}
+	gl.BindVertexArray(0)
+}
+
+// Shutdown is called by loop.
+func (app *example) Shutdown() {
+	for _, m := range app.meshes {
+		m.Delete()
+	}
+	gl.DeleteVertexArrays(1, &app.debugVAO)
+	gl.DeleteBuffers(int32(len(app.debugVBOs)), &app.debugVBOs[0])
+	gl.DeleteProgram(app.debugShader.ProgramID)
+	shadows.Delete()
+	app.program.Delete()
+}
+
+// drawDebugQuad shows the depth of the shadow map selected with key V in the lower
+// right corner. Depth comparison is disabled meanwhile.
+func (app *example) drawDebugQuad(width, height int) {
+	texture := shadows.SpotMap()
+	if debugView <= shadows.Cascades() {
+		texture = shadows.CascadeMap(debugView - 1)
+	}
+	size := float32(0.35)
+	model := vmath.Translate(vmath.Vec3{1 - size, -1 + size*float32(width)/float32(height), 0})
+	model = model.Mul(vmath.Scale(vmath.Vec3{size, size * float32(width) / float32(height), 1}))
+	gl.Disable(gl.DEPTH_TEST)
+	shadows.SetCompare(false)
+	gl.UseProgram(app.debugShader.ProgramID)
+	gl.UniformMatrix4fv(app.debugShader.ModelLocation, 1, false, model.Ptr())
+	gl.BindVertexArray(app.debugVAO)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+	gl.DrawElements(gl.TRIANGLES, 6, gl.UNSIGNED_INT, unsafe.Pointer(nil))
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	shadows.SetCompare(true)
+	gl.Enable(gl.DEPTH_TEST)
+}
+
+// newDebugQuad sets up a quad from -1 to 1 with the texture shader.
+func (app *example) newDebugQuad() {
+	shader := app.debugShader
+	shader.PositionLocation = gl.GetAttribLocation(shader.ProgramID, shader.PositionAttribute)
+	shader.CoordsLocation = gl.GetAttribLocation(shader.ProgramID, shader.CoordsAttribute)
+	shader.ModelLocation = gl.GetUniformLocation(shader.ProgramID, shader.ModelUniform)
+	shader.TextureLocation = gl.GetUniformLocation(shader.ProgramID, shader.TextureUniform)
+	// x, y, z, x_tex, y_tex (two triangles)
+	vertices := []float32{
+		1.0, 1.0, 0.0, 1.0, 1.0,
+		1.0, -1.0, 0.0, 1.0, 0.0,
+		-1.0, 1.0, 0.0, 0.0, 1.0,
+		-1.0, -1.0, 0.0, 0.0, 0.0,
+	}
+	indices := []uint32{
+		0, 1, 2,
+		2, 1, 3,
+	}
+	app.debugVBOs = make([]uint32, 2)
+	gl.GenBuffers(2, &app.debugVBOs[0])
+	gl.GenVertexArrays(1, &app.debugVAO)
+	gl.BindVertexArray(app.debugVAO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, app.debugVBOs[0])
+	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices), gl.STATIC_DRAW)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, app.debugVBOs[1])
+	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(indices)*4, gl.Ptr(indices), gl.STATIC_DRAW)
+	gl.VertexAttribPointer(uint32(shader.PositionLocation), 3, gl.FLOAT, false, 5*4, gl.PtrOffset(0))
+	gl.EnableVertexAttribArray(uint32(shader.PositionLocation))
+	gl.VertexAttribPointer(uint32(shader.CoordsLocation), 2, gl.FLOAT, false, 5*4, gl.PtrOffset(3*4))
+	gl.EnableVertexAttribArray(uint32(shader.CoordsLocation))
+	gl.BindVertexArray(0)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+
+	gl.UseProgram(shader.ProgramID)
+	gl.Uniform1i(shader.TextureLocation, 0)
+	gl.UseProgram(0)
+}
+
+// newScene creates a ground plane, shapes in a circle, a sun, point lights orbiting
+// the shapes and a swinging spot light. Point lights are shown as small spheres.
+func (app *example) newScene() {
+	ground := app.newMesh(mesh.Plane(16, 16, 16, 16))
+	sphere := app.newMesh(mesh.UVSphere(1, 24, 12))
+	generated := []*gfx.Mesh{
+		app.newMesh(mesh.Cube(1.4, 1)),
+		sphere,
+		app.newMesh(mesh.Torus(0.7, 0.3, 32, 16)),
+		app.newMesh(mesh.Cylinder(0.6, 1.6, 24, 1, true)),
+		app.newMesh(mesh.Cone(0.8, 1.6, 24, 1, true)),
+		app.newMesh(mesh.Capsule(0.5, 0.8, 24, 6, 1)),
+	}
+	app.root = scene.NewNode("root")
+	floor := scene.NewNode("ground")
+	floor.Mesh = ground
+	floor.Material = app.newMaterial(vmath.Vec3{0.6, 0.6, 0.6}, 8)
+	app.root.Add(floor)
+
+	app.shapes = scene.NewNode("shapes")
+	app.root.Add(app.shapes)
+	for i, m := range generated {
+		node := scene.NewNode(fmt.Sprintf("shape%d", i))
+		node.Mesh = m
+		hue := float64(i) / float64(len(generated))
+		node.Material = app.newMaterial(hueColor(hue), 64)
+		sin, cos := math.Sincos(hue * 2 * math.Pi)
+		node.SetPosition(vmath.Vec3{float32(cos) * 4, 1, float32(sin) * 4})
+		app.shapes.Add(node)
+	}
+
+	app.sun = scene.NewNode("sun")
+	app.sun.Light = light.NewDirectional(vmath.Vec3{-0.3, -1, -0.5}, vmath.Vec3{1, 0.95, 0.8}, 0.4)
+	app.root.Add(app.sun)
+
+	app.points = scene.NewNode("points")
+	app.root.Add(app.points)
+	for i := 0; i < *pointLightCount; i++ {
+		hue := float64(i) / float64(*pointLightCount)
+		color := hueColor(hue)
+		sin, cos := math.Sincos(hue * 2 * math.Pi)
+		node := scene.NewNode(fmt.Sprintf("point%d", i))
+		node.Light = light.NewPoint(vmath.Vec3{}, color, 2, 8)
+		node.Mesh = sphere
+		node.Material = &light.Material{Program: app.program, Emissive: color}
+		node.SetPosition(vmath.Vec3{float32(cos) * 6, 2.5, float32(sin) * 6})
+		node.SetScale(vmath.Vec3{0.1, 0.1, 0.1})
+		app.points.Add(node)
+	}
+
+	app.spot = scene.NewNode("spot")
+	app.spot.SetPosition(vmath.Vec3{0, 7, 0})
+	app.spot.Light = light.NewSpot(vmath.Vec3{}, vmath.Vec3{0, -1, 0}, vmath.Vec3{1, 1, 1}, 3, 15, vmath.Radians(12), vmath.Radians(20))
+	app.root.Add(app.spot)
+}
+
+func (app *example) newMesh(m *mesh.Mesh) *gfx.Mesh {
+	position := gfx.VertexAttribute{Location: app.program.PositionLocation, Size: 3, Offset: mesh.PositionOffset}
+	normal := gfx.VertexAttribute{Location: app.program.NormalLocation, Size: 3, Offset: mesh.NormalOffset}
+	gfxMesh := gfx.NewMesh(gl.TRIANGLES, m.VertexData(), mesh.VertexSize, m.Indices, position, normal)
+	app.meshes = append(app.meshes, gfxMesh)
+	return gfxMesh
+}
+
+func (app *example) newMaterial(diffuse vmath.Vec3, shininess float32) *light.Material {
+	return &light.Material{Program: app.program, Diffuse: diffuse, Specular: vmath.Vec3{0.5, 0.5, 0.5}, Shininess: shininess}
+}
+
+// hueColor returns a saturated color of hue from 0 to 1.
+func hueColor(hue float64) vmath.Vec3 {
+	channel := func(offset float64) float32 {
+		return float32(math.Max(0, math.Min(1, math.Abs(math.Mod(hue*6+offset, 6)-3)-1)))
+	}
+	return vmath.Vec3{channel(0), channel(4), channel(2)}
+}
+
+func onKey(window *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+	if action == glfw.Press {
+		switch key {
+		case glfw.KeyEscape:
+			window.SetShouldClose(true)
+		case glfw.Key1:
+			showSun = !showSun
+		case glfw.Key2:
+			showPoints = !showPoints
+		case glfw.Key3:
+			showSpot = !showSpot
+		case glfw.KeyH:
+			shadows.Enabled = !shadows.Enabled
+		case glfw.KeyV:
+			debugView = (debugView + 1) % (shadows.Cascades() + 2)
+		case glfw.KeyP:
+			shadows.PCFRadius = (shadows.PCFRadius + 1) % 4
+		case glfw.KeyLeftBracket:
+			shadows.DepthBias /= 2
+		case glfw.KeyRightBracket:
+			shadows.DepthBias *= 2
+		case glfw.KeyC:
+			mouse.NextCursorMode(window)
+		}
+		updateTitle(window)
+	}
+}
+
+// updateTitle shows the shadow settings in the window title.
+func updateTitle(window *glfw.Window) {
+	if shadows.Enabled {
+		window.SetTitle(fmt.Sprintf("OpenGL Example - shadows: %d cascades, PCF %dx%d, bias %g", shadows.Cascades(), 2*shadows.PCFRadius+1, 2*shadows.PCFRadius+1, shadows.DepthBias))
+	} else {
+		window.SetTitle("OpenGL Example - shadows off")
+	}
+}
+
+func onResize(w *glfw.Window, width, height int) {
+	gl.Viewport(0, 0, int32(width), int32(height))
+	orbit.Resize(width, height)
+}
@@ -0,0 +1,302 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+// +build material
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/vbsw/opengl-go-example/gfx"
+	"github.com/vbsw/opengl-go-example/loop"
+	"github.com/vbsw/opengl-go-example/material"
+	"github.com/vbsw/opengl-go-example/scene"
+	"github.com/vbsw/opengl-go-example/vmath"
+	"github.com/vbsw/shaders"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+)
+
+var materialsPath = flag.String("materials", "materials.json", "material definitions (JSON)")
+
+// tintedVertexShader and tintedFragmentShader blend two textures and multiply the
+// result with a tint color.
+const tintedVertexShader = `#version 130
+
+in vec3 positionIn;
+in vec2 textureCoordsIn;
+uniform mat4 projection;
+uniform mat4 model;
+out vec2 texCoords;
+
+void main() {
+	gl_Position = projection * model * vec4(positionIn, 1.0);
+	texCoords = textureCoordsIn;
+}
+`
+
+const tintedFragmentShader = `#version 130
+
+in vec2 texCoords;
+uniform sampler2D imageTexture;
+uniform sampler2D detailTexture;
+uniform vec4 tint;
+// amount of detailTexture from 0 to 1
+uniform float detail;
+// repetitions of detailTexture
+uniform float detailScale;
+out vec4 color;
+
+void main() {
+	vec4 image = texture(imageTexture, texCoords);
+	vec4 detailColor = texture(detailTexture, texCoords * detailScale);
+	color = mix(image, image * detailColor, detail) * tint;
+}
+`
+
+// defaultMaterials are used, if the materials file doesn't exist.
+var defaultMaterials = material.File{Materials: []material.Config{
+	{Name: "checker", Program: "texture", Textures: map[string]material.TextureConfig{"imageTexture": {Texture: "checker"}}},
+	{Name: "stripes", Program: "texture", Textures: map[string]material.TextureConfig{"imageTexture": {Texture: "stripes"}}},
+	{Name: "orange dots", Program: "tinted",
+		Textures: map[string]material.TextureConfig{"imageTexture": {Texture: "checker"}, "detailTexture": {Texture: "dots"}},
+		Uniforms: map[string]material.Value{"tint": {1, 0.6, 0.3, 1}, "detail": {0.8}, "detailScale": {2}}},
+	{Name: "blue dots", Program: "tinted",
+		Textures: map[string]material.TextureConfig{"imageTexture": {Texture: "stripes"}, "detailTexture": {Texture: "dots"}},
+		Uniforms: map[string]material.Value{"tint": {0.4, 0.7, 1, 1}, "detail": {1}, "detailScale": {4}}},
+}}
+
+var windowWidth, windowHeight int
+var library *material.Library
+
+// reload is set by key R to recreate the materials
+var reload bool
+
+type example struct {
+	window   *glfw.Window
+	programs []uint32
+	textures []*gfx.Texture
+	quad     *gfx.Mesh
+	root     *scene.Node
+	drawList scene.DrawList
+}
+
+func init() {
+	runtime.LockOSThread()
+}
+
+func main() {
+	flag.Parse()
+	err := glfw.Init()
+
+	if err == nil {
+		var window *glfw.Window
+		defer glfw.Terminate()
+		window, err = glfw.CreateWindow(600, 600, "OpenGL Example - Materials", nil, nil)
+
+		if err == nil {
+			defer window.Destroy()
+			windowWidth, windowHeight = window.GetFramebufferSize()
+			window.SetKeyCallback(onKey)
+			window.SetFramebufferSizeCallback(onResize)
+			window.MakeContextCurrent()
+			err = gl.Init()
+
+			if err == nil {
+				mainLoop := loop.New(1.0 / 60.0)
+				mainLoop.Clock = loop.ClockFunc(glfw.GetTime)
+				mainLoop.PollEvents = glfw.PollEvents
+				err = mainLoop.Run(window, &example{window: window})
+			}
+		}
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+	}
+}
+
+// Init is called by loop.
+func (app *example) Init() error {
+	textureShader := shaders.NewTextureShader()
+	textureProgram, err := gfx.NewProgramWithAttributes(textureShader.VertexShaderStr(), textureShader.FragmentShaderStr(), "positionIn", "textureCoordsIn")
+
+	if err == nil {
+		var tintedProgram uint32
+		tintedProgram, err = gfx.NewProgramWithAttributes(tintedVertexShader, tintedFragmentShader, "positionIn", "textureCoordsIn")
+
+		if err == nil {
+			app.programs = []uint32{textureProgram, tintedProgram}
+			library = material.NewLibrary(filepath.Dir(*materialsPath), "positionIn", "textureCoordsIn")
+			library.AddProgram("texture", textureProgram)
+			library.AddProgram("tinted", tintedProgram)
+			library.AddTexture("checker", app.newTexture(func(x, y int) bool { return (x/8+y/8)%2 == 0 }))
+			library.AddTexture("stripes", app.newTexture(func(x, y int) bool { return (x+y)/6%2 == 0 }))
+			library.AddTexture("dots", app.newTexture(func(x, y int) bool { return (x%16-8)*(x%16-8)+(y%16-8)*(y%16-8) < 20 }))
+			app.newQuad()
+			// invalid materials are reported, but don't stop the example
+			if loadErr := app.loadMaterials(); loadErr != nil {
+				fmt.Println(loadErr.Error())
+			}
+		} else {
+			gl.DeleteProgram(textureProgram)
+		}
+	}
+	return err
+}
+
+// Update is called by loop.
+func (app *example) Update(dt float64) {
+	if reload {
+		library.Delete()
+		if err := app.loadMaterials(); err != nil {
+			fmt.Println(err.Error())
+		}
+		reload = false
+	}
+	for i, node := range app.root.Children() {
+		node.Rotate(vmath.QuatAxisAngle(vmath.Vec3{0, 0, 1}, float32(dt)*0.2*float32(i%2*2-1)))
+	}
+}
+
+// Render is called by loop.
+func (app *example) Render(alpha float64) {
+	gl.ClearColor(0.1, 0.1, 0.1, 1)
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+	app.setProjection()
+	app.drawList.Reset()
+	app.drawList.Collect(app.root)
+	app.drawList.Draw()
+	gl.BindVertexArray(0)
+}
+
+// Shutdown is called by loop.
+func (app *example) Shutdown() {
+	library.Delete()
+	app.quad.Delete()
+	for _, texture := range app.textures {
+		texture.Delete()
+	}
+	for _, program := range app.programs {
+		gl.DeleteProgram(program)
+	}
+}
+
+// loadMaterials reads the materials file (or uses the defaults) and shows every
+// material on a quad. The quads are arranged in a grid in order of the names.
+func (app *example) loadMaterials() error {
+	file, err := material.ReadFile(*materialsPath)
+	if os.IsNotExist(err) {
+		file, err = defaultMaterials, nil
+	}
+	if err == nil {
+		for _, config := range file.Materials {
+			if _, err = library.Add(config); err != nil {
+				break
+			}
+		}
+	}
+	names := make([]string, 0, len(library.Materials))
+	for name := range library.Materials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	columns := 1
+	for columns*columns < len(names) {
+		columns++
+	}
+	size := 2 / float32(columns)
+	app.root = scene.NewNode("root")
+	for i, name := range names {
+		node := scene.NewNode(name)
+		node.Mesh = app.quad
+		node.Material = library.Material(name)
+		node.SetPosition(vmath.Vec3{-1 + size*(float32(i%columns)+0.5), 1 - size*(float32(i/columns)+0.5), 0})
+		node.SetScale(vmath.Vec3{size * 0.4, size * 0.4, 1})
+		app.root.Add(node)
+	}
+	return err
+}
+
+// setProjection sets an orthographic projection of -1 to 1 (in the smaller window
+// dimension) for the programs of all materials.
+func (app *example) setProjection() {
+	aspect := float32(windowWidth) / float32(windowHeight)
+	projection := vmath.Ortho(-aspect, aspect, -1, 1, -1, 1)
+	if aspect < 1 {
+		projection = vmath.Ortho(-1, 1, -1/aspect, 1/aspect, -1, 1)
+	}
+	done := make(map[uint32]bool)
+	for _, mat := range library.Materials {
+		if !done[mat.Program] {
+			gl.UseProgram(mat.Program)
+			gl.UniformMatrix4fv(gfx.UniformLocation(mat.Program, "projection"), 1, false, projection.Ptr())
+			done[mat.Program] = true
+		}
+	}
+}
+
+// newTexture returns the name of a new black and white 64x64 texture. Pixel returns
+// true for white pixels.
+func (app *example) newTexture(pixel func(x, y int) bool) uint32 {
+	pixels := make([]uint8, 64*64*4)
+	for i := 0; i < 64*64; i++ {
+		value := uint8(60)
+		if pixel(i%64, i/64) {
+			value = 255
+		}
+		pixels[i*4], pixels[i*4+1], pixels[i*4+2], pixels[i*4+3] = value, value, value, 255
+	}
+	texture := gfx.NewTexture(64, 64, pixels, gl.LINEAR)
+	texture.SetWrap(gl.REPEAT)
+	texture.GenerateMipmaps()
+	app.textures = append(app.textures, texture)
+	return texture.ID
+}
+
+// newQuad creates a quad from -1 to 1 with attributes at the locations bound by the
+// library.
+func (app *example) newQuad() {
+	// x, y, z, x_tex, y_tex
+	vertices := []float32{
+		1.0, 1.0, 0.0, 1.0, 1.0,
+		1.0, -1.0, 0.0, 1.0, 0.0,
+		-1.0, 1.0, 0.0, 0.0, 1.0,
+		-1.0, -1.0, 0.0, 0.0, 0.0,
+	}
+	indices := []uint32{0, 2, 1, 2, 3, 1}
+	position := gfx.VertexAttribute{Location: 0, Size: 3, Offset: 0}
+	coords := gfx.VertexAttribute{Location: 1, Size: 2, Offset: 3}
+	app.quad = gfx.NewMesh(gl.TRIANGLES, vertices, 5, indices, position, coords)
+}
+
+func onKey(window *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+	if action == glfw.Press {
+		switch key {
+		case glfw.KeyEscape:
+			window.SetShouldClose(true)
+		case glfw.KeyR:
+			reload = true
+		case glfw.KeyS:
+			// write the defaults as starting point, but never overwrite the file
+			if _, err := os.Stat(*materialsPath); os.IsNotExist(err) {
+				if err = defaultMaterials.Save(*materialsPath); err != nil {
+					fmt.Println(err.Error())
+				}
+			}
+		}
+	}
+}
+
+func onResize(w *glfw.Window, width, height int) {
+	if width > 0 && height > 0 {
+		windowWidth, windowHeight = width, height
+		gl.Viewport(0, 0, int32(width), int32(height))
+	}
+}
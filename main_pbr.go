@@ -0,0 +1,401 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+// +build pbr
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/vbsw/opengl-go-example/camera"
+	"github.com/vbsw/opengl-go-example/gfx"
+	"github.com/vbsw/opengl-go-example/input"
+	"github.com/vbsw/opengl-go-example/light"
+	"github.com/vbsw/opengl-go-example/loop"
+	"github.com/vbsw/opengl-go-example/mesh"
+	"github.com/vbsw/opengl-go-example/pbr"
+	"github.com/vbsw/opengl-go-example/post"
+	"github.com/vbsw/opengl-go-example/scene"
+	"github.com/vbsw/opengl-go-example/vmath"
+	"math"
+	"runtime"
+)
+
+var environmentPath = flag.String("env", "", "equirectangular environment map (.hdr, .png or .jpg), procedural sky if empty")
+var environmentSize = flag.Int("envsize", 512, "size of the environment cube map faces")
+
+const title = "OpenGL Example - PBR"
+
+// direction of the sun in the procedural sky and of the directional light
+var sunDirection = vmath.Vec3{-0.4, -0.6, -0.7}
+
+var mouse *input.Mouse
+var orbit *camera.Orbit
+var windowWidth, windowHeight int
+var framebuffer *gfx.Framebuffer
+var chain *post.Chain
+
+// toggled with keys I and L
+var useIBL, useLights = true, true
+
+// skybox shown with key B: environment, irradiance or prefiltered levels
+var skyboxView int
+
+type example struct {
+	window      *glfw.Window
+	program     *pbr.Program
+	environment *pbr.Environment
+	meshes      []*gfx.Mesh
+	textures    []*gfx.Texture
+	root        *scene.Node
+	lights      *scene.Node
+	object      *scene.Node
+	drawList    scene.DrawList
+	lightList   []light.Light
+}
+
+func init() {
+	runtime.LockOSThread()
+}
+
+func main() {
+	flag.Parse()
+	err := glfw.Init()
+
+	if err == nil {
+		var window *glfw.Window
+		defer glfw.Terminate()
+		glfw.WindowHint(glfw.DepthBits, 24)
+		window, err = glfw.CreateWindow(800, 500, title, nil, nil)
+
+		if err == nil {
+			defer window.Destroy()
+			windowWidth, windowHeight = window.GetFramebufferSize()
+			orbit = camera.NewOrbit(vmath.Vec3{0, 0, 0}, 14, windowWidth, windowHeight)
+			mouse = input.NewMouse()
+			mouse.Register(window)
+			window.SetKeyCallback(onKey)
+			window.SetFramebufferSizeCallback(onResize)
+			window.MakeContextCurrent()
+			err = gl.Init()
+
+			if err == nil {
+				mainLoop := loop.New(1.0 / 60.0)
+				mainLoop.Clock = loop.ClockFunc(glfw.GetTime)
+				mainLoop.PollEvents = glfw.PollEvents
+				err = mainLoop.Run(window, &example{window: window})
+			}
+		}
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+	}
+}
+
+// Init is called by loop.
+func (app *example) Init() error {
+	var hdr *pbr.HDR
+	var err error
+	if len(*environmentPath) > 0 {
+		hdr, err = pbr.LoadHDR(*environmentPath)
+	}
+	if err == nil {
+		app.program, err = pbr.NewProgram(5)
+
+		if err == nil {
+			app.environment, err = pbr.NewEnvironment(hdr, *environmentSize, sunDirection)
+
+			if err == nil {
+				config := gfx.FramebufferConfig{Width: windowWidth, Height: windowHeight, Colors: []int32{gl.RGBA16F}, Depth: gfx.DepthRenderbuffer}
+				framebuffer, err = gfx.NewFramebuffer(config)
+
+				if err == nil {
+					passes := []post.PassConfig{{Effect: post.ToneMap}, {Effect: post.Gamma}, {Effect: post.FXAA}}
+					chain, err = post.NewChain(windowWidth, windowHeight, post.Config{Passes: passes})
+
+					if err == nil {
+						app.newScene()
+						updateTitle(app.window)
+					} else {
+						framebuffer.Delete()
+						app.environment.Delete()
+						app.program.Delete()
+					}
+				} else {
+					app.environment.Delete()
+					app.program.Delete()
+				}
+			} else {
+				app.program.Delete()
+			}
+		}
+	}
+	return err
+}
+
+// Update is called by loop.
+func (app *example) Update(dt float64) {
+	orbit.HandleMouse(app.window, mouse)
+	app.lights.Visible = useLights
+	app.lights.Rotate(vmath.QuatAxisAngle(vmath.Vec3{0, 1, 0}, float32(dt)*0.3))
+	app.object.Rotate(vmath.QuatAxisAngle(vmath.Vec3{0, 1, 0}, float32(dt)*0.5))
+	mouse.EndFrame()
+}
+
+// Render is called by loop.
+func (app *example) Render(alpha float64) {
+	framebuffer.Bind()
+	gl.Disable(gl.DEPTH_TEST)
+	gl.DepthMask(true)
+	gl.Clear(gl.DEPTH_BUFFER_BIT)
+	app.drawSkybox()
+
+	state := gfx.Opaque3D()
+	state.Apply()
+	app.drawList.Reset()
+	app.drawList.Collect(app.root)
+	app.lightList = light.Collect(app.drawList.Lights, app.lightList[:0])
+	app.program.Use()
+	app.program.SetCamera(orbit.Projection(), orbit.View())
+	app.program.SetLights(vmath.Vec3{0.03, 0.03, 0.03}, app.lightList)
+	if useIBL {
+		app.program.SetEnvironment(app.environment, 1)
+	} else {
+		app.program.SetEnvironment(nil, 0)
+	}
+	app.drawList.Draw()
+	gl.BindVertexArray(0)
+
+	chain.Render(framebuffer.Texture(0), nil, windowWidth, windowHeight)
+}
+
+// Shutdown is called by loop.
+func (app *example) Shutdown() {
+	chain.Delete()
+	framebuffer.Delete()
+	for _, m := range app.meshes {
+		m.Delete()
+	}
+	for _, texture := range app.textures {
+		texture.Delete()
+	}
+	app.environment.Delete()
+	app.program.Delete()
+}
+
+// drawSkybox draws the environment, the irradiance or a level of the prefiltered map.
+func (app *example) drawSkybox() {
+	switch {
+	case skyboxView == 0:
+		app.environment.DrawSkybox(app.environment.Cubemap, orbit.Projection(), orbit.View(), 0)
+	case skyboxView == 1:
+		app.environment.DrawSkybox(app.environment.Irradiance, orbit.Projection(), orbit.View(), 0)
+	default:
+		level := float32(skyboxView - 2)
+		app.environment.DrawSkybox(app.environment.Prefiltered, orbit.Projection(), orbit.View(), level)
+	}
+}
+
+// newScene creates a grid of spheres with metallic increasing from left to right
+// and roughness from bottom to top, a textured object in front of the grid and
+// point lights circling the grid. The direct lights are shown as small spheres.
+func (app *example) newScene() {
+	sphere := app.newMesh(mesh.UVSphere(0.5, 48, 24))
+	app.root = scene.NewNode("root")
+	grid := scene.NewNode("grid")
+	app.root.Add(grid)
+	for row := 0; row < 7; row++ {
+		for col := 0; col < 7; col++ {
+			material := pbr.NewMaterial(app.program)
+			material.BaseColorFactor = vmath.Vec4{0.9, 0.2, 0.1, 1}
+			material.MetallicFactor = float32(col) / 6
+			material.RoughnessFactor = float32(row) / 6
+			node := scene.NewNode(fmt.Sprintf("sphere%d.%d", row, col))
+			node.Mesh = sphere
+			node.Material = material
+			node.SetPosition(vmath.Vec3{float32(col-3) * 1.25, float32(row-3) * 1.25, 0})
+			grid.Add(node)
+		}
+	}
+
+	app.object = scene.NewNode("object")
+	app.object.Mesh = app.newMesh(mesh.Torus(1.2, 0.5, 64, 32))
+	app.object.Material = app.newTexturedMaterial()
+	app.object.SetPosition(vmath.Vec3{0, 0, 3})
+	app.root.Add(app.object)
+
+	app.lights = scene.NewNode("lights")
+	app.root.Add(app.lights)
+	sun := scene.NewNode("sun")
+	sun.Light = light.NewDirectional(sunDirection, vmath.Vec3{1, 0.95, 0.85}, 2)
+	app.lights.Add(sun)
+	for i := 0; i < 4; i++ {
+		hue := float64(i) / 4
+		color := hueColor(hue)
+		sin, cos := math.Sincos(hue * 2 * math.Pi)
+		node := scene.NewNode(fmt.Sprintf("point%d", i))
+		node.Light = light.NewPoint(vmath.Vec3{}, color, 40, 15)
+		node.Mesh = sphere
+		emissive := pbr.NewMaterial(app.program)
+		emissive.BaseColorFactor = vmath.Vec4{0, 0, 0, 1}
+		emissive.EmissiveFactor = color.Scale(10)
+		node.Material = emissive
+		node.SetPosition(vmath.Vec3{float32(cos) * 6, float32(sin) * 4, 4})
+		node.SetScale(vmath.Vec3{0.2, 0.2, 0.2})
+		app.lights.Add(node)
+	}
+}
+
+// newTexturedMaterial generates the textures of a material: bright and dark tiles
+// with grooves (normal and occlusion map), polished metal on the bright tiles and
+// glowing lines in every fourth horizontal groove.
+func (app *example) newTexturedMaterial() *pbr.Material {
+	const tiles = 8
+	// distance to the nearest groove in tiles, 0 to 0.5
+	groove := func(u, v float64) float64 {
+		du, dv := u*tiles-math.Floor(u*tiles), v*tiles-math.Floor(v*tiles)
+		return math.Min(math.Min(du, 1-du), math.Min(dv, 1-dv))
+	}
+	height := func(u, v float64) float64 {
+		return math.Min(groove(u, v)/0.08, 1)
+	}
+	bright := func(u, v float64) bool {
+		return (int(u*tiles)+int(v*tiles))%2 == 0
+	}
+	material := pbr.NewMaterial(app.program)
+	material.BaseColorTexture = app.newTexture(true, func(u, v float64) [4]uint8 {
+		if bright(u, v) {
+			return [4]uint8{230, 190, 120, 255}
+		}
+		return [4]uint8{60, 70, 90, 255}
+	})
+	material.MetallicRoughnessTexture = app.newTexture(false, func(u, v float64) [4]uint8 {
+		if bright(u, v) {
+			return [4]uint8{0, 50, 255, 255}
+		}
+		return [4]uint8{0, 200, 0, 255}
+	})
+	material.NormalTexture = app.newTexture(false, func(u, v float64) [4]uint8 {
+		const step = 1.0 / 512
+		dx := (height(u+step, v) - height(u-step, v)) * 0.5
+		dy := (height(u, v+step) - height(u, v-step)) * 0.5
+		normal := vmath.Vec3{float32(-dx), float32(-dy), 0.2}.Normalize()
+		return [4]uint8{uint8(normal[0]*127.5 + 127.5), uint8(normal[1]*127.5 + 127.5), uint8(normal[2]*127.5 + 127.5), 255}
+	})
+	material.OcclusionTexture = app.newTexture(false, func(u, v float64) [4]uint8 {
+		occlusion := uint8(100 + 155*height(u, v))
+		return [4]uint8{occlusion, occlusion, occlusion, 255}
+	})
+	material.EmissiveTexture = app.newTexture(true, func(u, v float64) [4]uint8 {
+		row := math.Round(v * tiles)
+		if math.Abs(v*tiles-row) < 0.02 && int(row)%4 == 0 {
+			return [4]uint8{80, 255, 200, 255}
+		}
+		return [4]uint8{0, 0, 0, 255}
+	})
+	material.EmissiveFactor = vmath.Vec3{4, 4, 4}
+	return material
+}
+
+// newTexture returns a repeating texture with mipmaps of 256x256 texels. Texel
+// returns the color at texture coordinates u and v (0 to 1). Colors are sRGB, if
+// srgb is true.
+func (app *example) newTexture(srgb bool, texel func(u, v float64) [4]uint8) *gfx.Texture {
+	const size = 256
+	pixels := make([]uint8, 0, size*size*4)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			color := texel((float64(x)+0.5)/size, 1-(float64(y)+0.5)/size)
+			pixels = append(pixels, color[:]...)
+		}
+	}
+	var texture *gfx.Texture
+	if srgb {
+		texture = gfx.NewSRGBTexture(size, size, pixels, gl.LINEAR)
+	} else {
+		texture = gfx.NewTexture(size, size, pixels, gl.LINEAR)
+	}
+	texture.SetWrap(gl.REPEAT)
+	texture.GenerateMipmaps()
+	app.textures = append(app.textures, texture)
+	return texture
+}
+
+// newMesh uploads position, normal, texture coordinates and tangents of m.
+func (app *example) newMesh(m *mesh.Mesh) *gfx.Mesh {
+	m.GenerateTangents()
+	attributes := []gfx.VertexAttribute{
+		{Location: app.program.PositionLocation, Size: 3, Offset: mesh.PositionOffset},
+		{Location: app.program.NormalLocation, Size: 3, Offset: mesh.NormalOffset},
+		{Location: app.program.UVLocation, Size: 2, Offset: mesh.UVOffset},
+		{Location: app.program.TangentLocation, Size: 4, Offset: mesh.TangentOffset},
+	}
+	gfxMesh := gfx.NewMesh(gl.TRIANGLES, m.VertexData(), mesh.VertexSize, m.Indices, attributes...)
+	app.meshes = append(app.meshes, gfxMesh)
+	return gfxMesh
+}
+
+// hueColor returns a saturated color of hue from 0 to 1.
+func hueColor(hue float64) vmath.Vec3 {
+	channel := func(offset float64) float32 {
+		return float32(math.Max(0, math.Min(1, math.Abs(math.Mod(hue*6+offset, 6)-3)-1)))
+	}
+	return vmath.Vec3{channel(0), channel(4), channel(2)}
+}
+
+func onKey(window *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+	if action == glfw.Press {
+		switch key {
+		case glfw.KeyEscape:
+			window.SetShouldClose(true)
+		case glfw.KeyI:
+			useIBL = !useIBL
+		case glfw.KeyL:
+			useLights = !useLights
+		case glfw.KeyB:
+			skyboxView = (skyboxView + 1) % (pbr.PrefilteredLevels + 2)
+		case glfw.KeyC:
+			mouse.NextCursorMode(window)
+		}
+		updateTitle(window)
+	}
+}
+
+// updateTitle shows the lighting and the skybox in the window title.
+func updateTitle(window *glfw.Window) {
+	skybox := "environment"
+	if skyboxView == 1 {
+		skybox = "irradiance"
+	} else if skyboxView > 1 {
+		skybox = fmt.Sprintf("prefiltered, roughness %.2f", float32(skyboxView-2)/(pbr.PrefilteredLevels-1))
+	}
+	window.SetTitle(fmt.Sprintf("%s - IBL %s, lights %s, skybox: %s", title, onOff(useIBL), onOff(useLights), skybox))
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+// onResize resizes the scene framebuffer and the buffers of the chain (size in pixels).
+func onResize(w *glfw.Window, width, height int) {
+	if width > 0 && height > 0 {
+		windowWidth, windowHeight = width, height
+		orbit.Resize(width, height)
+		err := framebuffer.Resize(width, height)
+		if err == nil {
+			err = chain.Resize(width, height)
+		}
+		if err != nil {
+			fmt.Println(err.Error())
+			w.SetShouldClose(true)
+		}
+	}
+}
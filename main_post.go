@@ -0,0 +1,322 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+// +build post
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/vbsw/opengl-go-example/camera"
+	"github.com/vbsw/opengl-go-example/gfx"
+	"github.com/vbsw/opengl-go-example/input"
+	"github.com/vbsw/opengl-go-example/loop"
+	"github.com/vbsw/opengl-go-example/mesh"
+	"github.com/vbsw/opengl-go-example/post"
+	"github.com/vbsw/opengl-go-example/scene"
+	"github.com/vbsw/opengl-go-example/stats"
+	"github.com/vbsw/opengl-go-example/vmath"
+	"math"
+	"os"
+	"runtime"
+	"strings"
+)
+
+const vertexShader = `#version 130
+
+in vec3 positionIn;
+in vec3 normalIn;
+uniform mat4 projection;
+uniform mat4 model;
+out vec3 fragmentNormal;
+
+void main() {
+	gl_Position = projection * model * vec4(positionIn, 1.0);
+	fragmentNormal = mat3(model) * normalIn;
+}
+`
+
+// fragmentShader colors by normal. Brightness above 1 is high dynamic range and
+// shows bloom.
+const fragmentShader = `#version 130
+
+in vec3 fragmentNormal;
+uniform float brightness;
+out vec4 color;
+
+void main() {
+	vec3 normal = normalize(fragmentNormal);
+	float light = 0.3 + 0.7 * max(dot(normal, normalize(vec3(0.5, 1.0, 0.3))), 0.0);
+	color = vec4((normal * 0.5 + 0.5) * light * brightness, 1.0);
+}
+`
+
+// title is updated with frame statistics in this interval (seconds)
+const titleInterval = 0.5
+
+const title = "OpenGL Example"
+
+var configPath = flag.String("config", "post.json", "post-processing chain (JSON)")
+
+var mainLoop *loop.Loop
+var mouse *input.Mouse
+var orbit *camera.Orbit
+var framebuffer *gfx.Framebuffer
+var chain *post.Chain
+var windowWidth, windowHeight int
+
+// index of the pass moved with shift + up/down
+var selected int
+
+type example struct {
+	window     *glfw.Window
+	program    uint32
+	projection int32
+	shapes     []*gfx.Mesh
+	root       *scene.Node
+	drawList   scene.DrawList
+	titleTime  float64
+}
+
+// postMaterial sets model matrix and brightness.
+type postMaterial struct {
+	model              int32
+	brightnessLocation int32
+	brightness         float32
+}
+
+func init() {
+	runtime.LockOSThread()
+}
+
+func main() {
+	flag.Parse()
+	err := glfw.Init()
+
+	if err == nil {
+		var window *glfw.Window
+		defer glfw.Terminate()
+		window, err = glfw.CreateWindow(800, 500, title, nil, nil)
+
+		if err == nil {
+			defer window.Destroy()
+			windowWidth, windowHeight = window.GetFramebufferSize()
+			orbit = camera.NewOrbit(vmath.Vec3{0, 0, 0}, 9, windowWidth, windowHeight)
+			orbit.Pitch = vmath.Radians(-20)
+			mouse = input.NewMouse()
+			mouse.Register(window)
+			window.SetKeyCallback(onKey)
+			window.SetFramebufferSizeCallback(onResize)
+			window.MakeContextCurrent()
+			err = gl.Init()
+
+			if err == nil {
+				mainLoop = loop.New(1.0 / 60.0)
+				mainLoop.Clock = loop.ClockFunc(glfw.GetTime)
+				mainLoop.PollEvents = glfw.PollEvents
+				mainLoop.Stats = stats.New(stats.DefaultCapacity)
+				err = mainLoop.Run(window, &example{window: window})
+			}
+		}
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+	}
+}
+
+// Init is called by loop.
+func (app *example) Init() error {
+	var err error
+	app.program, err = gfx.NewProgram(vertexShader, fragmentShader)
+
+	if err == nil {
+		config := gfx.FramebufferConfig{Width: windowWidth, Height: windowHeight, Colors: []int32{gl.RGBA16F}, Depth: gfx.DepthRenderbuffer}
+		framebuffer, err = gfx.NewFramebuffer(config)
+
+		if err == nil {
+			chain, err = post.NewChain(windowWidth, windowHeight, loadConfig())
+
+			if err == nil {
+				app.projection = gfx.UniformLocation(app.program, "projection")
+				app.newScene()
+			} else {
+				framebuffer.Delete()
+				gl.DeleteProgram(app.program)
+			}
+		} else {
+			gl.DeleteProgram(app.program)
+		}
+	}
+	return err
+}
+
+// Update is called by loop.
+func (app *example) Update(dt float64) {
+	orbit.HandleMouse(app.window, mouse)
+	for i, node := range app.root.Children() {
+		axis := vmath.Vec3{float32(i%3 + 1), 1, float32(i % 2)}.Normalize()
+		node.Rotate(vmath.QuatAxisAngle(axis, float32(dt)*0.5))
+	}
+	mouse.EndFrame()
+}
+
+// Render is called by loop.
+func (app *example) Render(alpha float64) {
+	framebuffer.Bind()
+	gl.ClearColor(0.02, 0.02, 0.05, 1)
+	gl.Enable(gl.DEPTH_TEST)
+	gl.DepthMask(true)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+	gl.UseProgram(app.program)
+	camera.SetUniforms(orbit, app.projection, -1)
+	app.drawList.Reset()
+	app.drawList.Collect(app.root)
+	app.drawList.Draw()
+	gl.BindVertexArray(0)
+
+	chain.Render(framebuffer.Texture(0), nil, windowWidth, windowHeight)
+
+	if now := glfw.GetTime(); now-app.titleTime >= titleInterval {
+		app.window.SetTitle(fmt.Sprintf("%s - %s - %s", title, passesString(), mainLoop.Stats.Summary().String()))
+		app.titleTime = now
+	}
+}
+
+// Shutdown is called by loop.
+func (app *example) Shutdown() {
+	chain.Delete()
+	framebuffer.Delete()
+	for _, shape := range app.shapes {
+		shape.Delete()
+	}
+	gl.DeleteProgram(app.program)
+}
+
+// newScene places procedural shapes in a circle. Every third shape is bright enough
+// to bloom.
+func (app *example) newScene() {
+	position := gfx.VertexAttribute{Location: gfx.AttribLocation(app.program, "positionIn"), Size: 3, Offset: mesh.PositionOffset}
+	normal := gfx.VertexAttribute{Location: gfx.AttribLocation(app.program, "normalIn"), Size: 3, Offset: mesh.NormalOffset}
+	generated := []*mesh.Mesh{
+		mesh.Cube(1.4, 1),
+		mesh.Icosphere(1, 2),
+		mesh.Torus(0.8, 0.3, 32, 16),
+		mesh.Cylinder(0.7, 1.6, 24, 1, true),
+		mesh.Cone(0.8, 1.6, 24, 1, true),
+		mesh.Capsule(0.5, 0.8, 24, 6, 1),
+	}
+	for _, m := range generated {
+		app.shapes = append(app.shapes, gfx.NewMesh(gl.TRIANGLES, m.VertexData(), mesh.VertexSize, m.Indices, position, normal))
+	}
+	modelLocation := gfx.UniformLocation(app.program, "model")
+	brightnessLocation := gfx.UniformLocation(app.program, "brightness")
+	app.root = scene.NewNode("root")
+	for i := 0; i < 12; i++ {
+		node := scene.NewNode(fmt.Sprintf("shape%d", i))
+		node.Mesh = app.shapes[i%len(app.shapes)]
+		material := &postMaterial{model: modelLocation, brightnessLocation: brightnessLocation, brightness: 1}
+		if i%3 == 0 {
+			material.brightness = 4
+		}
+		node.Material = material
+		sin, cos := math.Sincos(float64(i) / 12 * 2 * math.Pi)
+		node.SetPosition(vmath.Vec3{float32(cos) * 4, 0, float32(sin) * 4})
+		app.root.Add(node)
+	}
+}
+
+// Apply sets model matrix and brightness.
+func (material *postMaterial) Apply(model vmath.Mat4) {
+	gl.UniformMatrix4fv(material.model, 1, false, model.Ptr())
+	gl.Uniform1f(material.brightnessLocation, material.brightness)
+}
+
+// loadConfig returns the chain from -config or the default chain, if the file
+// doesn't exist.
+func loadConfig() post.Config {
+	config, err := post.LoadConfig(*configPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Println(err.Error())
+		}
+		config = post.DefaultConfig()
+	}
+	return config
+}
+
+// passesString returns the enabled passes in order. The selected pass is in brackets.
+func passesString() string {
+	var names []string
+	for i, pass := range chain.Passes {
+		name := pass.Effect
+		if !pass.Enabled {
+			name = "-" + name
+		}
+		if i == selected {
+			name = "[" + name + "]"
+		}
+		names = append(names, name)
+	}
+	return strings.Join(names, " ")
+}
+
+func onKey(window *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+	if action == glfw.Press || action == glfw.Repeat {
+		switch {
+		case key == glfw.KeyEscape:
+			window.SetShouldClose(true)
+		case key >= glfw.Key1 && key <= glfw.Key9:
+			if index := int(key - glfw.Key1); index < len(chain.Passes) {
+				chain.Passes[index].Enabled = !chain.Passes[index].Enabled
+			}
+		case key == glfw.KeyUp || key == glfw.KeyDown:
+			next := selected + 1
+			if key == glfw.KeyUp {
+				next = selected - 1
+			}
+			if next >= 0 && next < len(chain.Passes) {
+				if mods&glfw.ModShift != 0 {
+					chain.Move(selected, next)
+				}
+				selected = next
+			}
+		case key == glfw.KeyR:
+			config, err := post.LoadConfig(*configPath)
+			if err == nil {
+				err = chain.SetConfig(config)
+				selected = 0
+			}
+			if err != nil {
+				fmt.Println(err.Error())
+			}
+		case key == glfw.KeyS:
+			if err := chain.Config().Save(*configPath); err != nil {
+				fmt.Println(err.Error())
+			}
+		case key == glfw.KeyC:
+			mouse.NextCursorMode(window)
+		}
+	}
+}
+
+// onResize resizes the scene framebuffer and the buffers of the chain (size in pixels).
+func onResize(w *glfw.Window, width, height int) {
+	if width > 0 && height > 0 {
+		windowWidth, windowHeight = width, height
+		orbit.Resize(width, height)
+		err := framebuffer.Resize(width, height)
+		if err == nil {
+			err = chain.Resize(width, height)
+		}
+		if err != nil {
+			fmt.Println(err.Error())
+			w.SetShouldClose(true)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+// +build sprite
+
+package main
+
+import (
+	"fmt"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/vbsw/opengl-go-example/atlas"
+	"github.com/vbsw/opengl-go-example/gfx"
+	"github.com/vbsw/opengl-go-example/loop"
+	"github.com/vbsw/opengl-go-example/stats"
+	"github.com/vbsw/opengl-go-example/vmath"
+	"image"
+	"math"
+	"math/rand"
+	"runtime"
+)
+
+// sprites with layer 2 are drawn with this program, it has the same inputs as the
+// default program of the sprite batch
+const grayFragmentShader = `#version 130
+
+in vec2 fragmentTextureCoords;
+in vec4 fragmentColor;
+uniform sampler2D image;
+out vec4 color;
+
+void main() {
+	vec4 texel = texture(image, fragmentTextureCoords) * fragmentColor;
+	float gray = dot(texel.rgb, vec3(0.299, 0.587, 0.114));
+	color = vec4(gray, gray, gray, texel.a);
+}
+`
+
+const spriteCount = 3000
+
+// names of the images in the atlas
+var shapeNames = []string{"circle", "ring", "diamond", "cross"}
+
+// title is updated with frame statistics in this interval (seconds)
+const titleInterval = 0.5
+
+const title = "OpenGL Example"
+
+var windowWidth, windowHeight int
+var mainLoop *loop.Loop
+
+type example struct {
+	window      *glfw.Window
+	batch       *gfx.SpriteBatch
+	grayProgram uint32
+	checker     *gfx.Texture
+	atlas       *gfx.Texture
+	sprites     []gfx.Sprite
+	velocities  []vmath.Vec3
+	titleTime   float64
+}
+
+func init() {
+	runtime.LockOSThread()
+}
+
+func main() {
+	err := glfw.Init()
+
+	if err == nil {
+		var window *glfw.Window
+		defer glfw.Terminate()
+		window, err = glfw.CreateWindow(800, 600, title, nil, nil)
+
+		if err == nil {
+			defer window.Destroy()
+			windowWidth, windowHeight = window.GetSize()
+			window.SetKeyCallback(onKey)
+			window.SetSizeCallback(onResize)
+			window.MakeContextCurrent()
+			err = gl.Init()
+
+			if err == nil {
+				mainLoop = loop.New(1.0 / 60.0)
+				mainLoop.Clock = loop.ClockFunc(glfw.GetTime)
+				mainLoop.PollEvents = glfw.PollEvents
+				mainLoop.Stats = stats.New(stats.DefaultCapacity)
+				err = mainLoop.Run(window, &example{window: window})
+			}
+		}
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+	}
+}
+
+// Init is called by loop.
+func (app *example) Init() error {
+	var err error
+	app.batch, err = gfx.NewSpriteBatch(1000)
+
+	if err == nil {
+		app.grayProgram, err = gfx.NewProgram(gfx.SpriteVertexShader, grayFragmentShader)
+
+		if err == nil {
+			app.checker = gfx.NewTexture(64, 64, newCheckerData(), gl.NEAREST)
+			var shapes *atlas.Atlas
+			shapes, err = atlas.Build(newShapeImages(), atlas.Options{Width: 256, Height: 256, Padding: 2, Extrude: 1})
+
+			if err == nil {
+				app.atlas = gfx.NewTextureFromImage(shapes.Pages[0], gl.LINEAR)
+				app.newSprites(shapes)
+			} else {
+				app.checker.Delete()
+				gl.DeleteProgram(app.grayProgram)
+				app.batch.Delete()
+			}
+		} else {
+			app.batch.Delete()
+		}
+	}
+	return err
+}
+
+// Update is called by loop.
+func (app *example) Update(dt float64) {
+	for i := range app.sprites {
+		sprite := &app.sprites[i]
+		velocity := &app.velocities[i]
+		sprite.X += velocity[0] * float32(dt)
+		sprite.Y += velocity[1] * float32(dt)
+		sprite.Rotation += velocity[2] * float32(dt)
+		if sprite.X < 0 && velocity[0] < 0 || sprite.X > float32(windowWidth) && velocity[0] > 0 {
+			velocity[0] = -velocity[0]
+		}
+		if sprite.Y < 0 && velocity[1] < 0 || sprite.Y > float32(windowHeight) && velocity[1] > 0 {
+			velocity[1] = -velocity[1]
+		}
+	}
+}
+
+// Render is called by loop.
+func (app *example) Render(alpha float64) {
+	gl.ClearColor(0.2, 0.2, 0.25, 1)
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+
+	app.batch.Begin(vmath.Ortho2D(0, float32(windowWidth), 0, float32(windowHeight)))
+	for i := range app.sprites {
+		app.batch.Draw(&app.sprites[i])
+	}
+	app.batch.End()
+
+	if now := glfw.GetTime(); now-app.titleTime >= titleInterval {
+		app.window.SetTitle(fmt.Sprintf("%s - %d sprites, %d draw calls - %s", title, len(app.sprites), app.batch.DrawCalls, mainLoop.Stats.Summary().String()))
+		app.titleTime = now
+	}
+}
+
+// Shutdown is called by loop.
+func (app *example) Shutdown() {
+	app.checker.Delete()
+	app.atlas.Delete()
+	gl.DeleteProgram(app.grayProgram)
+	app.batch.Delete()
+}
+
+// newSprites creates checkerboards (layer 0), colored shapes from the atlas
+// (layer 1) and gray shapes (layer 2) in random order.
+func (app *example) newSprites(shapes *atlas.Atlas) {
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < spriteCount; i++ {
+		var sprite gfx.Sprite
+		layer := random.Intn(3)
+		if layer == 0 {
+			sprite = gfx.NewSprite(app.checker)
+		} else {
+			region, _ := shapes.Region(shapeNames[random.Intn(len(shapeNames))])
+			sprite = gfx.NewSprite(app.atlas)
+			sprite.Region = gfx.Rect(region.UV)
+			sprite.Width, sprite.Height = float32(region.Width), float32(region.Height)
+			sprite.Color = vmath.Vec4{0.4 + random.Float32()*0.6, 0.4 + random.Float32()*0.6, 0.4 + random.Float32()*0.6, 1}
+		}
+		if layer == 2 {
+			sprite.Program = app.grayProgram
+		}
+		sprite.Layer = layer
+		sprite.X = random.Float32() * float32(windowWidth)
+		sprite.Y = random.Float32() * float32(windowHeight)
+		sprite.ScaleX = 0.2 + random.Float32()*0.4
+		sprite.ScaleY = sprite.ScaleX
+		sprite.Rotation = random.Float32() * 2 * math.Pi
+		velocity := vmath.Vec3{(random.Float32() - 0.5) * 200, (random.Float32() - 0.5) * 200, (random.Float32() - 0.5) * 4}
+		app.sprites = append(app.sprites, sprite)
+		app.velocities = append(app.velocities, velocity)
+	}
+}
+
+func onKey(window *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+	if key == glfw.KeyEscape && action == glfw.Press {
+		window.SetShouldClose(true)
+	}
+}
+
+func onResize(w *glfw.Window, width, height int) {
+	gl.Viewport(0, 0, int32(width), int32(height))
+	windowWidth, windowHeight = width, height
+}
+
+// newCheckerData returns a 64 x 64 checkerboard with transparent fields.
+func newCheckerData() []uint8 {
+	data := make([]uint8, 64*64*4)
+	for i := 0; i < 64*64; i++ {
+		offset := i * 4
+		if (i/16+i/(16*64))%2 == 0 {
+			data[offset] = 255
+			data[offset+1] = 255
+			data[offset+2] = 255
+			data[offset+3] = 255
+		} else {
+			data[offset+3] = 80
+		}
+	}
+	return data
+}
+
+// newShapeImages returns white shapes of 64 x 64 pixels named by shapeNames.
+func newShapeImages() []atlas.Image {
+	var images []atlas.Image
+	for i, name := range shapeNames {
+		img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+		for y := 0; y < 64; y++ {
+			for x := 0; x < 64; x++ {
+				// coordinates relative to the center (-1 to 1)
+				u := (float64(x)+0.5)/32 - 1
+				v := (float64(y)+0.5)/32 - 1
+				radius := math.Sqrt(u*u + v*v)
+				var inside bool
+				switch i {
+				case 0:
+					inside = radius < 0.9
+				case 1:
+					inside = radius < 0.9 && radius > 0.6
+				case 2:
+					inside = math.Abs(u)+math.Abs(v) < 0.9
+				case 3:
+					inside = (math.Abs(u) < 0.25 || math.Abs(v) < 0.25) && math.Abs(u) < 0.9 && math.Abs(v) < 0.9
+				}
+				if inside {
+					offset := y*img.Stride + x*4
+					img.Pix[offset] = 255
+					img.Pix[offset+1] = 255
+					img.Pix[offset+2] = 255
+					img.Pix[offset+3] = 255
+				}
+			}
+		}
+		images = append(images, atlas.Image{Name: name, Image: img})
+	}
+	return images
+}
@@ -0,0 +1,231 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+// +build stream
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/vbsw/opengl-go-example/gfx"
+	"github.com/vbsw/opengl-go-example/loop"
+	"github.com/vbsw/opengl-go-example/stats"
+	"math"
+	"math/rand"
+	"runtime"
+)
+
+const vertexShader = `#version 130
+
+in vec2 positionIn;
+in vec4 colorIn;
+out vec4 fragmentColor;
+
+void main() {
+	gl_Position = vec4(positionIn, 0.0, 1.0);
+	fragmentColor = colorIn;
+}
+`
+
+const fragmentShader = `#version 130
+
+in vec4 fragmentColor;
+out vec4 color;
+
+void main() {
+	color = fragmentColor;
+}
+`
+
+// x, y, r, g, b, a
+const vertexSize = 6
+
+// number of frames measured per stream mode with -benchmark
+const benchmarkFrames = 300
+
+// title is updated with frame statistics in this interval (seconds)
+const titleInterval = 0.5
+
+const title = "OpenGL Example"
+
+var particleCount = flag.Int("particles", 200000, "number of particles")
+var benchmark = flag.Bool("benchmark", false, "measure all stream modes and print the results")
+
+var mainLoop *loop.Loop
+var nextMode bool
+
+type example struct {
+	window    *glfw.Window
+	program   uint32
+	vao       uint32
+	buffer    *gfx.StreamBuffer
+	particles []particle
+	vertices  []float32
+	titleTime float64
+	// frames rendered with the current mode
+	frames  int
+	results []string
+}
+
+type particle struct {
+	radius, angle, speed float32
+}
+
+func init() {
+	runtime.LockOSThread()
+}
+
+func main() {
+	flag.Parse()
+	err := glfw.Init()
+
+	if err == nil {
+		var window *glfw.Window
+		defer glfw.Terminate()
+		window, err = glfw.CreateWindow(600, 600, title, nil, nil)
+
+		if err == nil {
+			defer window.Destroy()
+			window.SetKeyCallback(onKey)
+			window.SetSizeCallback(onResize)
+			window.MakeContextCurrent()
+			// measure upload performance, not the refresh rate
+			glfw.SwapInterval(0)
+			err = gl.Init()
+
+			if err == nil {
+				app := &example{window: window}
+				mainLoop = loop.New(1.0 / 60.0)
+				mainLoop.Clock = loop.ClockFunc(glfw.GetTime)
+				mainLoop.PollEvents = glfw.PollEvents
+				mainLoop.Stats = stats.New(benchmarkFrames)
+				err = mainLoop.Run(window, app)
+
+				for _, result := range app.results {
+					fmt.Println(result)
+				}
+			}
+		}
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+	}
+}
+
+// Init is called by loop.
+func (app *example) Init() error {
+	var err error
+	app.program, err = gfx.NewProgram(vertexShader, fragmentShader)
+
+	if err == nil {
+		random := rand.New(rand.NewSource(1))
+		app.particles = make([]particle, *particleCount)
+		for i := range app.particles {
+			app.particles[i] = particle{radius: float32(math.Sqrt(random.Float64())) * 0.95, angle: random.Float32() * 2 * math.Pi, speed: 0.2 + random.Float32()}
+		}
+		app.vertices = make([]float32, 0, len(app.particles)*vertexSize)
+		gl.GenVertexArrays(1, &app.vao)
+		app.setMode(gfx.StreamBufferData)
+	}
+	return err
+}
+
+// Update is called by loop.
+func (app *example) Update(dt float64) {
+	for i := range app.particles {
+		p := &app.particles[i]
+		// inner particles rotate faster
+		p.angle += p.speed * float32(dt) / (p.radius + 0.1)
+	}
+}
+
+// Render is called by loop.
+func (app *example) Render(alpha float64) {
+	app.vertices = app.vertices[:0]
+	for _, p := range app.particles {
+		sin, cos := math.Sincos(float64(p.angle))
+		app.vertices = append(app.vertices, p.radius*float32(cos), p.radius*float32(sin), 1-p.radius, 0.5, p.radius, 1)
+	}
+	gl.ClearColor(0, 0, 0, 1)
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+
+	gl.UseProgram(app.program)
+	gl.BindVertexArray(app.vao)
+	offset := app.buffer.Upload(app.vertices)
+	gl.DrawArrays(gl.POINTS, int32(offset/(vertexSize*4)), int32(len(app.particles)))
+	app.buffer.EndFrame()
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.BindVertexArray(0)
+
+	app.frames++
+	if *benchmark && app.frames == benchmarkFrames+1 {
+		nextMode = true
+	}
+	if nextMode {
+		nextMode = false
+		app.nextMode()
+	}
+	if now := glfw.GetTime(); now-app.titleTime >= titleInterval {
+		app.window.SetTitle(fmt.Sprintf("%s - %s - %s", title, app.buffer.Mode, mainLoop.Stats.Summary().String()))
+		app.titleTime = now
+	}
+}
+
+// Shutdown is called by loop.
+func (app *example) Shutdown() {
+	app.buffer.Delete()
+	gl.DeleteVertexArrays(1, &app.vao)
+	gl.DeleteProgram(app.program)
+}
+
+// nextMode switches to the next stream mode. With -benchmark the result of the
+// current mode is stored and the window closes after the last mode.
+func (app *example) nextMode() {
+	mode := app.buffer.Mode
+	if *benchmark {
+		summary := mainLoop.Stats.Summary()
+		app.results = append(app.results, fmt.Sprintf("%-14s %s, %d stalls", mode.String()+":", summary.String(), app.buffer.Stalls))
+		if mode.Next() == gfx.StreamBufferData {
+			app.window.SetShouldClose(true)
+		}
+	}
+	app.buffer.Delete()
+	app.setMode(mode.Next())
+}
+
+// setMode creates a stream buffer for mode and points the vertex attributes to it.
+func (app *example) setMode(mode gfx.StreamMode) {
+	position := uint32(gfx.AttribLocation(app.program, "positionIn"))
+	color := uint32(gfx.AttribLocation(app.program, "colorIn"))
+	app.buffer = gfx.NewStreamBuffer(mode, len(app.particles)*vertexSize*4)
+	gl.BindVertexArray(app.vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, app.buffer.VBO)
+	gl.EnableVertexAttribArray(position)
+	gl.EnableVertexAttribArray(color)
+	gl.VertexAttribPointer(position, 2, gl.FLOAT, false, vertexSize*4, gl.PtrOffset(0))
+	gl.VertexAttribPointer(color, 4, gl.FLOAT, false, vertexSize*4, gl.PtrOffset(2*4))
+	gl.BindVertexArray(0)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	mainLoop.Stats = stats.New(benchmarkFrames)
+	app.frames = 0
+}
+
+func onKey(window *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+	if action == glfw.Press {
+		switch key {
+		case glfw.KeyEscape:
+			window.SetShouldClose(true)
+		case glfw.KeyM:
+			nextMode = true
+		}
+	}
+}
+
+func onResize(w *glfw.Window, width, height int) {
+	gl.Viewport(0, 0, int32(width), int32(height))
+}
@@ -12,10 +12,28 @@ import (
 	"fmt"
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/vbsw/opengl-go-example/gfx"
+	"github.com/vbsw/opengl-go-example/render"
+	"github.com/vbsw/opengl-go-example/vmath"
 	"github.com/vbsw/shaders"
 	"runtime"
 )
 
+// arrays is a vertex array drawn with DrawArrays. It implements scene.Mesh.
+type arrays struct {
+	vao   uint32
+	mode  uint32
+	count int32
+}
+
+// shaderMaterial is a program with an optional texture. It implements
+// scene.Material and render.Sortable.
+type shaderMaterial struct {
+	program     uint32
+	texture     uint32
+	transparent bool
+}
+
 func init() {
 	runtime.LockOSThread()
 }
@@ -57,9 +75,16 @@ func main() {
 					bindPrimitiveObjects(primitiveShader, vaos, vbos)
 					bindTextureObjects(textureShader, vaos, vbos[1:], textures)
 
-					// transparency
-					gl.Enable(gl.BLEND);
-					gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA);
+					triangle := &arrays{vao: vaos[0], mode: gl.TRIANGLES, count: 3}
+					quad := &arrays{mode: gl.TRIANGLE_STRIP, count: 4}
+					primitiveMaterial := &shaderMaterial{program: primitiveShader.ProgramID}
+					// the checkerboard has transparent texels
+					textureMaterial := &shaderMaterial{program: textureShader.ProgramID, texture: textures[0], transparent: true}
+
+					// transparency: blending is enabled in the transparent pass only
+					queue := render.NewQueue()
+					queue.Opaque.RenderState = gfx.Flat2D()
+					queue.Transparent.RenderState = gfx.Flat2D()
 
 					// wireframe mode
 					// gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
@@ -68,17 +93,13 @@ func main() {
 						gl.ClearColor(0, 0, 0, 0)
 						gl.Clear(gl.COLOR_BUFFER_BIT)
 
-						gl.UseProgram(primitiveShader.ProgramID)
-						for _, vao := range vaos {
-							gl.BindVertexArray(vao)
-							gl.DrawArrays(gl.TRIANGLES, 0, 3)
-						}
+						// the order of submission doesn't matter
+						queue.Begin(vmath.Ident4())
+						queue.Submit(quad, textureMaterial, vmath.Ident4())
+						queue.Submit(triangle, primitiveMaterial, vmath.Ident4())
+						queue.Draw()
 						gl.BindVertexArray(0)
 
-						gl.UseProgram(textureShader.ProgramID)
-						gl.BindTexture(gl.TEXTURE_2D, textures[0])
-						gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
-
 						window.SwapBuffers()
 						glfw.PollEvents()
 					}
@@ -91,6 +112,23 @@ func main() {
 	}
 }
 
+// Draw binds the vertex array and draws it.
+func (mesh *arrays) Draw() {
+	gl.BindVertexArray(mesh.vao)
+	gl.DrawArrays(mesh.mode, 0, mesh.count)
+}
+
+// Apply makes the program current and binds the texture.
+func (material *shaderMaterial) Apply(model vmath.Mat4) {
+	gl.UseProgram(material.program)
+	gl.BindTexture(gl.TEXTURE_2D, material.texture)
+}
+
+// SortInfo returns program, texture and transparency for the render queue.
+func (material *shaderMaterial) SortInfo() render.SortInfo {
+	return render.SortInfo{Program: material.program, Texture: material.texture, Transparent: material.transparent}
+}
+
 func onKey(window *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
 	if key == glfw.KeyEscape && action == glfw.Press {
 		window.SetShouldClose(true)
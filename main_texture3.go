@@ -12,11 +12,40 @@ import (
 	"fmt"
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/vbsw/opengl-go-example/gfx"
+	"github.com/vbsw/opengl-go-example/render"
+	"github.com/vbsw/opengl-go-example/vmath"
 	"github.com/vbsw/shaders"
 	"runtime"
 	"unsafe"
 )
 
+// cache skips redundant state changes; toggled with key S
+var cache = gfx.NewStateCache()
+
+// arrays is a vertex array drawn with DrawArrays. It implements scene.Mesh.
+type arrays struct {
+	vao   uint32
+	mode  uint32
+	count int32
+}
+
+// elements is a vertex array drawn with DrawElements. It implements scene.Mesh.
+type elements struct {
+	vao   uint32
+	ebo   uint32
+	mode  uint32
+	count int32
+}
+
+// shaderMaterial is a program with an optional texture. It implements
+// scene.Material and render.Sortable.
+type shaderMaterial struct {
+	program     uint32
+	texture     uint32
+	transparent bool
+}
+
 func init() {
 	runtime.LockOSThread()
 }
@@ -58,34 +87,35 @@ func main() {
 					bindPrimitiveObjects(primitiveShader, vaos, vbos)
 					bindTextureObjects(textureShader, vaos[1:], vbos[1:], textures)
 
-					// transparency
-					gl.Enable(gl.BLEND);
-					gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA);
+					triangle := &arrays{vao: vaos[0], mode: gl.TRIANGLES, count: 3}
+					quad := &elements{vao: vaos[1], ebo: vbos[2], mode: gl.TRIANGLES, count: 6}
+					primitiveMaterial := &shaderMaterial{program: primitiveShader.ProgramID}
+					// the checkerboard has transparent texels
+					textureMaterial := &shaderMaterial{program: textureShader.ProgramID, texture: textures[0], transparent: true}
+
+					// transparency: blending is enabled in the transparent pass only
+					queue := render.NewQueue()
+					queue.Opaque.RenderState = gfx.Flat2D()
+					queue.Transparent.RenderState = gfx.Flat2D()
+					queue.Cache = cache
 
 					// wireframe mode
 					// gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
 
-					for !window.ShouldClose() {
+					for frames := 1; !window.ShouldClose(); frames++ {
 						gl.ClearColor(0, 0, 0, 0)
 						gl.Clear(gl.COLOR_BUFFER_BIT)
 
-						// triangle
-						gl.UseProgram(primitiveShader.ProgramID)
-						gl.BindVertexArray(vaos[0])
-						gl.DrawArrays(gl.TRIANGLES, 0, 3)
-						gl.BindVertexArray(0)
-
-						// texture
-						gl.UseProgram(textureShader.ProgramID)
-						gl.BindVertexArray(vaos[1])
-						gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, vbos[2])
-						gl.BindTexture(gl.TEXTURE_2D, textures[0])
-
-						gl.DrawElements(gl.TRIANGLES, 6, gl.UNSIGNED_INT, unsafe.Pointer(nil))
-						gl.BindTexture(gl.TEXTURE_2D, 0)
-						gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0)
-						gl.BindVertexArray(0)
+						// the order of submission doesn't matter
+						queue.Begin(vmath.Ident4())
+						queue.Submit(quad, textureMaterial, vmath.Ident4())
+						queue.Submit(triangle, primitiveMaterial, vmath.Ident4())
+						queue.Draw()
 
+						stats := cache.EndFrame()
+						if frames%60 == 0 {
+							window.SetTitle(fmt.Sprintf("OpenGL Example - cache %s: %s", onOff(cache.Enabled), stats.String()))
+						}
 						window.SwapBuffers()
 						glfw.PollEvents()
 					}
@@ -101,7 +131,69 @@ func main() {
 func onKey(window *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
 	if key == glfw.KeyEscape && action == glfw.Press {
 		window.SetShouldClose(true)
+	} else if key == glfw.KeyS && action == glfw.Press {
+		cache.Enabled = !cache.Enabled
+		// state was changed without the cache
+		cache.Invalidate()
+	}
+}
+
+// Draw binds the vertex array and draws it. Without the cache the vertex array is
+// unbound after drawing and the calls are counted for comparison.
+func (mesh *arrays) Draw() {
+	if cache.Enabled {
+		cache.BindVertexArray(mesh.vao)
+		cache.DrawArrays(mesh.mode, 0, mesh.count)
+	} else {
+		gl.BindVertexArray(mesh.vao)
+		gl.DrawArrays(mesh.mode, 0, mesh.count)
+		gl.BindVertexArray(0)
+		cache.CountDraw()
+		cache.CountStateChanges(2)
+	}
+}
+
+// Draw binds the vertex array and the element buffer and draws them. Without the
+// cache both are unbound after drawing and the calls are counted for comparison.
+func (mesh *elements) Draw() {
+	if cache.Enabled {
+		// the vertex array keeps the element buffer bound
+		cache.BindVertexArray(mesh.vao)
+		cache.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, mesh.ebo)
+		cache.DrawElements(mesh.mode, mesh.count, gl.UNSIGNED_INT, 0)
+	} else {
+		gl.BindVertexArray(mesh.vao)
+		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, mesh.ebo)
+		gl.DrawElements(mesh.mode, mesh.count, gl.UNSIGNED_INT, unsafe.Pointer(nil))
+		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0)
+		gl.BindVertexArray(0)
+		cache.CountDraw()
+		cache.CountStateChanges(4)
+	}
+}
+
+// Apply makes the program current and binds the texture (0, if there is none).
+func (material *shaderMaterial) Apply(model vmath.Mat4) {
+	if cache.Enabled {
+		cache.UseProgram(material.program)
+		cache.BindTexture(0, gl.TEXTURE_2D, material.texture)
+	} else {
+		gl.UseProgram(material.program)
+		gl.BindTexture(gl.TEXTURE_2D, material.texture)
+		cache.CountStateChanges(2)
+	}
+}
+
+// SortInfo returns program, texture and transparency for the render queue.
+func (material *shaderMaterial) SortInfo() render.SortInfo {
+	return render.SortInfo{Program: material.program, Texture: material.texture, Transparent: material.transparent}
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
 	}
+	return "off"
 }
 
 func onResize(w *glfw.Window, width, height int) {
@@ -0,0 +1,379 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+// +build transparency
+
+package main
+
+import (
+	"fmt"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/vbsw/opengl-go-example/camera"
+	"github.com/vbsw/opengl-go-example/gfx"
+	"github.com/vbsw/opengl-go-example/input"
+	"github.com/vbsw/opengl-go-example/loop"
+	"github.com/vbsw/opengl-go-example/mesh"
+	"github.com/vbsw/opengl-go-example/render"
+	"github.com/vbsw/opengl-go-example/scene"
+	"github.com/vbsw/opengl-go-example/vmath"
+	"math"
+	"runtime"
+)
+
+const vertexShader = `#version 130
+
+in vec3 positionIn;
+in vec3 normalIn;
+uniform mat4 projection;
+uniform mat4 model;
+out vec3 fragmentNormal;
+
+void main() {
+	gl_Position = projection * model * vec4(positionIn, 1.0);
+	fragmentNormal = mat3(model) * normalIn;
+}
+`
+
+// fragmentShader lights both sides of the faces. Compiled with OIT defined, it
+// writes with writeOIT of render.OITShader instead of to an output.
+const fragmentShader = `
+in vec3 fragmentNormal;
+uniform vec4 color;
+
+vec4 shade() {
+	vec3 normal = normalize(fragmentNormal);
+	float light = 0.4 + 0.6 * abs(dot(normal, normalize(vec3(0.5, 1.0, 0.3))));
+	return vec4(color.rgb * light, color.a);
+}
+
+#ifdef OIT
+void main() {
+	writeOIT(shade());
+}
+#else
+out vec4 fragmentColor;
+
+void main() {
+	fragmentColor = shade();
+}
+#endif
+`
+
+var mouse *input.Mouse
+var orbit *camera.Orbit
+var framebuffer *gfx.Framebuffer
+var oit *render.OIT
+var queue *render.Queue
+
+// toggled with keys O and S
+var useOIT = true
+
+// toggled with key space
+var paused bool
+
+type example struct {
+	window *glfw.Window
+	// blended and OIT variant of the shader
+	programs    [2]*colorProgram
+	meshes      []*gfx.Mesh
+	root        *scene.Node
+	transparent *scene.Node
+	drawList    scene.DrawList
+}
+
+// colorProgram is a compiled variant of the shader.
+type colorProgram struct {
+	id         uint32
+	projection int32
+	model      int32
+	color      int32
+}
+
+// colorMaterial draws with a single color. Transparent materials use the OIT variant
+// of the shader, if OIT is enabled.
+type colorMaterial struct {
+	programs    *[2]*colorProgram
+	color       vmath.Vec4
+	transparent bool
+}
+
+func init() {
+	runtime.LockOSThread()
+}
+
+func main() {
+	err := glfw.Init()
+
+	if err == nil {
+		var window *glfw.Window
+		defer glfw.Terminate()
+		window, err = glfw.CreateWindow(800, 500, "OpenGL Example", nil, nil)
+
+		if err == nil {
+			defer window.Destroy()
+			width, height := window.GetFramebufferSize()
+			orbit = camera.NewOrbit(vmath.Vec3{0, 1, 0}, 7, width, height)
+			orbit.Pitch = vmath.Radians(-20)
+			mouse = input.NewMouse()
+			mouse.Register(window)
+			window.SetKeyCallback(onKey)
+			window.SetFramebufferSizeCallback(onResize)
+			window.MakeContextCurrent()
+			err = gl.Init()
+
+			if err == nil {
+				mainLoop := loop.New(1.0 / 60.0)
+				mainLoop.Clock = loop.ClockFunc(glfw.GetTime)
+				mainLoop.PollEvents = glfw.PollEvents
+				err = mainLoop.Run(window, &example{window: window})
+			}
+		}
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+	}
+}
+
+// Init is called by loop.
+func (app *example) Init() error {
+	blended, err := newColorProgram(false)
+
+	if err == nil {
+		var oitProgram *colorProgram
+		oitProgram, err = newColorProgram(true)
+
+		if err == nil {
+			width, height := app.window.GetFramebufferSize()
+			config := gfx.FramebufferConfig{Width: width, Height: height, Colors: []int32{gl.RGBA8}, Depth: gfx.DepthRenderbuffer}
+			framebuffer, err = gfx.NewFramebuffer(config)
+
+			if err == nil {
+				oit, err = render.NewOIT(width, height)
+
+				if err == nil {
+					app.programs = [2]*colorProgram{blended, oitProgram}
+					queue = render.NewQueue()
+					app.newScene()
+					updateTitle(app.window)
+				} else {
+					framebuffer.Delete()
+					oitProgram.delete()
+					blended.delete()
+				}
+			} else {
+				oitProgram.delete()
+				blended.delete()
+			}
+		} else {
+			blended.delete()
+		}
+	}
+	return err
+}
+
+// Update is called by loop.
+func (app *example) Update(dt float64) {
+	orbit.HandleMouse(app.window, mouse)
+	if !paused {
+		app.transparent.Rotate(vmath.QuatAxisAngle(vmath.Vec3{0, 1, 0}, float32(dt)*0.3))
+	}
+	mouse.EndFrame()
+}
+
+// Render is called by loop. Opaque draws are rendered to the framebuffer first, then
+// the transparent draws either blended in order of the queue or with OIT.
+func (app *example) Render(alpha float64) {
+	framebuffer.Bind()
+	gl.ClearColor(0.05, 0.05, 0.08, 1)
+	gl.DepthMask(true)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+	for _, program := range app.programs {
+		gl.UseProgram(program.id)
+		camera.SetUniforms(orbit, program.projection, -1)
+	}
+	app.drawList.Reset()
+	app.drawList.Collect(app.root)
+	queue.Begin(orbit.View())
+	queue.SubmitList(&app.drawList)
+	queue.Sort()
+	queue.DrawOpaque()
+	if useOIT {
+		oit.Render(queue, framebuffer)
+	} else {
+		queue.DrawTransparent()
+	}
+	gl.BindVertexArray(0)
+
+	width, height := app.window.GetFramebufferSize()
+	framebuffer.BlitToScreen(width, height)
+}
+
+// Shutdown is called by loop.
+func (app *example) Shutdown() {
+	for _, m := range app.meshes {
+		m.Delete()
+	}
+	oit.Delete()
+	framebuffer.Delete()
+	for _, program := range app.programs {
+		program.delete()
+	}
+}
+
+// newScene creates a ground with opaque cubes and transparent quads, that intersect
+// each other and a cube. Sorting by the centers of the quads can't order them.
+func (app *example) newScene() {
+	ground := app.newMesh(mesh.Plane(12, 12, 1, 1))
+	cube := app.newMesh(mesh.Cube(1, 1))
+	quad := app.newMesh(mesh.Plane(2.4, 2.4, 1, 1))
+	app.root = scene.NewNode("root")
+
+	floor := scene.NewNode("ground")
+	floor.Mesh = ground
+	floor.Material = app.newMaterial(vmath.Vec4{0.5, 0.5, 0.5, 1})
+	app.root.Add(floor)
+	for i := 0; i < 4; i++ {
+		node := scene.NewNode(fmt.Sprintf("cube%d", i))
+		node.Mesh = cube
+		node.Material = app.newMaterial(vmath.Vec4{0.9, 0.8, 0.6, 1})
+		sin, cos := math.Sincos(float64(i)*math.Pi/2 + math.Pi/4)
+		node.SetPosition(vmath.Vec3{float32(cos) * 3, 0.5, float32(sin) * 3})
+		app.root.Add(node)
+	}
+	// cuts through the quads
+	center := scene.NewNode("center cube")
+	center.Mesh = cube
+	center.Material = app.newMaterial(vmath.Vec4{0.3, 0.6, 0.9, 1})
+	center.SetPosition(vmath.Vec3{0, 0.4, 0})
+	center.SetScale(vmath.Vec3{0.6, 0.6, 0.6})
+	app.root.Add(center)
+
+	// the planes are horizontal, they are stood up around the x axis
+	upright := vmath.QuatAxisAngle(vmath.Vec3{1, 0, 0}, vmath.Radians(90))
+	colors := []vmath.Vec4{{1, 0.2, 0.2, 0.5}, {0.2, 1, 0.2, 0.5}, {0.2, 0.3, 1, 0.5}}
+	app.transparent = scene.NewNode("transparent")
+	app.transparent.SetPosition(vmath.Vec3{0, 1.3, 0})
+	app.root.Add(app.transparent)
+	for i, color := range colors {
+		node := scene.NewNode(fmt.Sprintf("quad%d", i))
+		node.Mesh = quad
+		node.Material = app.newTransparentMaterial(color)
+		angle := vmath.QuatAxisAngle(vmath.Vec3{0, 1, 0}, vmath.Radians(float32(i)*60))
+		node.SetRotation(angle.Mul(upright))
+		app.transparent.Add(node)
+	}
+	// a tilted quad through the other three
+	tilted := scene.NewNode("tilted quad")
+	tilted.Mesh = quad
+	tilted.Material = app.newTransparentMaterial(vmath.Vec4{1, 0.9, 0.2, 0.4})
+	tilted.SetRotation(vmath.QuatAxisAngle(vmath.Vec3{1, 0, 0}, vmath.Radians(30)))
+	tilted.SetScale(vmath.Vec3{1.2, 1, 1.2})
+	app.transparent.Add(tilted)
+}
+
+func (app *example) newMesh(m *mesh.Mesh) *gfx.Mesh {
+	// both programs bind the attributes to the same locations
+	position := gfx.VertexAttribute{Location: 0, Size: 3, Offset: mesh.PositionOffset}
+	normal := gfx.VertexAttribute{Location: 1, Size: 3, Offset: mesh.NormalOffset}
+	gfxMesh := gfx.NewMesh(gl.TRIANGLES, m.VertexData(), mesh.VertexSize, m.Indices, position, normal)
+	app.meshes = append(app.meshes, gfxMesh)
+	return gfxMesh
+}
+
+func (app *example) newMaterial(color vmath.Vec4) *colorMaterial {
+	return &colorMaterial{programs: &app.programs, color: color}
+}
+
+func (app *example) newTransparentMaterial(color vmath.Vec4) *colorMaterial {
+	return &colorMaterial{programs: &app.programs, color: color, transparent: true}
+}
+
+// newColorProgram compiles the shader with or without OIT.
+func newColorProgram(withOIT bool) (*colorProgram, error) {
+	source := "#version 130\n" + fragmentShader
+	if withOIT {
+		source = "#version 130\n#define OIT\n" + render.OITShader + fragmentShader
+	}
+	id, err := gfx.NewProgramWithAttributes(vertexShader, source, "positionIn", "normalIn")
+	if err != nil {
+		return nil, err
+	}
+	program := &colorProgram{id: id}
+	program.projection = gfx.UniformLocation(id, "projection")
+	program.model = gfx.UniformLocation(id, "model")
+	program.color = gfx.UniformLocation(id, "color")
+	return program, nil
+}
+
+func (program *colorProgram) delete() {
+	gl.DeleteProgram(program.id)
+}
+
+// program returns the variant of the shader to draw with.
+func (material *colorMaterial) program() *colorProgram {
+	if material.transparent && useOIT {
+		return material.programs[1]
+	}
+	return material.programs[0]
+}
+
+// Apply sets program, model matrix and color.
+func (material *colorMaterial) Apply(model vmath.Mat4) {
+	program := material.program()
+	gl.UseProgram(program.id)
+	gl.UniformMatrix4fv(program.model, 1, false, model.Ptr())
+	gl.Uniform4f(program.color, material.color[0], material.color[1], material.color[2], material.color[3])
+}
+
+// SortInfo returns program and transparency for the render queue.
+func (material *colorMaterial) SortInfo() render.SortInfo {
+	return render.SortInfo{Program: material.program().id, Transparent: material.transparent}
+}
+
+func onKey(window *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+	if action == glfw.Press {
+		switch key {
+		case glfw.KeyEscape:
+			window.SetShouldClose(true)
+		case glfw.KeyO:
+			useOIT = !useOIT
+		case glfw.KeyS:
+			queue.Sorting = !queue.Sorting
+		case glfw.KeySpace:
+			paused = !paused
+		case glfw.KeyC:
+			mouse.NextCursorMode(window)
+		}
+		updateTitle(window)
+	}
+}
+
+// updateTitle shows the transparency mode in the window title.
+func updateTitle(window *glfw.Window) {
+	mode := "blended, sorted back to front"
+	if useOIT {
+		mode = "weighted blended OIT"
+	} else if !queue.Sorting {
+		mode = "blended, unsorted"
+	}
+	window.SetTitle("OpenGL Example - transparency: " + mode)
+}
+
+// onResize resizes the framebuffer and the OIT targets (size in pixels).
+func onResize(w *glfw.Window, width, height int) {
+	if width > 0 && height > 0 {
+		orbit.Resize(width, height)
+		err := framebuffer.Resize(width, height)
+		if err == nil {
+			err = oit.Resize(width, height)
+		}
+		if err != nil {
+			fmt.Println(err.Error())
+			w.SetShouldClose(true)
+		}
+	}
+}
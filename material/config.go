@@ -0,0 +1,184 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+// Package material binds a program, textures and uniform values for drawing.
+// Texture units are assigned automatically. Materials can be read from a JSON file:
+//
+//	{"materials": [
+//		{"name": "floor", "program": "texture",
+//			"textures": {"imageTexture": {"texture": "checker"}}},
+//		{"name": "water", "vertex": "water.vert", "fragment": "water.frag",
+//			"textures": {"normalMap": {"path": "water.png", "filter": "mipmap", "wrap": "repeat"}},
+//			"uniforms": {"tint": [0.2, 0.5, 0.8, 0.7], "speed": 0.5}, "transparent": true}
+//	]}
+//
+// Programs and textures are referenced by name, if they are registered in the
+// Library, or loaded from files relative to the JSON file.
+package material
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+)
+
+// Texture filters of TextureConfig.
+const (
+	Nearest = "nearest"
+	Linear  = "linear"
+	// linear with mipmaps (trilinear)
+	Mipmap = "mipmap"
+)
+
+// Wrap modes of TextureConfig.
+const (
+	Clamp  = "clamp"
+	Repeat = "repeat"
+	Mirror = "mirror"
+)
+
+// Value is the value of a float uniform. Its length selects the type: float, vec2,
+// vec3, vec4, mat3 or mat4 (column-major). In JSON a float can be written as number.
+type Value []float32
+
+// Config is the definition of a material.
+type Config struct {
+	Name string `json:"name"`
+	// name of a program registered in the library
+	Program string `json:"program,omitempty"`
+	// shader files, if Program is empty
+	Vertex   string `json:"vertex,omitempty"`
+	Fragment string `json:"fragment,omitempty"`
+	// textures by name of the sampler uniform
+	Textures map[string]TextureConfig `json:"textures,omitempty"`
+	// values by name of the uniform
+	Uniforms map[string]Value `json:"uniforms,omitempty"`
+	// drawn in the transparent pass of render.Queue
+	Transparent bool `json:"transparent,omitempty"`
+}
+
+// TextureConfig references a texture registered in the library or a PNG file.
+type TextureConfig struct {
+	Texture string `json:"texture,omitempty"`
+	Path    string `json:"path,omitempty"`
+	// Nearest, Linear (default) or Mipmap; only used for files
+	Filter string `json:"filter,omitempty"`
+	// Clamp (default), Repeat or Mirror; only used for files
+	Wrap string `json:"wrap,omitempty"`
+}
+
+// File is the content of a JSON material file.
+type File struct {
+	Materials []Config `json:"materials"`
+}
+
+// UnmarshalJSON decodes a number or an array of numbers.
+func (value *Value) UnmarshalJSON(data []byte) error {
+	var number float32
+	if err := json.Unmarshal(data, &number); err == nil {
+		*value = Value{number}
+		return nil
+	}
+	var numbers []float32
+	if err := json.Unmarshal(data, &numbers); err != nil {
+		return errors.New("uniform value must be a number or an array of numbers")
+	}
+	*value = numbers
+	return nil
+}
+
+// Valid returns true, if the length of value is a supported uniform type.
+func (value Value) Valid() bool {
+	switch len(value) {
+	case 1, 2, 3, 4, 9, 16:
+		return true
+	}
+	return false
+}
+
+// Validate returns an error, if the configuration is incomplete or has invalid
+// values.
+func (config *Config) Validate() error {
+	if len(config.Name) == 0 {
+		return errors.New("material without name")
+	}
+	if len(config.Program) == 0 && (len(config.Vertex) == 0 || len(config.Fragment) == 0) {
+		return fmt.Errorf("material %q: program or vertex and fragment shader required", config.Name)
+	}
+	for _, name := range config.TextureNames() {
+		texture := config.Textures[name]
+		if (len(texture.Texture) == 0) == (len(texture.Path) == 0) {
+			return fmt.Errorf("material %q: texture %q needs either texture or path", config.Name, name)
+		}
+		if _, ok := filters[texture.Filter]; !ok {
+			return fmt.Errorf("material %q: texture %q has unknown filter %q", config.Name, name, texture.Filter)
+		}
+		if _, ok := wrapModes[texture.Wrap]; !ok {
+			return fmt.Errorf("material %q: texture %q has unknown wrap mode %q", config.Name, name, texture.Wrap)
+		}
+	}
+	for name, value := range config.Uniforms {
+		if !value.Valid() {
+			return fmt.Errorf("material %q: uniform %q has %d values", config.Name, name, len(value))
+		}
+	}
+	return nil
+}
+
+// TextureNames returns the names of the textures in alphabetical order, which is
+// the order of their texture units.
+func (config *Config) TextureNames() []string {
+	names := make([]string, 0, len(config.Textures))
+	for name := range config.Textures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Read decodes a JSON material file and validates it. Names must be unique.
+func Read(reader io.Reader) (File, error) {
+	var file File
+	err := json.NewDecoder(reader).Decode(&file)
+	if err == nil {
+		names := make(map[string]bool)
+		for i := 0; i < len(file.Materials) && err == nil; i++ {
+			config := &file.Materials[i]
+			err = config.Validate()
+			if err == nil && names[config.Name] {
+				err = fmt.Errorf("material %q defined twice", config.Name)
+			}
+			names[config.Name] = true
+		}
+	}
+	return file, err
+}
+
+// ReadFile reads the JSON material file path.
+func ReadFile(path string) (File, error) {
+	osFile, err := os.Open(path)
+	if err != nil {
+		return File{}, err
+	}
+	defer osFile.Close()
+	file, err := Read(osFile)
+	if err != nil {
+		err = fmt.Errorf("%s: %v", path, err)
+	}
+	return file, err
+}
+
+// Save writes the materials as indented JSON to file path.
+func (file File) Save(path string) error {
+	data, err := json.MarshalIndent(file, "", "\t")
+	if err == nil {
+		err = ioutil.WriteFile(path, append(data, '\n'), 0644)
+	}
+	return err
+}
@@ -0,0 +1,65 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package material
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr bool
+	}{
+		{"empty", `{"materials": []}`, false},
+		{"program", `{"materials": [{"name": "a", "program": "texture"}]}`, false},
+		{"files", `{"materials": [{"name": "a", "vertex": "a.vert", "fragment": "a.frag"}]}`, false},
+		{"textures", `{"materials": [{"name": "a", "program": "p", "textures": {"t": {"texture": "checker"}, "u": {"path": "u.png", "filter": "mipmap", "wrap": "repeat"}}}]}`, false},
+		{"uniforms", `{"materials": [{"name": "a", "program": "p", "uniforms": {"f": 1, "v": [1, 2, 3], "m": [1, 0, 0, 0, 1, 0, 0, 0, 1]}}]}`, false},
+		{"no name", `{"materials": [{"program": "p"}]}`, true},
+		{"no program", `{"materials": [{"name": "a", "vertex": "a.vert"}]}`, true},
+		{"duplicate", `{"materials": [{"name": "a", "program": "p"}, {"name": "a", "program": "q"}]}`, true},
+		{"texture and path", `{"materials": [{"name": "a", "program": "p", "textures": {"t": {"texture": "x", "path": "x.png"}}}]}`, true},
+		{"no texture", `{"materials": [{"name": "a", "program": "p", "textures": {"t": {}}}]}`, true},
+		{"filter", `{"materials": [{"name": "a", "program": "p", "textures": {"t": {"path": "x.png", "filter": "cubic"}}}]}`, true},
+		{"wrap", `{"materials": [{"name": "a", "program": "p", "textures": {"t": {"path": "x.png", "wrap": "border"}}}]}`, true},
+		{"uniform size", `{"materials": [{"name": "a", "program": "p", "uniforms": {"v": [1, 2, 3, 4, 5]}}]}`, true},
+		{"uniform type", `{"materials": [{"name": "a", "program": "p", "uniforms": {"v": "red"}}]}`, true},
+		{"syntax", `{"materials": [`, true},
+	}
+	for _, test := range tests {
+		if _, err := Read(strings.NewReader(test.json)); (err != nil) != test.wantErr {
+			t.Errorf("%s: error %v", test.name, err)
+		}
+	}
+}
+
+func TestValue(t *testing.T) {
+	file, err := Read(strings.NewReader(`{"materials": [{"name": "a", "program": "p", "uniforms": {"f": 0.5, "v": [1, 2]}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	uniforms := file.Materials[0].Uniforms
+	if f := uniforms["f"]; len(f) != 1 || f[0] != 0.5 {
+		t.Errorf("number: got %v", f)
+	}
+	if v := uniforms["v"]; len(v) != 2 || v[0] != 1 || v[1] != 2 {
+		t.Errorf("array: got %v", v)
+	}
+}
+
+func TestTextureNames(t *testing.T) {
+	config := Config{Name: "a", Program: "p", Textures: map[string]TextureConfig{
+		"normalMap": {Texture: "n"},
+		"albedo":    {Texture: "a"},
+		"detail":    {Texture: "d"},
+	}}
+	if got := strings.Join(config.TextureNames(), " "); got != "albedo detail normalMap" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,175 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package material
+
+import (
+	"fmt"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/vbsw/opengl-go-example/gfx"
+	"io/ioutil"
+	"path/filepath"
+)
+
+var filters = map[string]int32{"": gl.LINEAR, Nearest: gl.NEAREST, Linear: gl.LINEAR, Mipmap: gl.LINEAR}
+var wrapModes = map[string]int32{"": gl.CLAMP_TO_EDGE, Clamp: gl.CLAMP_TO_EDGE, Repeat: gl.REPEAT, Mirror: gl.MIRRORED_REPEAT}
+
+// Library creates materials from configurations. Programs and textures are shared
+// by the materials. Registered programs and textures are owned by the caller, the
+// ones loaded from files by the library.
+type Library struct {
+	// directory of relative file paths
+	Dir string
+	// attributes bound to locations 0, 1, 2 etc. of programs loaded from files
+	Attributes []string
+	Materials  map[string]*Material
+	programs   map[string]uint32
+	textures   map[string]uint32
+	// loaded from files by file names
+	loadedPrograms map[string]uint32
+	loadedTextures map[string]*gfx.Texture
+}
+
+// NewLibrary returns an empty library with relative paths in dir.
+func NewLibrary(dir string, attributes ...string) *Library {
+	library := &Library{Dir: dir, Attributes: attributes}
+	library.Materials = make(map[string]*Material)
+	library.programs = make(map[string]uint32)
+	library.textures = make(map[string]uint32)
+	library.loadedPrograms = make(map[string]uint32)
+	library.loadedTextures = make(map[string]*gfx.Texture)
+	return library
+}
+
+// AddProgram registers program under name.
+func (library *Library) AddProgram(name string, program uint32) {
+	library.programs[name] = program
+}
+
+// AddTexture registers the 2D texture under name.
+func (library *Library) AddTexture(name string, texture uint32) {
+	library.textures[name] = texture
+}
+
+// Add creates a material from config and stores it by its name, replacing a material
+// of the same name.
+func (library *Library) Add(config Config) (*Material, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+	program, err := library.program(&config)
+	if err != nil {
+		return nil, fmt.Errorf("material %q: %v", config.Name, err)
+	}
+	material := New(config.Name, program)
+	material.Transparent = config.Transparent
+	for _, name := range config.TextureNames() {
+		texture, err := library.texture(config.Textures[name])
+		if err != nil {
+			return nil, fmt.Errorf("material %q: %v", config.Name, err)
+		}
+		material.SetTexture(name, gl.TEXTURE_2D, texture)
+	}
+	for name, value := range config.Uniforms {
+		material.SetUniform(name, value...)
+	}
+	library.Materials[config.Name] = material
+	return material, nil
+}
+
+// AddFile creates the materials of the JSON file path. Materials defined before the
+// first error are added.
+func (library *Library) AddFile(path string) error {
+	file, err := ReadFile(path)
+	for i := 0; i < len(file.Materials) && err == nil; i++ {
+		_, err = library.Add(file.Materials[i])
+		if err != nil {
+			err = fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	return err
+}
+
+// Material returns the material name or nil.
+func (library *Library) Material(name string) *Material {
+	return library.Materials[name]
+}
+
+// Delete deletes the materials and the programs and textures loaded from files.
+// Registered programs and textures are kept.
+func (library *Library) Delete() {
+	for _, program := range library.loadedPrograms {
+		gl.DeleteProgram(program)
+	}
+	for _, texture := range library.loadedTextures {
+		texture.Delete()
+	}
+	library.Materials = make(map[string]*Material)
+	library.loadedPrograms = make(map[string]uint32)
+	library.loadedTextures = make(map[string]*gfx.Texture)
+}
+
+// program returns the registered program or compiles the shader files once.
+func (library *Library) program(config *Config) (uint32, error) {
+	if len(config.Program) > 0 {
+		program, ok := library.programs[config.Program]
+		if !ok {
+			return 0, fmt.Errorf("unknown program %q", config.Program)
+		}
+		return program, nil
+	}
+	key := config.Vertex + "+" + config.Fragment
+	if program, ok := library.loadedPrograms[key]; ok {
+		return program, nil
+	}
+	vertexShader, err := ioutil.ReadFile(library.path(config.Vertex))
+	if err != nil {
+		return 0, err
+	}
+	fragmentShader, err := ioutil.ReadFile(library.path(config.Fragment))
+	if err != nil {
+		return 0, err
+	}
+	program, err := gfx.NewProgramWithAttributes(string(vertexShader), string(fragmentShader), library.Attributes...)
+	if err != nil {
+		return 0, err
+	}
+	library.loadedPrograms[key] = program
+	return program, nil
+}
+
+// texture returns the registered texture or loads the file once per filter and wrap
+// mode.
+func (library *Library) texture(config TextureConfig) (uint32, error) {
+	if len(config.Texture) > 0 {
+		texture, ok := library.textures[config.Texture]
+		if !ok {
+			return 0, fmt.Errorf("unknown texture %q", config.Texture)
+		}
+		return texture, nil
+	}
+	key := config.Path + "+" + config.Filter + "+" + config.Wrap
+	if texture, ok := library.loadedTextures[key]; ok {
+		return texture.ID, nil
+	}
+	texture, err := gfx.LoadTexture(library.path(config.Path), filters[config.Filter])
+	if err != nil {
+		return 0, err
+	}
+	texture.SetWrap(wrapModes[config.Wrap])
+	if config.Filter == Mipmap {
+		texture.GenerateMipmaps()
+	}
+	library.loadedTextures[key] = texture
+	return texture.ID, nil
+}
+
+func (library *Library) path(path string) string {
+	if filepath.IsAbs(path) || len(library.Dir) == 0 {
+		return path
+	}
+	return filepath.Join(library.Dir, path)
+}
@@ -0,0 +1,134 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package material
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/vbsw/opengl-go-example/gfx"
+	"github.com/vbsw/opengl-go-example/render"
+	"github.com/vbsw/opengl-go-example/vmath"
+)
+
+// Material is a program with textures and uniform values. It implements
+// scene.Material.
+type Material struct {
+	Name    string
+	Program uint32
+	// location of the uniform model or -1
+	ModelLocation int32
+	// texture i is bound to unit i
+	Textures []Texture
+	Uniforms []Uniform
+	// drawn in the transparent pass of render.Queue
+	Transparent bool
+}
+
+// Texture is a texture bound to a sampler uniform.
+type Texture struct {
+	Name string
+	// e.g. gl.TEXTURE_2D or gl.TEXTURE_CUBE_MAP
+	Target   uint32
+	ID       uint32
+	location int32
+}
+
+// Uniform is a uniform value.
+type Uniform struct {
+	Name     string
+	Value    Value
+	location int32
+}
+
+// New returns a material of program without textures and uniform values.
+func New(name string, program uint32) *Material {
+	return &Material{Name: name, Program: program, ModelLocation: gfx.UniformLocation(program, "model")}
+}
+
+// SetTexture binds texture to the sampler uniform name. A new texture gets the next
+// free texture unit.
+func (material *Material) SetTexture(name string, target, texture uint32) {
+	for i := range material.Textures {
+		if material.Textures[i].Name == name {
+			material.Textures[i].Target = target
+			material.Textures[i].ID = texture
+			return
+		}
+	}
+	location := gfx.UniformLocation(material.Program, name)
+	material.Textures = append(material.Textures, Texture{Name: name, Target: target, ID: texture, location: location})
+}
+
+// SetUniform sets the value of the uniform name. Values must be a valid Value.
+func (material *Material) SetUniform(name string, values ...float32) {
+	value := append(Value(nil), values...)
+	for i := range material.Uniforms {
+		if material.Uniforms[i].Name == name {
+			material.Uniforms[i].Value = value
+			return
+		}
+	}
+	location := gfx.UniformLocation(material.Program, name)
+	material.Uniforms = append(material.Uniforms, Uniform{Name: name, Value: value, location: location})
+}
+
+// Unit returns the texture unit of the sampler uniform name or -1.
+func (material *Material) Unit(name string) int {
+	for i := range material.Textures {
+		if material.Textures[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// SortInfo returns program, first texture and transparency for render.Queue.
+func (material *Material) SortInfo() render.SortInfo {
+	info := render.SortInfo{Program: material.Program, Transparent: material.Transparent}
+	if len(material.Textures) > 0 {
+		info.Texture = material.Textures[0].ID
+	}
+	return info
+}
+
+// Use makes the program current, binds the textures and sets the uniform values.
+// Texture unit 0 is active afterwards.
+func (material *Material) Use() {
+	gl.UseProgram(material.Program)
+	for i := range material.Textures {
+		texture := &material.Textures[i]
+		gl.ActiveTexture(gl.TEXTURE0 + uint32(i))
+		gl.BindTexture(texture.Target, texture.ID)
+		gl.Uniform1i(texture.location, int32(i))
+	}
+	gl.ActiveTexture(gl.TEXTURE0)
+	for i := range material.Uniforms {
+		uniform := &material.Uniforms[i]
+		setUniform(uniform.location, uniform.Value)
+	}
+}
+
+// Apply uses the material and sets the model matrix.
+func (material *Material) Apply(model vmath.Mat4) {
+	material.Use()
+	gl.UniformMatrix4fv(material.ModelLocation, 1, false, model.Ptr())
+}
+
+func setUniform(location int32, value Value) {
+	switch len(value) {
+	case 1:
+		gl.Uniform1f(location, value[0])
+	case 2:
+		gl.Uniform2f(location, value[0], value[1])
+	case 3:
+		gl.Uniform3f(location, value[0], value[1], value[2])
+	case 4:
+		gl.Uniform4f(location, value[0], value[1], value[2], value[3])
+	case 9:
+		gl.UniformMatrix3fv(location, 1, false, &value[0])
+	case 16:
+		gl.UniformMatrix4fv(location, 1, false, &value[0])
+	}
+}
@@ -0,0 +1,131 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package mesh
+
+import (
+	"github.com/vbsw/opengl-go-example/vmath"
+	"math"
+)
+
+// Box is an axis-aligned bounding box.
+type Box struct {
+	Min, Max vmath.Vec3
+}
+
+// Sphere is a bounding sphere.
+type Sphere struct {
+	Center vmath.Vec3
+	Radius float32
+}
+
+// BoundingBox returns the axis-aligned bounding box of the vertices.
+func (mesh *Mesh) BoundingBox() Box {
+	var box Box
+	if len(mesh.Vertices) > 0 {
+		box.Min = mesh.Vertices[0].Position
+		box.Max = box.Min
+		for _, vertex := range mesh.Vertices[1:] {
+			box = box.Extend(vertex.Position)
+		}
+	}
+	return box
+}
+
+// BoundingSphere returns a bounding sphere of the vertices computed with Ritter's
+// algorithm. It contains all vertices, but is usually larger than the minimal sphere
+// (typically by 5 to 20%).
+func (mesh *Mesh) BoundingSphere() Sphere {
+	var sphere Sphere
+	if len(mesh.Vertices) > 0 {
+		// start with the point farthest from the first vertex and the point farthest
+		// from that one
+		a := farthest(mesh.Vertices, mesh.Vertices[0].Position)
+		b := farthest(mesh.Vertices, a)
+		sphere.Center = a.Lerp(b, 0.5)
+		sphere.Radius = b.Sub(a).Len() / 2
+		for _, vertex := range mesh.Vertices {
+			sphere = sphere.Extend(vertex.Position)
+		}
+	}
+	return sphere
+}
+
+// Extend returns the box enlarged to contain point.
+func (box Box) Extend(point vmath.Vec3) Box {
+	for i := 0; i < 3; i++ {
+		box.Min[i] = float32(math.Min(float64(box.Min[i]), float64(point[i])))
+		box.Max[i] = float32(math.Max(float64(box.Max[i]), float64(point[i])))
+	}
+	return box
+}
+
+// Center returns the center of the box.
+func (box Box) Center() vmath.Vec3 {
+	return box.Min.Lerp(box.Max, 0.5)
+}
+
+// Size returns the edge lengths of the box.
+func (box Box) Size() vmath.Vec3 {
+	return box.Max.Sub(box.Min)
+}
+
+// Contains returns true, if point is inside or on the box.
+func (box Box) Contains(point vmath.Vec3) bool {
+	for i := 0; i < 3; i++ {
+		if point[i] < box.Min[i] || point[i] > box.Max[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Transform returns the bounding box of the box transformed by m (Arvo's method).
+func (box Box) Transform(m vmath.Mat4) Box {
+	translation := m.Translation()
+	transformed := Box{translation, translation}
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			a := m.At(row, col) * box.Min[col]
+			b := m.At(row, col) * box.Max[col]
+			transformed.Min[row] += float32(math.Min(float64(a), float64(b)))
+			transformed.Max[row] += float32(math.Max(float64(a), float64(b)))
+		}
+	}
+	return transformed
+}
+
+// Sphere returns the sphere enclosing the box.
+func (box Box) Sphere() Sphere {
+	return Sphere{box.Center(), box.Size().Len() / 2}
+}
+
+// Extend returns the sphere enlarged to contain point.
+func (sphere Sphere) Extend(point vmath.Vec3) Sphere {
+	distance := point.Sub(sphere.Center).Len()
+	if distance > sphere.Radius {
+		radius := (sphere.Radius + distance) / 2
+		sphere.Center = sphere.Center.Add(point.Sub(sphere.Center).Scale((radius - sphere.Radius) / distance))
+		sphere.Radius = radius
+	}
+	return sphere
+}
+
+// Contains returns true, if point is inside or on the sphere (with tolerance vmath.Epsilon).
+func (sphere Sphere) Contains(point vmath.Vec3) bool {
+	return point.Sub(sphere.Center).Len() <= sphere.Radius+vmath.Epsilon*float32(math.Max(1, float64(sphere.Radius)))
+}
+
+func farthest(vertices []Vertex, from vmath.Vec3) vmath.Vec3 {
+	var maxDistance float32
+	point := from
+	for _, vertex := range vertices {
+		if distance := vertex.Position.Sub(from).Len(); distance > maxDistance {
+			maxDistance = distance
+			point = vertex.Position
+		}
+	}
+	return point
+}
@@ -0,0 +1,48 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package mesh
+
+import (
+	"github.com/vbsw/opengl-go-example/vmath"
+	"math"
+	"testing"
+)
+
+func TestBoundingBox(t *testing.T) {
+	m := Cube(2, 1)
+	m.Transform(vmath.Translate(vmath.Vec3{1, 2, 3}))
+	box := m.BoundingBox()
+	if (box != Box{vmath.Vec3{0, 1, 2}, vmath.Vec3{2, 3, 4}}) {
+		t.Errorf("box %v", box)
+	}
+	if !box.Center().ApproxEqual(vmath.Vec3{1, 2, 3}) || !box.Contains(vmath.Vec3{1, 1, 4}) || box.Contains(vmath.Vec3{1, 0, 3}) {
+		t.Errorf("center %v", box.Center())
+	}
+	rotated := Box{vmath.Vec3{-1, -1, -1}, vmath.Vec3{1, 1, 1}}.Transform(vmath.RotateY(math.Pi / 4))
+	if !rotated.Max.ApproxEqual(vmath.Vec3{math.Sqrt2, 1, math.Sqrt2}) {
+		t.Errorf("rotated box %v", rotated)
+	}
+}
+
+func TestBoundingSphere(t *testing.T) {
+	for _, test := range testShapes {
+		sphere := test.mesh.BoundingSphere()
+		for _, vertex := range test.mesh.Vertices {
+			if !sphere.Contains(vertex.Position) {
+				t.Fatalf("%s: %v not in sphere %v", test.name, vertex.Position, sphere)
+			}
+		}
+		// the minimal sphere is at least as large as the half of the box diagonal
+		minimal := test.mesh.BoundingBox().Size().Len() / 2 / float32(math.Sqrt(3))
+		if sphere.Radius > test.mesh.BoundingBox().Sphere().Radius*1.05 || sphere.Radius < minimal {
+			t.Errorf("%s: radius %f", test.name, sphere.Radius)
+		}
+	}
+	sphere := UVSphere(2, 32, 16).BoundingSphere()
+	if !sphere.Center.ApproxEqual(vmath.Vec3{}) || math.Abs(float64(sphere.Radius-2)) > 0.05 {
+		t.Errorf("sphere %v", sphere)
+	}
+}
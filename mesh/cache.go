@@ -0,0 +1,181 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package mesh
+
+import (
+	"math"
+)
+
+// size of the simulated vertex cache in OptimizeVertexCache
+const cacheSize = 32
+
+// parameters of Forsyth's scoring function
+const (
+	cacheDecayPower   = 1.5
+	lastTriangleScore = 0.75
+	valenceBoostScale = 2.0
+	valenceBoostPower = 0.5
+)
+
+type cacheVertex struct {
+	// position in the simulated cache, -1 if not in cache
+	cachePosition int
+	score         float32
+	// triangles not yet added, that use the vertex
+	triangles []int
+}
+
+// OptimizeVertexCache reorders the triangles to reduce vertex shader invocations with
+// Tom Forsyth's "Linear-Speed Vertex Cache Optimisation". Vertices are not changed.
+func (mesh *Mesh) OptimizeVertexCache() {
+	triangleCount := mesh.Triangles()
+	if triangleCount == 0 {
+		return
+	}
+	vertices := make([]cacheVertex, len(mesh.Vertices))
+	for i := range vertices {
+		vertices[i].cachePosition = -1
+	}
+	for t := 0; t < triangleCount; t++ {
+		for k := 0; k < 3; k++ {
+			v := &vertices[mesh.Indices[t*3+k]]
+			v.triangles = append(v.triangles, t)
+		}
+	}
+	for i := range vertices {
+		vertices[i].score = vertexScore(&vertices[i])
+	}
+	added := make([]bool, triangleCount)
+	triangleScores := make([]float32, triangleCount)
+	for t := range triangleScores {
+		triangleScores[t] = mesh.triangleScore(vertices, t)
+	}
+	indices := make([]uint32, 0, len(mesh.Indices))
+	cache := make([]uint32, 0, cacheSize+3)
+	next := bestTriangle(triangleScores, added)
+	for len(indices) < len(mesh.Indices) {
+		if next < 0 {
+			// no triangle in cache, search all remaining triangles
+			next = bestTriangle(triangleScores, added)
+		}
+		added[next] = true
+		tri := mesh.Indices[next*3 : next*3+3]
+		indices = append(indices, tri...)
+		// move vertices of the triangle to the front of the cache
+		for k := 2; k >= 0; k-- {
+			cache = moveToFront(cache, tri[k])
+			removeTriangle(&vertices[tri[k]], next)
+		}
+		for i, index := range cache {
+			if i < cacheSize {
+				vertices[index].cachePosition = i
+			} else {
+				vertices[index].cachePosition = -1
+			}
+			vertices[index].score = vertexScore(&vertices[index])
+		}
+		// rescore triangles of cached vertices and find the best one
+		next = -1
+		var bestScore float32
+		for _, index := range cache {
+			for _, t := range vertices[index].triangles {
+				triangleScores[t] = mesh.triangleScore(vertices, t)
+				if triangleScores[t] > bestScore {
+					bestScore = triangleScores[t]
+					next = t
+				}
+			}
+		}
+		if len(cache) > cacheSize {
+			cache = cache[:cacheSize]
+		}
+	}
+	mesh.Indices = indices
+}
+
+// ACMR returns the average cache miss ratio (vertex shader invocations per triangle)
+// of a FIFO cache with size entries. It ranges from about 0.5 (optimal) to 3.
+func (mesh *Mesh) ACMR(size int) float32 {
+	if mesh.Triangles() == 0 {
+		return 0
+	}
+	var misses int
+	cache := make([]uint32, 0, size)
+	for _, index := range mesh.Indices {
+		cached := false
+		for _, entry := range cache {
+			if entry == index {
+				cached = true
+				break
+			}
+		}
+		if !cached {
+			misses++
+			if len(cache) == size {
+				cache = cache[1:]
+			}
+			cache = append(cache, index)
+		}
+	}
+	return float32(misses) / float32(mesh.Triangles())
+}
+
+func (mesh *Mesh) triangleScore(vertices []cacheVertex, t int) float32 {
+	return vertices[mesh.Indices[t*3]].score + vertices[mesh.Indices[t*3+1]].score + vertices[mesh.Indices[t*3+2]].score
+}
+
+func vertexScore(v *cacheVertex) float32 {
+	if len(v.triangles) == 0 {
+		return -1
+	}
+	var score float64
+	if v.cachePosition >= 0 {
+		if v.cachePosition < 3 {
+			// vertices of the last triangle get a fixed score, regardless of order
+			score = lastTriangleScore
+		} else {
+			scaler := 1.0 / (cacheSize - 3)
+			score = math.Pow(1-float64(v.cachePosition-3)*scaler, cacheDecayPower)
+		}
+	}
+	// vertices with few remaining triangles are preferred
+	score += valenceBoostScale * math.Pow(float64(len(v.triangles)), -valenceBoostPower)
+	return float32(score)
+}
+
+func bestTriangle(scores []float32, added []bool) int {
+	best := -1
+	for t, score := range scores {
+		if !added[t] && (best < 0 || score > scores[best]) {
+			best = t
+		}
+	}
+	return best
+}
+
+func moveToFront(cache []uint32, index uint32) []uint32 {
+	for i, entry := range cache {
+		if entry == index {
+			copy(cache[1:i+1], cache[:i])
+			cache[0] = index
+			return cache
+		}
+	}
+	cache = append(cache, 0)
+	copy(cache[1:], cache)
+	cache[0] = index
+	return cache
+}
+
+func removeTriangle(v *cacheVertex, t int) {
+	for i, other := range v.triangles {
+		if other == t {
+			v.triangles[i] = v.triangles[len(v.triangles)-1]
+			v.triangles = v.triangles[:len(v.triangles)-1]
+			return
+		}
+	}
+}
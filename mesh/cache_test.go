@@ -0,0 +1,68 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package mesh
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// shuffled returns a grid with triangles in random order.
+func shuffled() *Mesh {
+	m := Plane(1, 1, 64, 64)
+	random := rand.New(rand.NewSource(1))
+	random.Shuffle(m.Triangles(), func(i, j int) {
+		for k := 0; k < 3; k++ {
+			m.Indices[i*3+k], m.Indices[j*3+k] = m.Indices[j*3+k], m.Indices[i*3+k]
+		}
+	})
+	return m
+}
+
+func TestOptimizeVertexCache(t *testing.T) {
+	m := shuffled()
+	triangles := sortedTriangles(m)
+	before := m.ACMR(16)
+	m.OptimizeVertexCache()
+	after := m.ACMR(16)
+	if after >= before || after > 0.8 {
+		t.Errorf("ACMR %f before, %f after", before, after)
+	}
+	// same triangles with the same winding
+	optimized := sortedTriangles(m)
+	for i := range triangles {
+		if triangles[i] != optimized[i] {
+			t.Fatalf("triangle %v changed to %v", triangles[i], optimized[i])
+		}
+	}
+}
+
+// sortedTriangles returns the triangles rotated to start with the smallest index and sorted.
+func sortedTriangles(m *Mesh) [][3]uint32 {
+	var triangles [][3]uint32
+	for i := 0; i < len(m.Indices); i += 3 {
+		tri := [3]uint32{m.Indices[i], m.Indices[i+1], m.Indices[i+2]}
+		for tri[0] > tri[1] || tri[0] > tri[2] {
+			tri = [3]uint32{tri[1], tri[2], tri[0]}
+		}
+		triangles = append(triangles, tri)
+	}
+	sort.Slice(triangles, func(i, j int) bool {
+		a, b := triangles[i], triangles[j]
+		return a[0] < b[0] || a[0] == b[0] && (a[1] < b[1] || a[1] == b[1] && a[2] < b[2])
+	})
+	return triangles
+}
+
+func BenchmarkOptimizeVertexCache(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		m := shuffled()
+		b.StartTimer()
+		m.OptimizeVertexCache()
+	}
+}
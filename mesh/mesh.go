@@ -0,0 +1,117 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+// Package mesh generates and processes indexed triangle meshes on the CPU.
+//
+// Triangles are counter-clockwise seen from the front (outside). Texture coordinates
+// start at the lower left corner. The tangent points in direction of increasing u, its
+// w component is the sign of the bitangent (bitangent = cross(normal, tangent) * w),
+// which points in direction of increasing v.
+package mesh
+
+import (
+	"github.com/vbsw/opengl-go-example/vmath"
+)
+
+// Offsets of the vertex attributes in VertexData in number of floats.
+const (
+	PositionOffset = 0
+	NormalOffset   = 3
+	UVOffset       = 6
+	TangentOffset  = 8
+	// number of floats per vertex
+	VertexSize = 12
+)
+
+// Vertex is a vertex of a mesh.
+type Vertex struct {
+	Position vmath.Vec3
+	Normal   vmath.Vec3
+	UV       vmath.Vec2
+	Tangent  vmath.Vec4
+}
+
+// Mesh is an indexed triangle list.
+type Mesh struct {
+	Vertices []Vertex
+	// three indices per triangle
+	Indices []uint32
+}
+
+// VertexData returns the interleaved vertices (position, normal, uv, tangent) as
+// uploaded to a vertex buffer.
+func (mesh *Mesh) VertexData() []float32 {
+	data := make([]float32, 0, len(mesh.Vertices)*VertexSize)
+	for _, v := range mesh.Vertices {
+		data = append(data,
+			v.Position[0], v.Position[1], v.Position[2],
+			v.Normal[0], v.Normal[1], v.Normal[2],
+			v.UV[0], v.UV[1],
+			v.Tangent[0], v.Tangent[1], v.Tangent[2], v.Tangent[3])
+	}
+	return data
+}
+
+// Triangles returns the number of triangles.
+func (mesh *Mesh) Triangles() int {
+	return len(mesh.Indices) / 3
+}
+
+// Append adds the vertices and triangles of other to mesh.
+func (mesh *Mesh) Append(other *Mesh) {
+	first := uint32(len(mesh.Vertices))
+	mesh.Vertices = append(mesh.Vertices, other.Vertices...)
+	for _, index := range other.Indices {
+		mesh.Indices = append(mesh.Indices, first+index)
+	}
+}
+
+// Transform transforms positions with m and normals and tangents with the normal
+// matrix of m.
+func (mesh *Mesh) Transform(m vmath.Mat4) {
+	normalMatrix := m.NormalMatrix()
+	for i := range mesh.Vertices {
+		v := &mesh.Vertices[i]
+		v.Position = m.TransformPoint(v.Position)
+		v.Normal = normalMatrix.MulVec3(v.Normal).Normalize()
+		tangent := m.TransformDir(v.Tangent.Vec3()).Normalize()
+		v.Tangent = tangent.Vec4(v.Tangent[3])
+	}
+	if m.Det() < 0 {
+		// mirrored, restore counter-clockwise order
+		for i := 0; i+2 < len(mesh.Indices); i += 3 {
+			mesh.Indices[i+1], mesh.Indices[i+2] = mesh.Indices[i+2], mesh.Indices[i+1]
+		}
+	}
+}
+
+// addLattice adds a grid of rows x cols quads. Vertex returns the vertex at row i
+// (0 to rows) and column j (0 to cols), rows advance in direction of the bitangent
+// and columns in direction of the tangent. If bottomPole or topPole is true, the
+// first or last row of vertices collapses to a point and the degenerate triangles
+// are omitted.
+func (mesh *Mesh) addLattice(rows, cols int, bottomPole, topPole bool, vertex func(i, j int) Vertex) {
+	first := uint32(len(mesh.Vertices))
+	stride := uint32(cols + 1)
+	for i := 0; i <= rows; i++ {
+		for j := 0; j <= cols; j++ {
+			mesh.Vertices = append(mesh.Vertices, vertex(i, j))
+		}
+	}
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			bottomLeft := first + uint32(i)*stride + uint32(j)
+			bottomRight := bottomLeft + 1
+			topLeft := bottomLeft + stride
+			topRight := topLeft + 1
+			if i > 0 || !bottomPole {
+				mesh.Indices = append(mesh.Indices, bottomLeft, bottomRight, topRight)
+			}
+			if i < rows-1 || !topPole {
+				mesh.Indices = append(mesh.Indices, bottomLeft, topRight, topLeft)
+			}
+		}
+	}
+}
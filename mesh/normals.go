@@ -0,0 +1,85 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package mesh
+
+import (
+	"github.com/vbsw/opengl-go-example/vmath"
+	"math"
+)
+
+// SmoothNormals computes vertex normals by averaging the normals of adjacent
+// triangles weighted by the angle at the vertex. Triangles are adjacent, if they share
+// a vertex position. Only triangles, whose normals differ by at most angle (radians)
+// from the triangle of a corner, contribute to the normal of the corner. Vertices are
+// split where corners get different normals (hard edges). Vertices at the same
+// position get the same normal, but are not merged (see Weld).
+func (mesh *Mesh) SmoothNormals(angle float32) {
+	mesh.computeNormals(float32(math.Cos(float64(angle))), false)
+}
+
+// FlatNormals sets the normal of every vertex to the normal of its triangle. Vertices
+// shared by triangles with different normals are split.
+func (mesh *Mesh) FlatNormals() {
+	mesh.computeNormals(1, true)
+}
+
+func (mesh *Mesh) computeNormals(minCos float32, flat bool) {
+	faceNormals := make([]vmath.Vec3, mesh.Triangles())
+	// angle of every corner, corner i belongs to triangle i/3
+	angles := make([]float32, len(mesh.Indices))
+	// corners at the same position
+	cornersAt := make(map[vmath.Vec3][]int)
+	for i := 0; i+2 < len(mesh.Indices); i += 3 {
+		a := mesh.Vertices[mesh.Indices[i]].Position
+		b := mesh.Vertices[mesh.Indices[i+1]].Position
+		c := mesh.Vertices[mesh.Indices[i+2]].Position
+		faceNormals[i/3] = b.Sub(a).Cross(c.Sub(a)).Normalize()
+		angles[i] = cornerAngle(a, b, c)
+		angles[i+1] = cornerAngle(b, c, a)
+		angles[i+2] = cornerAngle(c, a, b)
+	}
+	for corner, index := range mesh.Indices {
+		position := mesh.Vertices[index].Position
+		cornersAt[position] = append(cornersAt[position], corner)
+	}
+	type key struct {
+		index  uint32
+		normal vmath.Vec3
+	}
+	vertices := make([]Vertex, 0, len(mesh.Vertices))
+	newIndices := make(map[key]uint32)
+	for corner, index := range mesh.Indices {
+		faceNormal := faceNormals[corner/3]
+		normal := faceNormal
+		if !flat {
+			normal = vmath.Vec3{}
+			for _, other := range cornersAt[mesh.Vertices[index].Position] {
+				otherNormal := faceNormals[other/3]
+				if other/3 == corner/3 || faceNormal.Dot(otherNormal) >= minCos {
+					normal = normal.Add(otherNormal.Scale(angles[other]))
+				}
+			}
+			normal = normal.Normalize()
+		}
+		k := key{index, normal}
+		newIndex, ok := newIndices[k]
+		if !ok {
+			vertex := mesh.Vertices[index]
+			vertex.Normal = normal
+			newIndex = uint32(len(vertices))
+			vertices = append(vertices, vertex)
+			newIndices[k] = newIndex
+		}
+		mesh.Indices[corner] = newIndex
+	}
+	mesh.Vertices = vertices
+}
+
+// cornerAngle returns the angle at a in triangle a, b, c.
+func cornerAngle(a, b, c vmath.Vec3) float32 {
+	ab, ac := b.Sub(a).Normalize(), c.Sub(a).Normalize()
+	return float32(math.Acos(float64(vmath.Clamp(ab.Dot(ac), -1, 1))))
+}
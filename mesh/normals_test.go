@@ -0,0 +1,81 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package mesh
+
+import (
+	"github.com/vbsw/opengl-go-example/vmath"
+	"testing"
+)
+
+// positionsOnly returns mesh with vertices reduced to positions and welded, like
+// an imported mesh without normals.
+func positionsOnly(mesh *Mesh) *Mesh {
+	stripped := &Mesh{Indices: append([]uint32(nil), mesh.Indices...)}
+	for _, vertex := range mesh.Vertices {
+		stripped.Vertices = append(stripped.Vertices, Vertex{Position: vertex.Position})
+	}
+	stripped.Weld(1e-5)
+	return stripped
+}
+
+func TestSmoothNormals(t *testing.T) {
+	tests := []struct {
+		name     string
+		angle    float32
+		vertices int
+	}{
+		// cube corners are split into three vertices (one per face)
+		{"hard edges", vmath.Radians(60), 24},
+		// every corner keeps one vertex with the averaged normal
+		{"smooth", vmath.Radians(100), 8},
+	}
+	for _, test := range tests {
+		m := positionsOnly(Cube(2, 1))
+		if len(m.Vertices) != 8 {
+			t.Fatalf("welded cube has %d vertices", len(m.Vertices))
+		}
+		m.SmoothNormals(test.angle)
+		if len(m.Vertices) != test.vertices {
+			t.Errorf("%s: %d vertices, want %d", test.name, len(m.Vertices), test.vertices)
+		}
+		for _, vertex := range m.Vertices {
+			if test.vertices == 8 {
+				want := vertex.Position.Normalize()
+				if !vertex.Normal.ApproxEqual(want) {
+					t.Errorf("%s: normal %v, want %v", test.name, vertex.Normal, want)
+				}
+			} else if vertex.Normal.Dot(vertex.Position) != 1 {
+				t.Errorf("%s: normal %v is not a face normal at %v", test.name, vertex.Normal, vertex.Position)
+			}
+		}
+	}
+}
+
+func TestSmoothNormalsSphere(t *testing.T) {
+	sphere := UVSphere(1, 32, 16)
+	m := positionsOnly(sphere)
+	m.SmoothNormals(vmath.Radians(45))
+	for _, vertex := range m.Vertices {
+		if vertex.Normal.Dot(vertex.Position.Normalize()) < 0.99 {
+			t.Fatalf("normal %v at %v", vertex.Normal, vertex.Position)
+		}
+	}
+}
+
+func TestFlatNormals(t *testing.T) {
+	m := positionsOnly(Icosphere(1, 0))
+	m.FlatNormals()
+	if len(m.Vertices) != 60 {
+		t.Errorf("%d vertices, want 60", len(m.Vertices))
+	}
+	for i := 0; i < len(m.Indices); i += 3 {
+		a, b, c := m.Vertices[m.Indices[i]], m.Vertices[m.Indices[i+1]], m.Vertices[m.Indices[i+2]]
+		want := b.Position.Sub(a.Position).Cross(c.Position.Sub(a.Position)).Normalize()
+		if !a.Normal.ApproxEqual(want) || a.Normal != b.Normal || a.Normal != c.Normal {
+			t.Fatalf("triangle %d: normals %v %v %v, want %v", i/3, a.Normal, b.Normal, c.Normal, want)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package mesh
+
+import (
+	"github.com/vbsw/opengl-go-example/vmath"
+	"math"
+)
+
+// Plane returns a plane on the xz-plane centered at the origin facing up (+y) with
+// segmentsX x segmentsZ quads. The texture's v axis points to -z.
+func Plane(width, depth float32, segmentsX, segmentsZ int) *Mesh {
+	mesh := new(Mesh)
+	mesh.addGrid(vmath.Vec3{0, 0, 0}, vmath.Vec3{width / 2, 0, 0}, vmath.Vec3{0, 0, -depth / 2}, segmentsX, segmentsZ)
+	return mesh
+}
+
+// Cube returns a cube centered at the origin with edges of length size. Each face
+// is a grid of segments x segments quads with its own texture coordinates (0 to 1).
+func Cube(size float32, segments int) *Mesh {
+	mesh := new(Mesh)
+	half := size / 2
+	normals := []vmath.Vec3{{1, 0, 0}, {-1, 0, 0}, {0, 1, 0}, {0, -1, 0}, {0, 0, 1}, {0, 0, -1}}
+	for _, normal := range normals {
+		up := vmath.Vec3{0, 1, 0}
+		if normal[1] > 0 {
+			up = vmath.Vec3{0, 0, -1}
+		} else if normal[1] < 0 {
+			up = vmath.Vec3{0, 0, 1}
+		}
+		// right x up = normal
+		right := up.Cross(normal)
+		mesh.addGrid(normal.Scale(half), right.Scale(half), up.Scale(half), segments, segments)
+	}
+	return mesh
+}
+
+// UVSphere returns a sphere centered at the origin with segments around the y axis and
+// rings from pole to pole. The texture seam is at +z.
+func UVSphere(radius float32, segments, rings int) *Mesh {
+	mesh := new(Mesh)
+	mesh.addLattice(rings, segments, true, true, func(i, j int) Vertex {
+		u := float32(j) / float32(segments)
+		v := float32(i) / float32(rings)
+		// polar angle from the top
+		theta := (1 - v) * math.Pi
+		return sphereVertex(radius, u, theta, vmath.Vec3{}, v)
+	})
+	return mesh
+}
+
+// Icosphere returns a subdivided icosahedron centered at the origin. Every subdivision
+// splits each triangle into four. Vertices are duplicated at the texture seam (+z).
+func Icosphere(radius float32, subdivisions int) *Mesh {
+	positions, indices := icosahedron()
+	for i := 0; i < subdivisions; i++ {
+		positions, indices = subdivide(positions, indices)
+	}
+	mesh := &Mesh{Indices: indices}
+	for _, position := range positions {
+		if position[0]*position[0]+position[2]*position[2] < 1e-6 {
+			// remove rounding errors at the poles
+			position = vmath.Vec3{0, float32(math.Copysign(1, float64(position[1]))), 0}
+		}
+		u := float32(math.Atan2(float64(position[0]), float64(position[2])) / (2 * math.Pi))
+		if u < 0 {
+			u += 1
+		}
+		theta := float32(math.Acos(float64(vmath.Clamp(position[1], -1, 1))))
+		mesh.Vertices = append(mesh.Vertices, sphereVertex(radius, u, theta, vmath.Vec3{}, 1-theta/math.Pi))
+	}
+	mesh.fixSeam()
+	return mesh
+}
+
+// Cylinder returns a cylinder centered at the origin along the y axis with segments
+// around the axis and stacks along the axis. If caps is true, top and bottom are closed.
+func Cylinder(radius, height float32, segments, stacks int, caps bool) *Mesh {
+	return frustum(radius, radius, height, segments, stacks, caps, caps)
+}
+
+// Cone returns a cone centered at the origin along the y axis with the tip at the top.
+// Segments are around the axis and stacks along the axis. If caps is true, the bottom
+// is closed.
+func Cone(radius, height float32, segments, stacks int, caps bool) *Mesh {
+	return frustum(radius, 0, height, segments, stacks, caps, false)
+}
+
+// Torus returns a torus centered at the origin around the y axis. Major is the radius
+// of the ring, minor the radius of the tube. Segments are around the y axis and sides
+// around the tube.
+func Torus(major, minor float32, segments, sides int) *Mesh {
+	mesh := new(Mesh)
+	mesh.addLattice(sides, segments, false, false, func(i, j int) Vertex {
+		u := float32(j) / float32(segments)
+		v := float32(i) / float32(sides)
+		phi := float64(u) * 2 * math.Pi
+		// angle around the tube, starting at the inner equator
+		psi := float64(v)*2*math.Pi + math.Pi
+		sinPhi, cosPhi := float32(math.Sin(phi)), float32(math.Cos(phi))
+		sinPsi, cosPsi := float32(math.Sin(psi)), float32(math.Cos(psi))
+		normal := vmath.Vec3{cosPsi * sinPhi, sinPsi, cosPsi * cosPhi}
+		center := vmath.Vec3{major * sinPhi, 0, major * cosPhi}
+		position := center.Add(normal.Scale(minor))
+		return Vertex{position, normal, vmath.Vec2{u, v}, vmath.Vec4{cosPhi, 0, -sinPhi, 1}}
+	})
+	return mesh
+}
+
+// Capsule returns a cylinder with hemispherical ends centered at the origin along the
+// y axis. Height is the length of the cylindric part. Segments are around the axis,
+// rings are per hemisphere and stacks (at least 1) along the cylindric part.
+func Capsule(radius, height float32, segments, rings, stacks int) *Mesh {
+	mesh := new(Mesh)
+	half := height / 2
+	// length of the outline from bottom pole to top pole
+	length := math.Pi*radius + height
+	rows := 2*rings + stacks
+	mesh.addLattice(rows, segments, true, true, func(i, j int) Vertex {
+		var theta float32
+		var offset vmath.Vec3
+		var distance float32
+		u := float32(j) / float32(segments)
+		if i <= rings {
+			theta = math.Pi - float32(i)/float32(rings)*math.Pi/2
+			offset = vmath.Vec3{0, -half, 0}
+			distance = (math.Pi - theta) * radius
+		} else if i < rings+stacks {
+			theta = math.Pi / 2
+			t := float32(i-rings) / float32(stacks)
+			offset = vmath.Vec3{0, -half + t*height, 0}
+			distance = math.Pi/2*radius + t*height
+		} else {
+			theta = float32(rows-i) / float32(rings) * math.Pi / 2
+			offset = vmath.Vec3{0, half, 0}
+			distance = length - theta*radius
+		}
+		return sphereVertex(radius, u, theta, offset, distance/length)
+	})
+	return mesh
+}
+
+// addGrid adds a rectangle of segmentsU x segmentsV quads. Right and up are half the
+// edges of the rectangle, the normal is cross(right, up).
+func (mesh *Mesh) addGrid(center, right, up vmath.Vec3, segmentsU, segmentsV int) {
+	normal := right.Cross(up).Normalize()
+	tangent := right.Normalize().Vec4(1)
+	mesh.addLattice(segmentsV, segmentsU, false, false, func(i, j int) Vertex {
+		u := float32(j) / float32(segmentsU)
+		v := float32(i) / float32(segmentsV)
+		position := center.Add(right.Scale(u*2 - 1)).Add(up.Scale(v*2 - 1))
+		return Vertex{position, normal, vmath.Vec2{u, v}, tangent}
+	})
+}
+
+// frustum returns a truncated cone along the y axis.
+func frustum(bottomRadius, topRadius, height float32, segments, stacks int, bottomCap, topCap bool) *Mesh {
+	mesh := new(Mesh)
+	half := height / 2
+	slope := vmath.Vec2{height, bottomRadius - topRadius}.Normalize()
+	mesh.addLattice(stacks, segments, bottomRadius == 0, topRadius == 0, func(i, j int) Vertex {
+		u := float32(j) / float32(segments)
+		v := float32(i) / float32(stacks)
+		phi := float64(u) * 2 * math.Pi
+		sinPhi, cosPhi := float32(math.Sin(phi)), float32(math.Cos(phi))
+		radius := vmath.Lerp(bottomRadius, topRadius, v)
+		position := vmath.Vec3{radius * sinPhi, -half + v*height, radius * cosPhi}
+		normal := vmath.Vec3{slope[0] * sinPhi, slope[1], slope[0] * cosPhi}
+		return Vertex{position, normal, vmath.Vec2{u, v}, vmath.Vec4{cosPhi, 0, -sinPhi, 1}}
+	})
+	if bottomCap && bottomRadius > 0 {
+		mesh.addDisc(-half, bottomRadius, segments, -1)
+	}
+	if topCap && topRadius > 0 {
+		mesh.addDisc(half, topRadius, segments, 1)
+	}
+	return mesh
+}
+
+// addDisc adds a disc at height y facing up (direction 1) or down (direction -1).
+func (mesh *Mesh) addDisc(y, radius float32, segments int, direction float32) {
+	first := uint32(len(mesh.Vertices))
+	normal := vmath.Vec3{0, direction, 0}
+	tangent := vmath.Vec4{1, 0, 0, 1}
+	mesh.Vertices = append(mesh.Vertices, Vertex{vmath.Vec3{0, y, 0}, normal, vmath.Vec2{0.5, 0.5}, tangent})
+	for j := 0; j <= segments; j++ {
+		phi := float64(j) / float64(segments) * 2 * math.Pi
+		sinPhi, cosPhi := float32(math.Sin(phi)), float32(math.Cos(phi))
+		position := vmath.Vec3{radius * sinPhi, y, radius * cosPhi}
+		// bitangent (v) is -z on the top and +z on the bottom
+		uv := vmath.Vec2{0.5 + 0.5*sinPhi, 0.5 - 0.5*cosPhi*direction}
+		mesh.Vertices = append(mesh.Vertices, Vertex{position, normal, uv, tangent})
+	}
+	for j := uint32(1); j <= uint32(segments); j++ {
+		if direction > 0 {
+			mesh.Indices = append(mesh.Indices, first, first+j, first+j+1)
+		} else {
+			mesh.Indices = append(mesh.Indices, first, first+j+1, first+j)
+		}
+	}
+}
+
+// sphereVertex returns the vertex at azimuth u (0 to 1) and polar angle theta
+// (0 at the top) of a sphere moved by offset.
+func sphereVertex(radius, u, theta float32, offset vmath.Vec3, v float32) Vertex {
+	phi := float64(u) * 2 * math.Pi
+	sinPhi, cosPhi := float32(math.Sin(phi)), float32(math.Cos(phi))
+	sinTheta, cosTheta := float32(math.Sin(float64(theta))), float32(math.Cos(float64(theta)))
+	normal := vmath.Vec3{sinTheta * sinPhi, cosTheta, sinTheta * cosPhi}
+	position := offset.Add(normal.Scale(radius))
+	return Vertex{position, normal, vmath.Vec2{u, v}, vmath.Vec4{cosPhi, 0, -sinPhi, 1}}
+}
+
+// icosahedron returns the unit icosahedron.
+func icosahedron() ([]vmath.Vec3, []uint32) {
+	t := float32((1 + math.Sqrt(5)) / 2)
+	positions := []vmath.Vec3{
+		{-1, t, 0}, {1, t, 0}, {-1, -t, 0}, {1, -t, 0},
+		{0, -1, t}, {0, 1, t}, {0, -1, -t}, {0, 1, -t},
+		{t, 0, -1}, {t, 0, 1}, {-t, 0, -1}, {-t, 0, 1}}
+	for i := range positions {
+		positions[i] = positions[i].Normalize()
+	}
+	indices := []uint32{
+		0, 11, 5, 0, 5, 1, 0, 1, 7, 0, 7, 10, 0, 10, 11,
+		1, 5, 9, 5, 11, 4, 11, 10, 2, 10, 7, 6, 7, 1, 8,
+		3, 9, 4, 3, 4, 2, 3, 2, 6, 3, 6, 8, 3, 8, 9,
+		4, 9, 5, 2, 4, 11, 6, 2, 10, 8, 6, 7, 9, 8, 1}
+	return positions, indices
+}
+
+// subdivide splits every triangle into four and projects new vertices onto the unit sphere.
+func subdivide(positions []vmath.Vec3, indices []uint32) ([]vmath.Vec3, []uint32) {
+	midpoints := make(map[[2]uint32]uint32)
+	midpoint := func(a, b uint32) uint32 {
+		key := [2]uint32{a, b}
+		if a > b {
+			key = [2]uint32{b, a}
+		}
+		index, ok := midpoints[key]
+		if !ok {
+			index = uint32(len(positions))
+			positions = append(positions, positions[a].Add(positions[b]).Normalize())
+			midpoints[key] = index
+		}
+		return index
+	}
+	subdivided := make([]uint32, 0, len(indices)*4)
+	for i := 0; i+2 < len(indices); i += 3 {
+		a, b, c := indices[i], indices[i+1], indices[i+2]
+		ab, bc, ca := midpoint(a, b), midpoint(b, c), midpoint(c, a)
+		subdivided = append(subdivided, a, ab, ca, b, bc, ab, c, ca, bc, ab, bc, ca)
+	}
+	return positions, subdivided
+}
+
+// fixSeam duplicates vertices of triangles crossing the texture seam, where u wraps
+// from 1 to 0. The copies get u + 1. Vertices at the poles, where u is undefined, are
+// duplicated for every triangle and get the average u of the other two vertices.
+func (mesh *Mesh) fixSeam() {
+	wrapped := make(map[uint32]uint32)
+	for i := 0; i+2 < len(mesh.Indices); i += 3 {
+		tri := mesh.Indices[i : i+3]
+		minU, maxU := float32(1), float32(0)
+		for _, index := range tri {
+			if !isPole(mesh.Vertices[index]) {
+				minU = float32(math.Min(float64(minU), float64(mesh.Vertices[index].UV[0])))
+				maxU = float32(math.Max(float64(maxU), float64(mesh.Vertices[index].UV[0])))
+			}
+		}
+		crossing := maxU-minU > 0.5
+		uOf := func(index uint32) float32 {
+			u := mesh.Vertices[index].UV[0]
+			if crossing && u < 0.5 {
+				return u + 1
+			}
+			return u
+		}
+		for k, index := range tri {
+			vertex := mesh.Vertices[index]
+			if isPole(vertex) {
+				u := (uOf(tri[(k+1)%3]) + uOf(tri[(k+2)%3])) / 2
+				tri[k] = mesh.addSphereVertexCopy(vertex, u)
+			} else if crossing && vertex.UV[0] < 0.5 {
+				copyIndex, ok := wrapped[index]
+				if !ok {
+					copyIndex = mesh.addSphereVertexCopy(vertex, vertex.UV[0]+1)
+					wrapped[index] = copyIndex
+				}
+				tri[k] = copyIndex
+			}
+		}
+	}
+}
+
+func (mesh *Mesh) addSphereVertexCopy(vertex Vertex, u float32) uint32 {
+	phi := float64(u) * 2 * math.Pi
+	vertex.UV[0] = u
+	vertex.Tangent = vmath.Vec4{float32(math.Cos(phi)), 0, float32(-math.Sin(phi)), 1}
+	mesh.Vertices = append(mesh.Vertices, vertex)
+	return uint32(len(mesh.Vertices) - 1)
+}
+
+func isPole(vertex Vertex) bool {
+	return vertex.Normal[0]*vertex.Normal[0]+vertex.Normal[2]*vertex.Normal[2] < 1e-6
+}
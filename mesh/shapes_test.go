@@ -0,0 +1,116 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package mesh
+
+import (
+	"github.com/vbsw/opengl-go-example/vmath"
+	"math"
+	"testing"
+)
+
+var testShapes = []struct {
+	name      string
+	mesh      *Mesh
+	triangles int
+}{
+	{"plane", Plane(2, 3, 4, 2), 16},
+	{"cube", Cube(1, 2), 48},
+	{"uv sphere", UVSphere(1, 16, 8), 16*8*2 - 32},
+	{"icosphere", Icosphere(1, 2), 20 * 16},
+	{"cylinder", Cylinder(1, 2, 12, 3, true), 12*3*2 + 24},
+	{"cone", Cone(1, 2, 12, 3, true), 12*3*2 - 12 + 12},
+	{"torus", Torus(2, 0.5, 24, 12), 24 * 12 * 2},
+	{"capsule", Capsule(0.5, 1, 16, 4, 2), 16*10*2 - 32},
+}
+
+func TestShapeTriangles(t *testing.T) {
+	for _, test := range testShapes {
+		if got := test.mesh.Triangles(); got != test.triangles {
+			t.Errorf("%s: %d triangles, want %d", test.name, got, test.triangles)
+		}
+		for _, index := range test.mesh.Indices {
+			if int(index) >= len(test.mesh.Vertices) {
+				t.Fatalf("%s: index %d out of range", test.name, index)
+			}
+		}
+	}
+}
+
+func TestShapeVertices(t *testing.T) {
+	for _, test := range testShapes {
+		for i, v := range test.mesh.Vertices {
+			if !vmath.ApproxEqual(v.Normal.Len(), 1) || !vmath.ApproxEqual(v.Tangent.Vec3().Len(), 1) {
+				t.Fatalf("%s: vertex %d: normal %v, tangent %v not normalized", test.name, i, v.Normal, v.Tangent)
+			}
+			if dot := v.Normal.Dot(v.Tangent.Vec3()); math.Abs(float64(dot)) > 1e-4 {
+				t.Fatalf("%s: vertex %d: tangent not orthogonal to normal (%f)", test.name, i, dot)
+			}
+		}
+	}
+}
+
+// TestShapeOrientation checks that triangles are counter-clockwise seen from the side
+// the normals point to and that tangents and bitangents point in direction of
+// increasing u and v.
+func TestShapeOrientation(t *testing.T) {
+	for _, test := range testShapes {
+		m := test.mesh
+		for i := 0; i < len(m.Indices); i += 3 {
+			a, b, c := m.Vertices[m.Indices[i]], m.Vertices[m.Indices[i+1]], m.Vertices[m.Indices[i+2]]
+			e1, e2 := b.Position.Sub(a.Position), c.Position.Sub(a.Position)
+			faceNormal := e1.Cross(e2)
+			if faceNormal.Len() < 1e-6 {
+				t.Fatalf("%s: triangle %d is degenerate", test.name, i/3)
+			}
+			normal := a.Normal.Add(b.Normal).Add(c.Normal)
+			if faceNormal.Dot(normal) <= 0 {
+				t.Fatalf("%s: triangle %d is clockwise", test.name, i/3)
+			}
+			duv1, duv2 := b.UV.Sub(a.UV), c.UV.Sub(a.UV)
+			det := duv1[0]*duv2[1] - duv2[0]*duv1[1]
+			if det == 0 {
+				continue
+			}
+			tangent := e1.Scale(duv2[1]).Sub(e2.Scale(duv1[1])).Scale(1 / det)
+			bitangent := e2.Scale(duv1[0]).Sub(e1.Scale(duv2[0])).Scale(1 / det)
+			vertexTangent := a.Tangent.Vec3().Add(b.Tangent.Vec3()).Add(c.Tangent.Vec3())
+			vertexBitangent := normal.Cross(vertexTangent).Scale(a.Tangent[3])
+			if tangent.Dot(vertexTangent) <= 0 || bitangent.Dot(vertexBitangent) <= 0 {
+				t.Fatalf("%s: triangle %d: tangent space does not match texture coordinates", test.name, i/3)
+			}
+		}
+	}
+}
+
+func TestTransformMirror(t *testing.T) {
+	m := Cube(1, 1)
+	m.Transform(vmath.Scale(vmath.Vec3{-1, 1, 1}))
+	for i := 0; i < len(m.Indices); i += 3 {
+		a, b, c := m.Vertices[m.Indices[i]], m.Vertices[m.Indices[i+1]], m.Vertices[m.Indices[i+2]]
+		faceNormal := b.Position.Sub(a.Position).Cross(c.Position.Sub(a.Position))
+		if faceNormal.Dot(a.Normal) <= 0 {
+			t.Fatalf("triangle %d is clockwise after mirroring", i/3)
+		}
+	}
+}
+
+func TestVertexData(t *testing.T) {
+	m := Plane(1, 1, 1, 1)
+	data := m.VertexData()
+	if len(data) != len(m.Vertices)*VertexSize {
+		t.Fatalf("%d floats, want %d", len(data), len(m.Vertices)*VertexSize)
+	}
+	v := m.Vertices[1]
+	if data[VertexSize+NormalOffset+1] != v.Normal[1] || data[VertexSize+UVOffset] != v.UV[0] || data[VertexSize+TangentOffset+3] != v.Tangent[3] {
+		t.Errorf("wrong layout: %v", data[VertexSize:2*VertexSize])
+	}
+}
+
+func BenchmarkUVSphere(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		UVSphere(1, 64, 32)
+	}
+}
@@ -0,0 +1,324 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package mesh
+
+import (
+	"github.com/vbsw/opengl-go-example/vmath"
+	"math"
+	"sort"
+)
+
+// flags of tangentTriangle
+const (
+	orientPreserving = 1 << iota
+	groupWithAny
+)
+
+// floatMin is the smallest normalized float32 (FLT_MIN).
+const floatMin = 1.17549435e-38
+
+// tangentThresholdCos is the cosine of the angle above which tangents of a vertex
+// are not averaged; MikkTSpace's default of 180 degrees averages all of them.
+const tangentThresholdCos = -1
+
+type tangentTriangle struct {
+	corners   [3]int
+	neighbors [3]int
+	groups    [3]*tangentGroup
+	os, ot    vmath.Vec3
+	flags     int
+	good      bool
+}
+
+// tangentGroup is a fan of triangles around a vertex with the same orientation.
+type tangentGroup struct {
+	vertex           int
+	orientPreserving bool
+	triangles        []int
+}
+
+type tangentEdge struct {
+	lo, hi, triangle, edge int
+}
+
+// GenerateTangents computes tangents from positions, normals and texture coordinates.
+// It is a port of MikkTSpace (Morten S. Mikkelsen) with the default angular threshold
+// of 180 degrees, i.e. the tangents are the ones baked normal maps expect. For every
+// corner the tangent of the triangle is projected into the tangent plane of the vertex
+// normal and weighted by the angle at the corner. Corners are averaged, if they share
+// position, normal and texture coordinates, have the same orientation in uv space and
+// are connected by edges. The sign of the bitangent is stored in w. The bitangent is
+// reconstructed in the shader as cross(normal, tangent) * w. Vertices are split where
+// the tangents of their corners differ. Corners without texture coordinates (zero area
+// in uv space) get the tangent (1, 0, 0, -1), like in MikkTSpace.
+func (mesh *Mesh) GenerateTangents() {
+	triangles := mesh.tangentTriangles()
+	tangentNeighbors(triangles)
+	groups := tangentGroups(triangles)
+	tangents := make([]vmath.Vec4, len(mesh.Indices))
+	for i := range tangents {
+		tangents[i] = vmath.Vec4{1, 0, 0, -1}
+	}
+	for _, group := range groups {
+		for _, f := range group.triangles {
+			k := triangles[f].corner(group.vertex)
+			tangent := mesh.groupTangent(triangles, group, triangles[f].subgroup(mesh, triangles, group))
+			w := float32(-1)
+			if group.orientPreserving {
+				w = 1
+			}
+			tangents[f*3+k] = tangent.Vec4(w)
+		}
+	}
+	// degenerate triangles take the tangent of the first good corner of the vertex
+	first := make(map[int]int)
+	for f, triangle := range triangles {
+		for k, corner := range triangle.corners {
+			if _, ok := first[corner]; triangle.good && !ok {
+				first[corner] = f*3 + k
+			}
+		}
+	}
+	for f, triangle := range triangles {
+		for k, corner := range triangle.corners {
+			if src, ok := first[corner]; !triangle.good && ok {
+				tangents[f*3+k] = tangents[src]
+			}
+		}
+	}
+	mesh.splitTangents(tangents)
+}
+
+// tangentTriangles returns the triangles with welded corners, their tangent, their
+// bitangent and their flags.
+func (mesh *Mesh) tangentTriangles() []tangentTriangle {
+	type key struct {
+		position, normal vmath.Vec3
+		uv               vmath.Vec2
+	}
+	welded := make(map[key]int)
+	triangles := make([]tangentTriangle, len(mesh.Indices)/3)
+	for f := range triangles {
+		triangle := &triangles[f]
+		for k := range triangle.corners {
+			index := int(mesh.Indices[f*3+k])
+			vertex := mesh.Vertices[index]
+			vertexKey := key{vertex.Position, vertex.Normal, vertex.UV}
+			if weldedIndex, ok := welded[vertexKey]; ok {
+				index = weldedIndex
+			} else {
+				welded[vertexKey] = index
+			}
+			triangle.corners[k] = index
+			triangle.neighbors[k] = -1
+		}
+		a, b, c := mesh.Vertices[triangle.corners[0]], mesh.Vertices[triangle.corners[1]], mesh.Vertices[triangle.corners[2]]
+		triangle.good = a.Position != b.Position && a.Position != c.Position && b.Position != c.Position
+		triangle.flags = groupWithAny
+		duv1, duv2 := b.UV.Sub(a.UV), c.UV.Sub(a.UV)
+		e1, e2 := b.Position.Sub(a.Position), c.Position.Sub(a.Position)
+		area := duv1[0]*duv2[1] - duv1[1]*duv2[0]
+		triangle.os = e1.Scale(duv2[1]).Sub(e2.Scale(duv1[1]))
+		triangle.ot = e1.Scale(-duv2[0]).Add(e2.Scale(duv1[0]))
+		if area > 0 {
+			triangle.flags |= orientPreserving
+		}
+		if notZero(area) {
+			sign := float32(1)
+			if area < 0 {
+				sign = -1
+			}
+			lenOs, lenOt := triangle.os.Len(), triangle.ot.Len()
+			if notZero(lenOs) {
+				triangle.os = triangle.os.Scale(sign / lenOs)
+			}
+			if notZero(lenOt) {
+				triangle.ot = triangle.ot.Scale(sign / lenOt)
+			}
+			if notZero(lenOs/abs(area)) && notZero(lenOt/abs(area)) {
+				triangle.flags &^= groupWithAny
+			}
+		}
+	}
+	return triangles
+}
+
+// tangentNeighbors connects good triangles sharing an edge with opposite direction.
+func tangentNeighbors(triangles []tangentTriangle) {
+	edges := make([]tangentEdge, 0, len(triangles)*3)
+	for f, triangle := range triangles {
+		for k := 0; k < 3 && triangle.good; k++ {
+			i0, i1 := triangle.corners[k], triangle.corners[(k+1)%3]
+			if i0 > i1 {
+				i0, i1 = i1, i0
+			}
+			edges = append(edges, tangentEdge{i0, i1, f, k})
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		a, b := edges[i], edges[j]
+		if a.lo != b.lo {
+			return a.lo < b.lo
+		}
+		if a.hi != b.hi {
+			return a.hi < b.hi
+		}
+		return a.triangle < b.triangle
+	})
+	for i, a := range edges {
+		triangleA := &triangles[a.triangle]
+		if triangleA.neighbors[a.edge] >= 0 {
+			continue
+		}
+		for j := i + 1; j < len(edges) && edges[j].lo == a.lo && edges[j].hi == a.hi; j++ {
+			b := edges[j]
+			triangleB := &triangles[b.triangle]
+			if triangleB.neighbors[b.edge] < 0 && triangleA.corners[a.edge] == triangleB.corners[(b.edge+1)%3] {
+				triangleA.neighbors[a.edge] = b.triangle
+				triangleB.neighbors[b.edge] = a.triangle
+				break
+			}
+		}
+	}
+}
+
+// tangentGroups returns the groups of all corners. Only triangles with texture
+// coordinates start groups, triangles without join the first group reaching them.
+func tangentGroups(triangles []tangentTriangle) []*tangentGroup {
+	groups := make([]*tangentGroup, 0, len(triangles))
+	for f := range triangles {
+		triangle := &triangles[f]
+		if !triangle.good || triangle.flags&groupWithAny != 0 {
+			continue
+		}
+		for k := range triangle.corners {
+			if triangle.groups[k] == nil {
+				group := &tangentGroup{vertex: triangle.corners[k], orientPreserving: triangle.flags&orientPreserving != 0}
+				group.triangles = append(group.triangles, f)
+				triangle.groups[k] = group
+				groups = append(groups, group)
+				if neighbor := triangle.neighbors[(k+2)%3]; neighbor >= 0 {
+					group.assign(triangles, neighbor)
+				}
+				if neighbor := triangle.neighbors[k]; neighbor >= 0 {
+					group.assign(triangles, neighbor)
+				}
+			}
+		}
+	}
+	return groups
+}
+
+// assign adds triangle f and its neighbours around the vertex of the group, if they
+// are not in another group and have the same orientation.
+func (group *tangentGroup) assign(triangles []tangentTriangle, f int) {
+	triangle := &triangles[f]
+	k := triangle.corner(group.vertex)
+	if triangle.groups[k] != nil {
+		return
+	}
+	if triangle.flags&groupWithAny != 0 && triangle.groups[0] == nil && triangle.groups[1] == nil && triangle.groups[2] == nil {
+		// the first group determines the orientation
+		triangle.flags &^= orientPreserving
+		if group.orientPreserving {
+			triangle.flags |= orientPreserving
+		}
+	}
+	if (triangle.flags&orientPreserving != 0) != group.orientPreserving {
+		return
+	}
+	group.triangles = append(group.triangles, f)
+	triangle.groups[k] = group
+	if neighbor := triangle.neighbors[k]; neighbor >= 0 {
+		group.assign(triangles, neighbor)
+	}
+	if neighbor := triangle.neighbors[(k+2)%3]; neighbor >= 0 {
+		group.assign(triangles, neighbor)
+	}
+}
+
+// corner returns the corner of the triangle at vertex.
+func (triangle *tangentTriangle) corner(vertex int) int {
+	if triangle.corners[0] == vertex {
+		return 0
+	} else if triangle.corners[1] == vertex {
+		return 1
+	}
+	return 2
+}
+
+// subgroup returns the triangles of the group, whose tangents are averaged with the
+// tangent of triangle, sorted by index.
+func (triangle *tangentTriangle) subgroup(mesh *Mesh, triangles []tangentTriangle, group *tangentGroup) []int {
+	normal := mesh.Vertices[group.vertex].Normal
+	os, ot := projectTangent(normal, triangle.os), projectTangent(normal, triangle.ot)
+	members := make([]int, 0, len(group.triangles))
+	for _, f := range group.triangles {
+		other := &triangles[f]
+		withAny := (triangle.flags|other.flags)&groupWithAny != 0
+		cosS := os.Dot(projectTangent(normal, other.os))
+		cosT := ot.Dot(projectTangent(normal, other.ot))
+		if withAny || other == triangle || cosS > tangentThresholdCos && cosT > tangentThresholdCos {
+			members = append(members, f)
+		}
+	}
+	sort.Ints(members)
+	return members
+}
+
+// groupTangent returns the average of the projected tangents of the triangles at the
+// vertex of the group, weighted by the angle of the projected corners.
+func (mesh *Mesh) groupTangent(triangles []tangentTriangle, group *tangentGroup, members []int) vmath.Vec3 {
+	var tangent vmath.Vec3
+	normal := mesh.Vertices[group.vertex].Normal
+	for _, f := range members {
+		triangle := &triangles[f]
+		if triangle.flags&groupWithAny == 0 {
+			k := triangle.corner(group.vertex)
+			p0 := mesh.Vertices[triangle.corners[(k+2)%3]].Position
+			p1 := mesh.Vertices[triangle.corners[k]].Position
+			p2 := mesh.Vertices[triangle.corners[(k+1)%3]].Position
+			v1 := projectTangent(normal, p0.Sub(p1))
+			v2 := projectTangent(normal, p2.Sub(p1))
+			angle := float32(math.Acos(float64(vmath.Clamp(v1.Dot(v2), -1, 1))))
+			tangent = tangent.Add(projectTangent(normal, triangle.os).Scale(angle))
+		}
+	}
+	return tangent.Normalize()
+}
+
+// splitTangents sets the tangents of the corners and splits vertices with more than
+// one tangent.
+func (mesh *Mesh) splitTangents(tangents []vmath.Vec4) {
+	type key struct {
+		index   uint32
+		tangent vmath.Vec4
+	}
+	newIndices := make(map[key]uint32)
+	vertices := make([]Vertex, 0, len(mesh.Vertices))
+	for corner, index := range mesh.Indices {
+		k := key{index, tangents[corner]}
+		newIndex, ok := newIndices[k]
+		if !ok {
+			vertex := mesh.Vertices[index]
+			vertex.Tangent = tangents[corner]
+			newIndex = uint32(len(vertices))
+			vertices = append(vertices, vertex)
+			newIndices[k] = newIndex
+		}
+		mesh.Indices[corner] = newIndex
+	}
+	mesh.Vertices = vertices
+}
+
+// projectTangent returns v projected into the plane orthogonal to normal, normalized.
+func projectTangent(normal, v vmath.Vec3) vmath.Vec3 {
+	return v.Sub(normal.Scale(normal.Dot(v))).Normalize()
+}
+
+func notZero(x float32) bool {
+	return x > floatMin || x < -floatMin
+}
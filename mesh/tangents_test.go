@@ -0,0 +1,120 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package mesh
+
+import (
+	"github.com/vbsw/opengl-go-example/vmath"
+	"math"
+	"testing"
+)
+
+func TestGenerateTangents(t *testing.T) {
+	for _, test := range testShapes {
+		m := &Mesh{Vertices: append([]Vertex(nil), test.mesh.Vertices...), Indices: append([]uint32(nil), test.mesh.Indices...)}
+		for i := range m.Vertices {
+			m.Vertices[i].Tangent = vmath.Vec4{}
+		}
+		m.GenerateTangents()
+		if len(m.Vertices) > len(test.mesh.Vertices) {
+			t.Errorf("%s: %d vertices, want at most %d", test.name, len(m.Vertices), len(test.mesh.Vertices))
+		}
+		// vertices are reordered, compare the corners of the triangles
+		for i, index := range m.Indices {
+			vertex := m.Vertices[index]
+			want := test.mesh.Vertices[test.mesh.Indices[i]].Tangent
+			// the tangent at a pole depends on the neighbouring triangles, only the
+			// handedness is compared; next to the poles of the icosphere the texture
+			// coordinates are distorted and the tangents deviate by about 30 degrees
+			if vertex.Tangent[3] != want[3] || !isPole(vertex) && vertex.Tangent.Vec3().Dot(want.Vec3()) < 0.85 {
+				t.Fatalf("%s: vertex %d: tangent %v, want %v", test.name, i, vertex.Tangent, want)
+			}
+			if dot := vertex.Normal.Dot(vertex.Tangent.Vec3()); abs(dot) > 1e-4 {
+				t.Fatalf("%s: vertex %d: tangent not orthogonal to normal (%f)", test.name, i, dot)
+			}
+			if !vmath.ApproxEqual(vertex.Tangent.Vec3().Len(), 1) {
+				t.Fatalf("%s: vertex %d: tangent %v not normalized", test.name, i, vertex.Tangent)
+			}
+		}
+	}
+}
+
+func TestGenerateTangentsMirrored(t *testing.T) {
+	// two triangles sharing an edge, the right one with mirrored u
+	m := &Mesh{
+		Vertices: []Vertex{
+			{Position: vmath.Vec3{-1, 0, 0}, UV: vmath.Vec2{1, 0}},
+			{Position: vmath.Vec3{0, 0, 0}, UV: vmath.Vec2{0, 0}},
+			{Position: vmath.Vec3{0, 1, 0}, UV: vmath.Vec2{0, 1}},
+			{Position: vmath.Vec3{1, 0, 0}, UV: vmath.Vec2{1, 0}},
+		},
+		Indices: []uint32{0, 1, 2, 1, 3, 2},
+	}
+	for i := range m.Vertices {
+		m.Vertices[i].Normal = vmath.Vec3{0, 0, 1}
+	}
+	m.GenerateTangents()
+	// shared vertices 1 and 2 are split
+	if len(m.Vertices) != 6 {
+		t.Fatalf("%d vertices, want 6", len(m.Vertices))
+	}
+	left, right := m.Vertices[m.Indices[0]], m.Vertices[m.Indices[4]]
+	if !left.Tangent.ApproxEqual(vmath.Vec4{-1, 0, 0, -1}) || !right.Tangent.ApproxEqual(vmath.Vec4{1, 0, 0, 1}) {
+		t.Errorf("tangents %v, %v", left.Tangent, right.Tangent)
+	}
+}
+
+func TestGenerateTangentsReference(t *testing.T) {
+	// MikkTSpace projects the tangent of every triangle into the tangent plane of the
+	// vertex normal before it is weighted by the projected corner angle
+	c, s := float32(math.Cos(math.Pi/8)), float32(math.Sin(math.Pi/8))
+	r := float32(math.Sqrt2 / 2)
+	tests := []struct {
+		name     string
+		vertices []Vertex
+		indices  []uint32
+		want     []vmath.Vec4
+	}{
+		{
+			// parallelogram with normals tilted by 45 degrees around y
+			name: "sheared quad",
+			vertices: []Vertex{
+				{Position: vmath.Vec3{0, 0, 0}, Normal: vmath.Vec3{r, 0, r}, UV: vmath.Vec2{0, 0}},
+				{Position: vmath.Vec3{1, 0, 0}, Normal: vmath.Vec3{r, 0, r}, UV: vmath.Vec2{1, 0}},
+				{Position: vmath.Vec3{1.5, 1, 0}, Normal: vmath.Vec3{r, 0, r}, UV: vmath.Vec2{1, 1}},
+				{Position: vmath.Vec3{0.5, 1, 0}, Normal: vmath.Vec3{r, 0, r}, UV: vmath.Vec2{0, 1}},
+			},
+			indices: []uint32{0, 1, 2, 0, 2, 3},
+			want:    []vmath.Vec4{{r, 0, -r, 1}, {r, 0, -r, 1}, {r, 0, -r, 1}, {r, 0, -r, 1}},
+		},
+		{
+			// two bent triangles with tangents (1, 0, 1) and (1, -1, -1); projected
+			// they are 45 degrees apart and the projected corner angles are equal,
+			// so the shared vertices get the tangent at -22.5 degrees
+			name: "bent fan",
+			vertices: []Vertex{
+				{Position: vmath.Vec3{0, 0, 0}, Normal: vmath.Vec3{0, 0, 1}, UV: vmath.Vec2{0, 0}},
+				{Position: vmath.Vec3{1, 0, 1}, Normal: vmath.Vec3{0, 0, 1}, UV: vmath.Vec2{1, 0}},
+				{Position: vmath.Vec3{0, 1, 1}, Normal: vmath.Vec3{0, 0, 1}, UV: vmath.Vec2{0, 1}},
+				{Position: vmath.Vec3{-1, 0, 0}, Normal: vmath.Vec3{0, 0, 1}, UV: vmath.Vec2{-1, -1}},
+			},
+			indices: []uint32{0, 1, 2, 0, 2, 3},
+			want:    []vmath.Vec4{{c, -s, 0, 1}, {1, 0, 0, 1}, {c, -s, 0, 1}, {r, -r, 0, 1}},
+		},
+	}
+	for _, test := range tests {
+		m := &Mesh{Vertices: test.vertices, Indices: test.indices}
+		m.GenerateTangents()
+		if len(m.Vertices) != len(test.want) {
+			t.Errorf("%s: %d vertices, want %d", test.name, len(m.Vertices), len(test.want))
+			continue
+		}
+		for i, vertex := range m.Vertices {
+			if !vertex.Tangent.ApproxEqual(test.want[i]) {
+				t.Errorf("%s: vertex %d: tangent %v, want %v", test.name, i, vertex.Tangent, test.want[i])
+			}
+		}
+	}
+}
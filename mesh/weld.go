@@ -0,0 +1,115 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package mesh
+
+import (
+	"github.com/vbsw/opengl-go-example/vmath"
+	"math"
+)
+
+// Weld merges vertices, whose attributes (position, normal, uv and tangent) differ by
+// at most epsilon per component, and removes triangles, that become degenerate. With
+// epsilon 0 only identical vertices are merged. Unused vertices are removed.
+func (mesh *Mesh) Weld(epsilon float32) {
+	remap := make([]uint32, len(mesh.Vertices))
+	vertices := make([]Vertex, 0, len(mesh.Vertices))
+	if epsilon <= 0 {
+		unique := make(map[Vertex]uint32)
+		for i, vertex := range mesh.Vertices {
+			index, ok := unique[vertex]
+			if !ok {
+				index = uint32(len(vertices))
+				vertices = append(vertices, vertex)
+				unique[vertex] = index
+			}
+			remap[i] = index
+		}
+	} else {
+		// vertices in a grid of cells with size epsilon, neighbouring cells are searched, too
+		cells := make(map[[3]int32][]uint32)
+		for i, vertex := range mesh.Vertices {
+			cell := cellOf(vertex.Position, epsilon)
+			index, ok := findVertex(vertices, cells, cell, vertex, epsilon)
+			if !ok {
+				index = uint32(len(vertices))
+				vertices = append(vertices, vertex)
+				cells[cell] = append(cells[cell], index)
+			}
+			remap[i] = index
+		}
+	}
+	indices := mesh.Indices[:0]
+	for i := 0; i+2 < len(mesh.Indices); i += 3 {
+		a, b, c := remap[mesh.Indices[i]], remap[mesh.Indices[i+1]], remap[mesh.Indices[i+2]]
+		if a != b && b != c && c != a {
+			indices = append(indices, a, b, c)
+		}
+	}
+	mesh.Vertices = vertices
+	mesh.Indices = indices
+	mesh.RemoveUnused()
+}
+
+// RemoveUnused removes vertices not referenced by any triangle.
+func (mesh *Mesh) RemoveUnused() {
+	remap := make([]int64, len(mesh.Vertices))
+	for i := range remap {
+		remap[i] = -1
+	}
+	vertices := make([]Vertex, 0, len(mesh.Vertices))
+	for i, index := range mesh.Indices {
+		if remap[index] < 0 {
+			remap[index] = int64(len(vertices))
+			vertices = append(vertices, mesh.Vertices[index])
+		}
+		mesh.Indices[i] = uint32(remap[index])
+	}
+	mesh.Vertices = vertices
+}
+
+func cellOf(position vmath.Vec3, size float32) [3]int32 {
+	var cell [3]int32
+	for i, value := range position {
+		cell[i] = int32(math.Floor(float64(value / size)))
+	}
+	return cell
+}
+
+func findVertex(vertices []Vertex, cells map[[3]int32][]uint32, cell [3]int32, vertex Vertex, epsilon float32) (uint32, bool) {
+	for x := int32(-1); x <= 1; x++ {
+		for y := int32(-1); y <= 1; y++ {
+			for z := int32(-1); z <= 1; z++ {
+				for _, index := range cells[[3]int32{cell[0] + x, cell[1] + y, cell[2] + z}] {
+					if verticesEqual(vertices[index], vertex, epsilon) {
+						return index, true
+					}
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
+func verticesEqual(a, b Vertex, epsilon float32) bool {
+	for i := 0; i < 3; i++ {
+		if abs(a.Position[i]-b.Position[i]) > epsilon || abs(a.Normal[i]-b.Normal[i]) > epsilon {
+			return false
+		}
+	}
+	for i := 0; i < 4; i++ {
+		if abs(a.Tangent[i]-b.Tangent[i]) > epsilon {
+			return false
+		}
+	}
+	return abs(a.UV[0]-b.UV[0]) <= epsilon && abs(a.UV[1]-b.UV[1]) <= epsilon
+}
+
+func abs(value float32) float32 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,55 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package mesh
+
+import (
+	"github.com/vbsw/opengl-go-example/vmath"
+	"testing"
+)
+
+func TestWeld(t *testing.T) {
+	tests := []struct {
+		name      string
+		offset    float32
+		epsilon   float32
+		vertices  int
+		triangles int
+	}{
+		{"identical", 0, 0, 4, 2},
+		{"within epsilon", 1e-4, 1e-3, 4, 2},
+		{"outside epsilon", 1e-2, 1e-3, 5, 2},
+	}
+	for _, test := range tests {
+		// two triangles with separate vertices and one degenerate triangle
+		m := &Mesh{
+			Vertices: []Vertex{
+				{Position: vmath.Vec3{0, 0, 0}}, {Position: vmath.Vec3{1, 0, 0}}, {Position: vmath.Vec3{1, 1, 0}},
+				{Position: vmath.Vec3{0, 0, 0}}, {Position: vmath.Vec3{1, 1, test.offset}}, {Position: vmath.Vec3{0, 1, test.offset}},
+				{Position: vmath.Vec3{5, 5, 5}},
+			},
+			Indices: []uint32{0, 1, 2, 3, 4, 5, 0, 3, 6},
+		}
+		m.Weld(test.epsilon)
+		if len(m.Vertices) != test.vertices || m.Triangles() != test.triangles {
+			t.Errorf("%s: %d vertices and %d triangles, want %d and %d", test.name, len(m.Vertices), m.Triangles(), test.vertices, test.triangles)
+		}
+	}
+}
+
+func TestWeldKeepsSeams(t *testing.T) {
+	m := UVSphere(1, 16, 8)
+	vertices := len(m.Vertices)
+	m.Weld(1e-5)
+	// pole and seam vertices differ in uv or tangent, only the unused last vertex
+	// of both pole rows is removed
+	if len(m.Vertices) != vertices-2 {
+		t.Errorf("%d vertices, want %d", len(m.Vertices), vertices-2)
+	}
+	p := positionsOnly(m)
+	if want := 16*7 + 2; len(p.Vertices) != want {
+		t.Errorf("positions only: %d vertices, want %d", len(p.Vertices), want)
+	}
+}
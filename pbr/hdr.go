@@ -0,0 +1,198 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package pbr
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	// register decoders for LoadHDR
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HDR is an image with linear RGB float colors, e.g. an equirectangular environment
+// map. Rows are ordered from top to bottom.
+type HDR struct {
+	Width, Height int
+	// r, g, b per pixel
+	Pix []float32
+}
+
+// LoadHDR reads a Radiance HDR file (.hdr) or a PNG or JPEG image, whose sRGB colors
+// are converted to linear colors.
+func LoadHDR(path string) (*HDR, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var hdr *HDR
+	if strings.EqualFold(filepath.Ext(path), ".hdr") {
+		hdr, err = DecodeHDR(file)
+	} else {
+		var img image.Image
+		img, _, err = image.Decode(file)
+		if err == nil {
+			hdr = NewHDRFromImage(img)
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("%s: %v", path, err)
+	}
+	return hdr, err
+}
+
+// NewHDRFromImage converts the sRGB colors of img to linear colors.
+func NewHDRFromImage(img image.Image) *HDR {
+	bounds := img.Bounds()
+	hdr := &HDR{Width: bounds.Dx(), Height: bounds.Dy(), Pix: make([]float32, bounds.Dx()*bounds.Dy()*3)}
+	i := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			hdr.Pix[i] = SRGBToLinear(float32(r) / 0xffff)
+			hdr.Pix[i+1] = SRGBToLinear(float32(g) / 0xffff)
+			hdr.Pix[i+2] = SRGBToLinear(float32(b) / 0xffff)
+			i += 3
+		}
+	}
+	return hdr
+}
+
+// DecodeHDR reads a Radiance RGBE image with flat or run-length encoded scanlines.
+// Only the standard orientation (-Y height +X width) is supported.
+func DecodeHDR(reader io.Reader) (*HDR, error) {
+	buffered := bufio.NewReader(reader)
+	width, height, err := readHDRHeader(buffered)
+	if err != nil {
+		return nil, err
+	}
+	hdr := &HDR{Width: width, Height: height, Pix: make([]float32, width*height*3)}
+	scanline := make([]byte, width*4)
+	for y := 0; y < height && err == nil; y++ {
+		err = readScanline(buffered, scanline)
+		for x := 0; x < width && err == nil; x++ {
+			r, g, b := rgbeToFloat(scanline[x*4:])
+			hdr.Pix[(y*width+x)*3] = r
+			hdr.Pix[(y*width+x)*3+1] = g
+			hdr.Pix[(y*width+x)*3+2] = b
+		}
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = errors.New("hdr: unexpected end of data")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return hdr, nil
+}
+
+// SRGBToLinear converts an sRGB color channel (0 to 1) to linear.
+func SRGBToLinear(c float32) float32 {
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return float32(math.Pow((float64(c)+0.055)/1.055, 2.4))
+}
+
+func readHDRHeader(reader *bufio.Reader) (int, int, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "#?") {
+		return 0, 0, errors.New("hdr: not a Radiance file")
+	}
+	for err == nil {
+		line, err = reader.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "FORMAT=") && line != "FORMAT=32-bit_rle_rgbe" {
+			return 0, 0, fmt.Errorf("hdr: unsupported %s", line)
+		} else if len(line) == 0 {
+			break
+		}
+	}
+	var width, height int
+	if err == nil {
+		line, err = reader.ReadString('\n')
+		if err == nil {
+			_, err = fmt.Sscanf(line, "-Y %d +X %d", &height, &width)
+			if err != nil {
+				err = fmt.Errorf("hdr: unsupported resolution %q", strings.TrimSpace(line))
+			}
+		}
+	}
+	if err == nil && (width <= 0 || height <= 0) {
+		err = errors.New("hdr: empty image")
+	}
+	return width, height, err
+}
+
+// readScanline reads width * 4 bytes of RGBE. Run-length encoded scanlines start with
+// 2, 2 and the width and store each channel separately.
+func readScanline(reader *bufio.Reader, scanline []byte) error {
+	width := len(scanline) / 4
+	start, err := reader.Peek(4)
+	if err != nil {
+		return err
+	}
+	if width < 8 || width > 0x7fff || start[0] != 2 || start[1] != 2 || start[2]&0x80 != 0 {
+		_, err = io.ReadFull(reader, scanline)
+		return err
+	}
+	if int(start[2])<<8|int(start[3]) != width {
+		return errors.New("hdr: wrong scanline width")
+	}
+	reader.Discard(4)
+	for channel := 0; channel < 4; channel++ {
+		for x := 0; x < width; {
+			count, err := reader.ReadByte()
+			if err != nil {
+				return err
+			}
+			if count > 128 {
+				n := int(count) - 128
+				value, err := reader.ReadByte()
+				if err != nil {
+					return err
+				}
+				if x+n > width {
+					return errors.New("hdr: run exceeds scanline")
+				}
+				for ; n > 0; n-- {
+					scanline[x*4+channel] = value
+					x++
+				}
+			} else {
+				n := int(count)
+				if n == 0 || x+n > width {
+					return errors.New("hdr: bad run length")
+				}
+				for ; n > 0; n-- {
+					value, err := reader.ReadByte()
+					if err != nil {
+						return err
+					}
+					scanline[x*4+channel] = value
+					x++
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func rgbeToFloat(rgbe []byte) (float32, float32, float32) {
+	if rgbe[3] == 0 {
+		return 0, 0, 0
+	}
+	f := float32(math.Ldexp(1, int(rgbe[3])-(128+8)))
+	return float32(rgbe[0]) * f, float32(rgbe[1]) * f, float32(rgbe[2]) * f
+}
@@ -0,0 +1,99 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package pbr
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+const hdrHeader = "#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n"
+
+func TestDecodeHDR(t *testing.T) {
+	// 8 pixels: the first is (1, 0.5, 0.25) with exponent 129, the rest (2, 2, 2)
+	flat := []byte{128, 64, 32, 129}
+	for i := 1; i < 8; i++ {
+		flat = append(flat, 128, 128, 128, 130)
+	}
+	rle := []byte{2, 2, 0, 8}
+	rle = append(rle, 1, 128, 128+7, 128) // red: 1 literal, run of 7
+	rle = append(rle, 1, 64, 128+7, 128)  // green
+	rle = append(rle, 1, 32, 128+7, 128)  // blue
+	rle = append(rle, 1, 129, 128+7, 130) // exponent
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"flat", append([]byte(hdrHeader+"-Y 1 +X 8\n"), flat...)},
+		{"rle", append([]byte(hdrHeader+"-Y 1 +X 8\n"), rle...)},
+	}
+	for _, test := range tests {
+		hdr, err := DecodeHDR(bytes.NewReader(test.data))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if hdr.Width != 8 || hdr.Height != 1 || len(hdr.Pix) != 24 {
+			t.Errorf("%s: got %dx%d with %d values", test.name, hdr.Width, hdr.Height, len(hdr.Pix))
+			continue
+		}
+		want := []float32{1, 0.5, 0.25, 2, 2, 2}
+		for i, value := range want {
+			if hdr.Pix[i] != value {
+				t.Errorf("%s: value %d is %v, want %v", test.name, i, hdr.Pix[i], value)
+			}
+		}
+	}
+}
+
+func TestDecodeHDRErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"no magic", "P6\n"},
+		{"format", "#?RADIANCE\nFORMAT=32-bit_rle_xyze\n\n-Y 1 +X 1\n"},
+		{"orientation", hdrHeader + "+Y 1 +X 1\n"},
+		{"truncated", hdrHeader + "-Y 2 +X 1\n\x80\x80\x80\x81"},
+		{"bad run", hdrHeader + "-Y 1 +X 8\n\x02\x02\x00\x08\x00"},
+	}
+	for _, test := range tests {
+		if _, err := DecodeHDR(bytes.NewReader([]byte(test.data))); err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+}
+
+func TestNewHDRFromImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.RGBA{255, 0, 0, 255})
+	img.Set(1, 0, color.RGBA{188, 188, 188, 255})
+	hdr := NewHDRFromImage(img)
+	if hdr.Pix[0] != 1 || hdr.Pix[1] != 0 {
+		t.Errorf("red: %v", hdr.Pix[:3])
+	}
+	if gray := hdr.Pix[3]; gray < 0.49 || gray > 0.51 {
+		t.Errorf("sRGB 188 must be about 0.5 linear, got %v", gray)
+	}
+}
+
+func BenchmarkDecodeHDR(b *testing.B) {
+	data := []byte(hdrHeader + "-Y 64 +X 256\n")
+	for y := 0; y < 64; y++ {
+		data = append(data, 2, 2, 1, 0)
+		for channel := 0; channel < 4; channel++ {
+			for x := 0; x < 256; x += 128 {
+				data = append(data, 128+127, byte(100+channel), 1, byte(y))
+			}
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		DecodeHDR(bytes.NewReader(data))
+	}
+}
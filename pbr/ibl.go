@@ -0,0 +1,412 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package pbr
+
+import (
+	"errors"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/vbsw/opengl-go-example/gfx"
+	"github.com/vbsw/opengl-go-example/vmath"
+)
+
+// Sizes of the maps precomputed by NewEnvironment.
+const (
+	IrradianceSize  = 32
+	PrefilteredSize = 128
+	// mipmap levels of the prefiltered map, roughness 0 to 1
+	PrefilteredLevels = 5
+	BRDFSize          = 512
+)
+
+// cubeShader maps texture coordinates of the fullscreen triangle to a direction
+// on cube map face (gl.TEXTURE_CUBE_MAP_POSITIVE_X + face).
+const cubeShader = `
+in vec2 texCoords;
+uniform int face;
+out vec4 color;
+
+const float PI = 3.14159265359;
+
+vec3 cubeDirection() {
+	vec2 st = texCoords * 2.0 - 1.0;
+	vec3 direction;
+	if (face == 0) {
+		direction = vec3(1.0, -st.y, -st.x);
+	} else if (face == 1) {
+		direction = vec3(-1.0, -st.y, st.x);
+	} else if (face == 2) {
+		direction = vec3(st.x, 1.0, st.y);
+	} else if (face == 3) {
+		direction = vec3(st.x, -1.0, -st.y);
+	} else if (face == 4) {
+		direction = vec3(st.x, -st.y, 1.0);
+	} else {
+		direction = vec3(-st.x, -st.y, -1.0);
+	}
+	return normalize(direction);
+}
+`
+
+// equirectShader samples an equirectangular image (top row is up).
+const equirectShader = `
+uniform sampler2D equirect;
+
+void main() {
+	vec3 d = cubeDirection();
+	vec2 uv = vec2(atan(d.z, d.x) / (2.0 * PI) + 0.5, 0.5 - asin(clamp(d.y, -1.0, 1.0)) / PI);
+	color = vec4(texture(equirect, uv).rgb, 1.0);
+}
+`
+
+// skyShader is a procedural sky with a sun, used without environment image.
+const skyShader = `
+uniform vec3 sunDirection;
+
+void main() {
+	vec3 d = cubeDirection();
+	vec3 horizon = vec3(1.2, 1.1, 1.0);
+	vec3 zenith = vec3(0.15, 0.35, 0.9);
+	vec3 ground = vec3(0.25, 0.22, 0.2);
+	vec3 sky = mix(horizon, zenith, pow(max(d.y, 0.0), 0.5));
+	vec3 result = mix(ground, sky, smoothstep(-0.02, 0.02, d.y));
+	float sun = dot(d, -sunDirection);
+	result += vec3(1.0, 0.9, 0.7) * (pow(max(sun, 0.0), 8.0) * 0.5 + smoothstep(0.9995, 0.9998, sun) * 200.0);
+	color = vec4(result, 1.0);
+}
+`
+
+// irradianceShader convolves the environment with the cosine over the hemisphere.
+const irradianceShader = `
+uniform samplerCube environment;
+
+void main() {
+	vec3 normal = cubeDirection();
+	vec3 up = abs(normal.y) < 0.999 ? vec3(0.0, 1.0, 0.0) : vec3(1.0, 0.0, 0.0);
+	vec3 right = normalize(cross(up, normal));
+	up = cross(normal, right);
+	vec3 sum = vec3(0.0);
+	float count = 0.0;
+	const float delta = 0.025;
+	for (float phi = 0.0; phi < 2.0 * PI; phi += delta) {
+		for (float theta = 0.0; theta < 0.5 * PI; theta += delta) {
+			vec3 tangent = vec3(sin(theta) * cos(phi), sin(theta) * sin(phi), cos(theta));
+			vec3 direction = tangent.x * right + tangent.y * up + tangent.z * normal;
+			sum += textureLod(environment, direction, 3.0).rgb * cos(theta) * sin(theta);
+			count++;
+		}
+	}
+	color = vec4(PI * sum / count, 1.0);
+}
+`
+
+// samplingShader generates GGX distributed half vectors from the Hammersley
+// sequence.
+const samplingShader = `
+float radicalInverse(uint bits) {
+	bits = (bits << 16u) | (bits >> 16u);
+	bits = ((bits & 0x55555555u) << 1u) | ((bits & 0xAAAAAAAAu) >> 1u);
+	bits = ((bits & 0x33333333u) << 2u) | ((bits & 0xCCCCCCCCu) >> 2u);
+	bits = ((bits & 0x0F0F0F0Fu) << 4u) | ((bits & 0xF0F0F0F0u) >> 4u);
+	bits = ((bits & 0x00FF00FFu) << 8u) | ((bits & 0xFF00FF00u) >> 8u);
+	return float(bits) * 2.3283064365386963e-10;
+}
+
+vec3 importanceSampleGGX(uint i, uint count, vec3 normal, float roughness) {
+	float a = roughness * roughness;
+	float phi = 2.0 * PI * float(i) / float(count);
+	float cosTheta = sqrt((1.0 - radicalInverse(i)) / (1.0 + (a * a - 1.0) * radicalInverse(i)));
+	float sinTheta = sqrt(1.0 - cosTheta * cosTheta);
+	vec3 h = vec3(cos(phi) * sinTheta, sin(phi) * sinTheta, cosTheta);
+	vec3 up = abs(normal.z) < 0.999 ? vec3(0.0, 0.0, 1.0) : vec3(1.0, 0.0, 0.0);
+	vec3 tangent = normalize(cross(up, normal));
+	vec3 bitangent = cross(normal, tangent);
+	return normalize(tangent * h.x + bitangent * h.y + normal * h.z);
+}
+`
+
+// prefilterShader convolves the environment with the GGX distribution of
+// roughness. Samples are taken from mipmap levels of the environment matching their
+// probability to avoid bright dots.
+const prefilterShader = `
+uniform samplerCube environment;
+uniform float roughness;
+// size of the environment in texels
+uniform float environmentSize;
+
+void main() {
+	const uint count = 512u;
+	vec3 normal = cubeDirection();
+	vec3 sum = vec3(0.0);
+	float weight = 0.0;
+	for (uint i = 0u; i < count; i++) {
+		vec3 h = importanceSampleGGX(i, count, normal, roughness);
+		vec3 l = normalize(2.0 * dot(normal, h) * h - normal);
+		float NdotL = dot(normal, l);
+		if (NdotL > 0.0) {
+			float NdotH = max(dot(normal, h), 0.0);
+			float a2 = roughness * roughness * roughness * roughness;
+			float d = NdotH * NdotH * (a2 - 1.0) + 1.0;
+			float pdf = a2 / (PI * d * d) / 4.0 + 0.0001;
+			float texelAngle = 4.0 * PI / (6.0 * environmentSize * environmentSize);
+			float sampleAngle = 1.0 / (float(count) * pdf + 0.0001);
+			float level = roughness == 0.0 ? 0.0 : 0.5 * log2(sampleAngle / texelAngle);
+			sum += textureLod(environment, l, level).rgb * NdotL;
+			weight += NdotL;
+		}
+	}
+	color = vec4(sum / weight, 1.0);
+}
+`
+
+// brdfShader integrates the specular BRDF for NdotV (x) and roughness (y) into a
+// scale (red) and bias (green) of F0.
+const brdfShader = `
+in vec2 texCoords;
+out vec4 color;
+
+const float PI = 3.14159265359;
+` + samplingShader + `
+void main() {
+	const uint count = 1024u;
+	float NdotV = max(texCoords.x, 1e-4);
+	float roughness = texCoords.y;
+	vec3 v = vec3(sqrt(1.0 - NdotV * NdotV), 0.0, NdotV);
+	vec3 normal = vec3(0.0, 0.0, 1.0);
+	float k = roughness * roughness / 2.0;
+	vec2 result = vec2(0.0);
+	for (uint i = 0u; i < count; i++) {
+		vec3 h = importanceSampleGGX(i, count, normal, roughness);
+		vec3 l = normalize(2.0 * dot(v, h) * h - v);
+		float NdotL = max(l.z, 0.0);
+		if (NdotL > 0.0) {
+			float NdotH = max(h.z, 0.0);
+			float VdotH = max(dot(v, h), 0.0);
+			float g = NdotV / (NdotV * (1.0 - k) + k) * NdotL / (NdotL * (1.0 - k) + k);
+			float visibility = g * VdotH / (NdotH * NdotV);
+			float fresnel = pow(1.0 - VdotH, 5.0);
+			result += vec2((1.0 - fresnel) * visibility, fresnel * visibility);
+		}
+	}
+	color = vec4(result / float(count), 0.0, 1.0);
+}
+`
+
+// skyboxShader shows a cube map behind everything. The view must not translate.
+const skyboxShader = `#version 130
+
+in vec2 texCoords;
+uniform samplerCube cubemap;
+uniform mat4 inverseViewProjection;
+uniform float lod;
+out vec4 color;
+
+void main() {
+	vec4 far = inverseViewProjection * vec4(texCoords * 2.0 - 1.0, 1.0, 1.0);
+	color = vec4(textureLod(cubemap, far.xyz / far.w, lod).rgb, 1.0);
+}
+`
+
+// Environment is the image based lighting of an environment: the environment cube
+// map, the irradiance for diffuse lighting, the radiance prefiltered for specular
+// lighting of increasing roughness in its mipmap levels and the lookup table of the
+// split sum approximation of the specular BRDF.
+type Environment struct {
+	// environment with mipmaps
+	Cubemap    uint32
+	Irradiance uint32
+	// PrefilteredLevels levels of roughness 0 to 1
+	Prefiltered       uint32
+	PrefilteredLevels int
+	// scale and bias of F0 by NdotV and roughness
+	BRDF     uint32
+	Size     int
+	skybox   uint32
+	cubemap  int32
+	inverse  int32
+	lod      int32
+	triangle *gfx.FullscreenTriangle
+}
+
+// generator renders the fullscreen triangle into textures.
+type generator struct {
+	framebuffer uint32
+	triangle    *gfx.FullscreenTriangle
+}
+
+// NewEnvironment converts the equirectangular image hdr to a cube map of size and
+// precomputes the maps for image based lighting on the GPU. If hdr is nil, a
+// procedural sky with a sun in sunDirection is used.
+func NewEnvironment(hdr *HDR, size int, sunDirection vmath.Vec3) (*Environment, error) {
+	environment := &Environment{Size: size, PrefilteredLevels: PrefilteredLevels, triangle: gfx.NewFullscreenTriangle()}
+	var err error
+	environment.skybox, err = gfx.NewProgram(gfx.FullscreenVertexShader, skyboxShader)
+	if err == nil {
+		environment.cubemap = gfx.UniformLocation(environment.skybox, "cubemap")
+		environment.inverse = gfx.UniformLocation(environment.skybox, "inverseViewProjection")
+		environment.lod = gfx.UniformLocation(environment.skybox, "lod")
+		gen := &generator{triangle: environment.triangle}
+		gl.GenFramebuffers(1, &gen.framebuffer)
+		gl.Disable(gl.DEPTH_TEST)
+		gl.Disable(gl.BLEND)
+		gl.Enable(gl.TEXTURE_CUBE_MAP_SEAMLESS)
+		err = environment.generate(gen, hdr, sunDirection)
+		gl.DeleteFramebuffers(1, &gen.framebuffer)
+		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	}
+	if err != nil {
+		environment.Delete()
+		return nil, err
+	}
+	return environment, nil
+}
+
+// DrawSkybox draws the mipmap level lod of cubemap (e.g. Cubemap or Prefiltered)
+// into the whole viewport. Depth test should be disabled.
+func (environment *Environment) DrawSkybox(cubemap uint32, projection, view vmath.Mat4, lod float32) {
+	// only rotation
+	view[12], view[13], view[14] = 0, 0, 0
+	inverse, _ := projection.Mul(view).Inverse()
+	gl.UseProgram(environment.skybox)
+	gl.UniformMatrix4fv(environment.inverse, 1, false, inverse.Ptr())
+	gl.Uniform1f(environment.lod, lod)
+	gl.Uniform1i(environment.cubemap, 0)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, cubemap)
+	environment.triangle.Draw()
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, 0)
+}
+
+// Delete deletes the textures and programs.
+func (environment *Environment) Delete() {
+	textures := []uint32{environment.Cubemap, environment.Irradiance, environment.Prefiltered, environment.BRDF}
+	gl.DeleteTextures(int32(len(textures)), &textures[0])
+	gl.DeleteProgram(environment.skybox)
+	environment.triangle.Delete()
+}
+
+func (environment *Environment) generate(gen *generator, hdr *HDR, sunDirection vmath.Vec3) error {
+	gl.ActiveTexture(gl.TEXTURE0)
+	programs := make([]uint32, 0, 5)
+	defer func() {
+		for _, program := range programs {
+			gl.DeleteProgram(program)
+		}
+	}()
+	sources := []string{equirectShader, skyShader, irradianceShader, samplingShader + prefilterShader}
+	for _, source := range sources {
+		program, err := gfx.NewProgram(gfx.FullscreenVertexShader, "#version 130\n"+cubeShader+source)
+		if err != nil {
+			return err
+		}
+		programs = append(programs, program)
+	}
+	program, err := gfx.NewProgram(gfx.FullscreenVertexShader, "#version 130\n"+brdfShader)
+	if err != nil {
+		return err
+	}
+	programs = append(programs, program)
+
+	environment.Cubemap = newCubemap(environment.Size, true)
+	if hdr != nil {
+		if len(hdr.Pix) != hdr.Width*hdr.Height*3 || hdr.Width == 0 {
+			return errors.New("pbr: invalid environment image")
+		}
+		var equirect uint32
+		gl.GenTextures(1, &equirect)
+		gl.BindTexture(gl.TEXTURE_2D, equirect)
+		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGB16F, int32(hdr.Width), int32(hdr.Height), 0, gl.RGB, gl.FLOAT, gl.Ptr(hdr.Pix))
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+		gl.UseProgram(programs[0])
+		gl.Uniform1i(gfx.UniformLocation(programs[0], "equirect"), 0)
+		gen.renderCube(programs[0], environment.Cubemap, environment.Size, 0)
+		gl.DeleteTextures(1, &equirect)
+	} else {
+		direction := sunDirection.Normalize()
+		gl.UseProgram(programs[1])
+		gl.Uniform3f(gfx.UniformLocation(programs[1], "sunDirection"), direction[0], direction[1], direction[2])
+		gen.renderCube(programs[1], environment.Cubemap, environment.Size, 0)
+	}
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, environment.Cubemap)
+	gl.GenerateMipmap(gl.TEXTURE_CUBE_MAP)
+
+	environment.Irradiance = newCubemap(IrradianceSize, false)
+	gl.UseProgram(programs[2])
+	gl.Uniform1i(gfx.UniformLocation(programs[2], "environment"), 0)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, environment.Cubemap)
+	gen.renderCube(programs[2], environment.Irradiance, IrradianceSize, 0)
+
+	environment.Prefiltered = newCubemap(PrefilteredSize, true)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, environment.Prefiltered)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAX_LEVEL, PrefilteredLevels-1)
+	gl.UseProgram(programs[3])
+	gl.Uniform1i(gfx.UniformLocation(programs[3], "environment"), 0)
+	gl.Uniform1f(gfx.UniformLocation(programs[3], "environmentSize"), float32(environment.Size))
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, environment.Cubemap)
+	for level := 0; level < PrefilteredLevels; level++ {
+		roughness := float32(level) / float32(PrefilteredLevels-1)
+		gl.Uniform1f(gfx.UniformLocation(programs[3], "roughness"), roughness)
+		gen.renderCube(programs[3], environment.Prefiltered, PrefilteredSize, level)
+	}
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, 0)
+
+	gl.GenTextures(1, &environment.BRDF)
+	gl.BindTexture(gl.TEXTURE_2D, environment.BRDF)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RG16F, BRDFSize, BRDFSize, 0, gl.RG, gl.FLOAT, nil)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, gen.framebuffer)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, environment.BRDF, 0)
+	gl.Viewport(0, 0, BRDFSize, BRDFSize)
+	gl.UseProgram(programs[4])
+	gen.triangle.Draw()
+	gl.UseProgram(0)
+	return nil
+}
+
+// renderCube renders program into the six faces of mipmap level of texture. The
+// uniform face is set to the index of the face.
+func (gen *generator) renderCube(program, texture uint32, size, level int) {
+	faceLocation := gfx.UniformLocation(program, "face")
+	levelSize := int32(size >> uint(level))
+	gl.BindFramebuffer(gl.FRAMEBUFFER, gen.framebuffer)
+	gl.Viewport(0, 0, levelSize, levelSize)
+	gl.UseProgram(program)
+	for face := uint32(0); face < 6; face++ {
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_CUBE_MAP_POSITIVE_X+face, texture, int32(level))
+		gl.Uniform1i(faceLocation, int32(face))
+		gen.triangle.Draw()
+	}
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+}
+
+// newCubemap returns a new RGB16F cube map. With mipmaps all levels are allocated
+// and filtered trilinear.
+func newCubemap(size int, mipmaps bool) uint32 {
+	var texture uint32
+	gl.GenTextures(1, &texture)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, texture)
+	for face := uint32(0); face < 6; face++ {
+		gl.TexImage2D(gl.TEXTURE_CUBE_MAP_POSITIVE_X+face, 0, gl.RGB16F, int32(size), int32(size), 0, gl.RGB, gl.FLOAT, nil)
+	}
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_R, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	if mipmaps {
+		gl.GenerateMipmap(gl.TEXTURE_CUBE_MAP)
+		gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+	} else {
+		gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	}
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, 0)
+	return texture
+}
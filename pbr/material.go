@@ -0,0 +1,104 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package pbr
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/vbsw/opengl-go-example/gfx"
+	"github.com/vbsw/opengl-go-example/render"
+	"github.com/vbsw/opengl-go-example/vmath"
+)
+
+// AlphaMode is the alpha mode of a material as defined by glTF 2.0.
+type AlphaMode int32
+
+// Alpha modes.
+const (
+	// alpha is ignored
+	AlphaOpaque AlphaMode = iota
+	// fragments with alpha below AlphaCutoff are discarded, the others are opaque
+	AlphaMask
+	// alpha blended, drawn in the transparent pass of render.Queue
+	AlphaBlend
+)
+
+// Material is a metallic-roughness material as defined by glTF 2.0. Factors are
+// multiplied with the texels of the textures. Textures are optional. Color textures
+// must be sRGB textures (see gfx.NewSRGBTexture), the others linear. It implements
+// scene.Material and render.Sortable.
+type Material struct {
+	Program *Program
+	// linear RGBA
+	BaseColorFactor vmath.Vec4
+	MetallicFactor  float32
+	RoughnessFactor float32
+	// linear RGB
+	EmissiveFactor    vmath.Vec3
+	NormalScale       float32
+	OcclusionStrength float32
+	AlphaMode         AlphaMode
+	// used with AlphaMask
+	AlphaCutoff float32
+	// sRGB color and linear alpha
+	BaseColorTexture *gfx.Texture
+	// roughness in green and metallic in blue channel
+	MetallicRoughnessTexture *gfx.Texture
+	// tangent space normals
+	NormalTexture *gfx.Texture
+	// occlusion in red channel
+	OcclusionTexture *gfx.Texture
+	// sRGB color
+	EmissiveTexture *gfx.Texture
+}
+
+// NewMaterial returns a material with the default values of glTF: white, fully
+// metallic and rough, opaque, without emission.
+func NewMaterial(program *Program) *Material {
+	material := &Material{Program: program, MetallicFactor: 1, RoughnessFactor: 1, NormalScale: 1, OcclusionStrength: 1, AlphaCutoff: 0.5}
+	material.BaseColorFactor = vmath.Vec4{1, 1, 1, 1}
+	return material
+}
+
+// Apply sets the model matrix, normal matrix, factors and textures of the current
+// program. Missing textures are replaced by white (or a flat normal).
+func (material *Material) Apply(model vmath.Mat4) {
+	program := material.Program
+	normalMatrix := model.NormalMatrix()
+	base, emissive := material.BaseColorFactor, material.EmissiveFactor
+	gl.UniformMatrix4fv(program.ModelLocation, 1, false, model.Ptr())
+	gl.UniformMatrix3fv(program.NormalMatrixLocation, 1, false, normalMatrix.Ptr())
+	gl.Uniform4f(program.BaseColorLocation, base[0], base[1], base[2], base[3])
+	gl.Uniform1f(program.MetallicLocation, material.MetallicFactor)
+	gl.Uniform1f(program.RoughnessLocation, material.RoughnessFactor)
+	gl.Uniform3f(program.EmissiveLocation, emissive[0], emissive[1], emissive[2])
+	gl.Uniform1f(program.NormalScaleLocation, material.NormalScale)
+	gl.Uniform1f(program.OcclusionStrengthLocation, material.OcclusionStrength)
+	gl.Uniform1i(program.AlphaModeLocation, int32(material.AlphaMode))
+	gl.Uniform1f(program.AlphaCutoffLocation, material.AlphaCutoff)
+	bindTexture(BaseColorUnit, material.BaseColorTexture, program.white)
+	bindTexture(MetallicRoughnessUnit, material.MetallicRoughnessTexture, program.white)
+	bindTexture(NormalUnit, material.NormalTexture, program.flatNormal)
+	bindTexture(OcclusionUnit, material.OcclusionTexture, program.white)
+	bindTexture(EmissiveUnit, material.EmissiveTexture, program.white)
+	gl.ActiveTexture(gl.TEXTURE0)
+}
+
+// SortInfo returns program, base color texture and transparency for render.Queue.
+func (material *Material) SortInfo() render.SortInfo {
+	info := render.SortInfo{Program: material.Program.ID, Transparent: material.AlphaMode == AlphaBlend}
+	if material.BaseColorTexture != nil {
+		info.Texture = material.BaseColorTexture.ID
+	}
+	return info
+}
+
+func bindTexture(unit uint32, texture, fallback *gfx.Texture) {
+	if texture == nil {
+		texture = fallback
+	}
+	gl.ActiveTexture(gl.TEXTURE0 + unit)
+	gl.BindTexture(gl.TEXTURE_2D, texture.ID)
+}
@@ -0,0 +1,294 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+// Package pbr implements physically based shading with the metallic-roughness
+// material model of glTF 2.0 and image based lighting from environment maps.
+package pbr
+
+import (
+	"fmt"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/vbsw/opengl-go-example/gfx"
+	"github.com/vbsw/opengl-go-example/light"
+	"github.com/vbsw/opengl-go-example/vmath"
+)
+
+// Texture units of the material textures and the environment.
+const (
+	BaseColorUnit = iota
+	MetallicRoughnessUnit
+	NormalUnit
+	OcclusionUnit
+	EmissiveUnit
+	IrradianceUnit
+	PrefilteredUnit
+	BRDFUnit
+)
+
+// VertexShader transforms position, normal and tangent to world space. The w
+// component of the tangent is the sign of the bitangent (see package mesh).
+const VertexShader = `#version 130
+
+in vec3 positionIn;
+in vec3 normalIn;
+in vec2 uvIn;
+in vec4 tangentIn;
+uniform mat4 projection;
+uniform mat4 view;
+uniform mat4 model;
+uniform mat3 normalMatrix;
+out vec3 worldPosition;
+out vec3 worldNormal;
+out vec2 uv;
+out vec4 worldTangent;
+
+void main() {
+	vec4 position = model * vec4(positionIn, 1.0);
+	gl_Position = projection * view * position;
+	worldPosition = position.xyz;
+	worldNormal = normalMatrix * normalIn;
+	worldTangent = vec4(mat3(model) * tangentIn.xyz, tangentIn.w);
+	uv = uvIn;
+}
+`
+
+// FragmentShader is Cook-Torrance shading (GGX distribution, Smith-Schlick geometry,
+// Fresnel-Schlick) of the glTF metallic-roughness material. It follows
+// light.LightsShader. Without environment (iblIntensity 0) the ambient color of
+// the lights is used instead of image based lighting.
+const FragmentShader = `
+in vec3 worldPosition;
+in vec3 worldNormal;
+in vec2 uv;
+in vec4 worldTangent;
+uniform vec3 cameraPosition;
+uniform vec4 baseColorFactor;
+uniform float metallicFactor;
+uniform float roughnessFactor;
+uniform vec3 emissiveFactor;
+uniform float normalScale;
+uniform float occlusionStrength;
+// 0 opaque, 1 mask, 2 blend (see AlphaMode)
+uniform int alphaMode;
+uniform float alphaCutoff;
+// sRGB texture, linear when sampled
+uniform sampler2D baseColorTexture;
+// linear, roughness in green and metallic in blue
+uniform sampler2D metallicRoughnessTexture;
+uniform sampler2D normalTexture;
+// linear, occlusion in red
+uniform sampler2D occlusionTexture;
+// sRGB texture, linear when sampled
+uniform sampler2D emissiveTexture;
+uniform samplerCube irradianceMap;
+uniform samplerCube prefilteredMap;
+uniform sampler2D brdfLUT;
+// highest mipmap level of prefilteredMap (roughness 1)
+uniform float prefilteredLevels;
+uniform float iblIntensity;
+out vec4 color;
+
+const float PI = 3.14159265359;
+const int ALPHA_MASK = 1;
+const int ALPHA_BLEND = 2;
+
+float distributionGGX(float NdotH, float roughness) {
+	float a2 = roughness * roughness * roughness * roughness;
+	float d = NdotH * NdotH * (a2 - 1.0) + 1.0;
+	return a2 / (PI * d * d);
+}
+
+float geometrySmith(float NdotV, float NdotL, float roughness) {
+	float r = roughness + 1.0;
+	float k = r * r / 8.0;
+	return NdotV / (NdotV * (1.0 - k) + k) * NdotL / (NdotL * (1.0 - k) + k);
+}
+
+vec3 fresnelSchlick(float cosTheta, vec3 F0) {
+	return F0 + (1.0 - F0) * pow(1.0 - cosTheta, 5.0);
+}
+
+vec3 fresnelSchlickRoughness(float cosTheta, vec3 F0, float roughness) {
+	return F0 + (max(vec3(1.0 - roughness), F0) - F0) * pow(1.0 - cosTheta, 5.0);
+}
+
+vec3 surfaceNormal() {
+	vec3 normal = normalize(worldNormal);
+	if (!gl_FrontFacing) {
+		normal = -normal;
+	}
+	vec3 tangent = worldTangent.xyz - normal * dot(normal, worldTangent.xyz);
+	if (dot(tangent, tangent) < 1e-8) {
+		return normal;
+	}
+	tangent = normalize(tangent);
+	vec3 bitangent = cross(normal, tangent) * worldTangent.w;
+	vec3 mapped = texture(normalTexture, uv).xyz * 2.0 - 1.0;
+	mapped.xy *= normalScale;
+	return normalize(mat3(tangent, bitangent, normal) * mapped);
+}
+
+void main() {
+	vec4 baseColor = baseColorFactor * texture(baseColorTexture, uv);
+	if (alphaMode == ALPHA_MASK && baseColor.a < alphaCutoff) {
+		discard;
+	}
+	float alpha = alphaMode == ALPHA_BLEND ? baseColor.a : 1.0;
+	vec4 metallicRoughness = texture(metallicRoughnessTexture, uv);
+	float metallic = clamp(metallicFactor * metallicRoughness.b, 0.0, 1.0);
+	float roughness = clamp(roughnessFactor * metallicRoughness.g, 0.04, 1.0);
+	float occlusion = mix(1.0, texture(occlusionTexture, uv).r, occlusionStrength);
+	vec3 emissive = emissiveFactor * texture(emissiveTexture, uv).rgb;
+
+	vec3 N = surfaceNormal();
+	vec3 V = normalize(cameraPosition - worldPosition);
+	float NdotV = max(dot(N, V), 1e-4);
+	vec3 F0 = mix(vec3(0.04), baseColor.rgb, metallic);
+	vec3 diffuseColor = baseColor.rgb * (1.0 - metallic);
+
+	vec3 direct = vec3(0.0);
+	for (int i = 0; i < lightCount; i++) {
+		float factor;
+		vec3 L = lightDirection(i, worldPosition, factor);
+		float NdotL = dot(N, L);
+		if (NdotL > 0.0) {
+			vec3 H = normalize(V + L);
+			vec3 F = fresnelSchlick(max(dot(H, V), 0.0), F0);
+			float D = distributionGGX(max(dot(N, H), 0.0), roughness);
+			float G = geometrySmith(NdotV, NdotL, roughness);
+			vec3 specular = D * G * F / (4.0 * NdotV * NdotL);
+			vec3 diffuse = (1.0 - F) * diffuseColor / PI;
+			direct += (diffuse + specular) * lights[i].color * factor * NdotL;
+		}
+	}
+
+	vec3 indirect = ambient * baseColor.rgb;
+	if (iblIntensity > 0.0) {
+		vec3 F = fresnelSchlickRoughness(NdotV, F0, roughness);
+		vec3 irradiance = texture(irradianceMap, N).rgb;
+		vec3 prefiltered = textureLod(prefilteredMap, reflect(-V, N), roughness * prefilteredLevels).rgb;
+		vec2 brdf = texture(brdfLUT, vec2(NdotV, roughness)).rg;
+		indirect = ((1.0 - F) * diffuseColor * irradiance + prefiltered * (F * brdf.x + brdf.y)) * iblIntensity;
+	}
+	color = vec4(indirect * occlusion + direct + emissive, alpha);
+}
+`
+
+// Program is the PBR program with its locations. The attributes positionIn,
+// normalIn, uvIn and tangentIn have the locations 0 to 3, which match the
+// interleaved vertex data of package mesh.
+type Program struct {
+	ID                                              uint32
+	Lights                                          *light.Uniforms
+	PositionLocation, NormalLocation                int32
+	UVLocation, TangentLocation                     int32
+	ProjectionLocation, ViewLocation, ModelLocation int32
+	NormalMatrixLocation, CameraPositionLocation    int32
+	BaseColorLocation, MetallicLocation             int32
+	RoughnessLocation, EmissiveLocation             int32
+	NormalScaleLocation, OcclusionStrengthLocation  int32
+	AlphaModeLocation, AlphaCutoffLocation          int32
+	PrefilteredLevelsLocation, IBLIntensityLocation int32
+	// 1x1 textures used for missing material textures
+	white, flatNormal *gfx.Texture
+}
+
+// NewProgram compiles a program with maxLights direct lights.
+func NewProgram(maxLights int) (*Program, error) {
+	header := fmt.Sprintf("#version 130\n#define MAX_LIGHTS %d\n", maxLights)
+	id, err := gfx.NewProgramWithAttributes(VertexShader, header+light.LightsShader+FragmentShader, "positionIn", "normalIn", "uvIn", "tangentIn")
+	if err != nil {
+		return nil, err
+	}
+	program := &Program{ID: id, Lights: light.NewUniforms(id, maxLights)}
+	program.PositionLocation = gfx.AttribLocation(id, "positionIn")
+	program.NormalLocation = gfx.AttribLocation(id, "normalIn")
+	program.UVLocation = gfx.AttribLocation(id, "uvIn")
+	program.TangentLocation = gfx.AttribLocation(id, "tangentIn")
+	program.ProjectionLocation = gfx.UniformLocation(id, "projection")
+	program.ViewLocation = gfx.UniformLocation(id, "view")
+	program.ModelLocation = gfx.UniformLocation(id, "model")
+	program.NormalMatrixLocation = gfx.UniformLocation(id, "normalMatrix")
+	program.CameraPositionLocation = gfx.UniformLocation(id, "cameraPosition")
+	program.BaseColorLocation = gfx.UniformLocation(id, "baseColorFactor")
+	program.MetallicLocation = gfx.UniformLocation(id, "metallicFactor")
+	program.RoughnessLocation = gfx.UniformLocation(id, "roughnessFactor")
+	program.EmissiveLocation = gfx.UniformLocation(id, "emissiveFactor")
+	program.NormalScaleLocation = gfx.UniformLocation(id, "normalScale")
+	program.OcclusionStrengthLocation = gfx.UniformLocation(id, "occlusionStrength")
+	program.AlphaModeLocation = gfx.UniformLocation(id, "alphaMode")
+	program.AlphaCutoffLocation = gfx.UniformLocation(id, "alphaCutoff")
+	program.PrefilteredLevelsLocation = gfx.UniformLocation(id, "prefilteredLevels")
+	program.IBLIntensityLocation = gfx.UniformLocation(id, "iblIntensity")
+	program.white = gfx.NewTexture(1, 1, []uint8{255, 255, 255, 255}, gl.NEAREST)
+	program.flatNormal = gfx.NewTexture(1, 1, []uint8{128, 128, 255, 255}, gl.NEAREST)
+
+	gl.UseProgram(id)
+	samplers := []struct {
+		name string
+		unit int32
+	}{
+		{"baseColorTexture", BaseColorUnit},
+		{"metallicRoughnessTexture", MetallicRoughnessUnit},
+		{"normalTexture", NormalUnit},
+		{"occlusionTexture", OcclusionUnit},
+		{"emissiveTexture", EmissiveUnit},
+		{"irradianceMap", IrradianceUnit},
+		{"prefilteredMap", PrefilteredUnit},
+		{"brdfLUT", BRDFUnit},
+	}
+	for _, sampler := range samplers {
+		gl.Uniform1i(gfx.UniformLocation(id, sampler.name), sampler.unit)
+	}
+	gl.UseProgram(0)
+	return program, nil
+}
+
+// Use makes the program current.
+func (program *Program) Use() {
+	gl.UseProgram(program.ID)
+}
+
+// SetCamera uploads projection, view and the camera position of the current program.
+func (program *Program) SetCamera(projection, view vmath.Mat4) {
+	position := vmath.Vec3{}
+	if inverse, ok := view.Inverse(); ok {
+		position = inverse.Translation()
+	}
+	gl.UniformMatrix4fv(program.ProjectionLocation, 1, false, projection.Ptr())
+	gl.UniformMatrix4fv(program.ViewLocation, 1, false, view.Ptr())
+	gl.Uniform3f(program.CameraPositionLocation, position[0], position[1], position[2])
+}
+
+// SetLights uploads the ambient color and lights in world space to the current
+// program. The ambient color is only used without environment.
+func (program *Program) SetLights(ambient vmath.Vec3, lights []light.Light) {
+	program.Lights.Set(ambient, lights)
+}
+
+// SetEnvironment binds the maps of environment for image based lighting scaled by
+// intensity. A nil environment or intensity 0 disables image based lighting.
+func (program *Program) SetEnvironment(environment *Environment, intensity float32) {
+	if environment == nil {
+		intensity = 0
+	} else {
+		gl.ActiveTexture(gl.TEXTURE0 + IrradianceUnit)
+		gl.BindTexture(gl.TEXTURE_CUBE_MAP, environment.Irradiance)
+		gl.ActiveTexture(gl.TEXTURE0 + PrefilteredUnit)
+		gl.BindTexture(gl.TEXTURE_CUBE_MAP, environment.Prefiltered)
+		gl.ActiveTexture(gl.TEXTURE0 + BRDFUnit)
+		gl.BindTexture(gl.TEXTURE_2D, environment.BRDF)
+		gl.ActiveTexture(gl.TEXTURE0)
+		gl.Uniform1f(program.PrefilteredLevelsLocation, float32(environment.PrefilteredLevels-1))
+	}
+	gl.Uniform1f(program.IBLIntensityLocation, intensity)
+}
+
+// Delete deletes the program and its default textures.
+func (program *Program) Delete() {
+	program.white.Delete()
+	program.flatNormal.Delete()
+	gl.DeleteProgram(program.ID)
+}
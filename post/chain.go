@@ -0,0 +1,246 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package post
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/vbsw/opengl-go-example/gfx"
+)
+
+// internal passes of bloom
+const (
+	copyEffect         = "copy"
+	bloomExtractEffect = "bloom extract"
+	bloomBlurEffect    = "bloom blur"
+)
+
+// Chain renders its enabled passes in order. Every pass reads the output of the
+// previous one. Intermediate results are rendered alternately into two 16 bit float
+// (ping-pong) buffers, so colors stay in high dynamic range until tone mapping.
+type Chain struct {
+	Passes   []*Pass
+	buffers  [2]*gfx.Framebuffer
+	bloom    [2]*gfx.Framebuffer
+	programs map[string]*program
+	triangle *gfx.FullscreenTriangle
+}
+
+type program struct {
+	id        uint32
+	texelSize int32
+	// locations of parameters and additional uniforms
+	uniforms map[string]int32
+}
+
+// NewChain returns a new chain with buffers of size width x height and the passes
+// from config.
+func NewChain(width, height int, config Config) (*Chain, error) {
+	passes, err := config.NewPasses()
+	if err != nil {
+		return nil, err
+	}
+	chain := &Chain{Passes: passes, programs: make(map[string]*program)}
+	shaders := map[string]string{copyEffect: copyShader, bloomExtractEffect: bloomExtractShader, bloomBlurEffect: bloomBlurShader}
+	for effect, shader := range fragmentShaders {
+		shaders[effect] = shader
+	}
+	for effect, shader := range shaders {
+		var id uint32
+		id, err = gfx.NewProgram(gfx.FullscreenVertexShader, shader)
+		if err != nil {
+			chain.Delete()
+			return nil, err
+		}
+		chain.programs[effect] = newProgram(id, effect)
+	}
+	for i := range chain.buffers {
+		chain.buffers[i], err = gfx.NewFramebuffer(bufferConfig(width, height))
+		if err == nil {
+			chain.bloom[i], err = gfx.NewFramebuffer(bufferConfig(bloomSize(width, height)))
+		}
+		if err != nil {
+			chain.Delete()
+			return nil, err
+		}
+	}
+	chain.triangle = gfx.NewFullscreenTriangle()
+	return chain, nil
+}
+
+// SetConfig replaces the passes with the ones from config (e.g. after reloading the
+// file). On error the passes remain unchanged.
+func (chain *Chain) SetConfig(config Config) error {
+	passes, err := config.NewPasses()
+	if err == nil {
+		chain.Passes = passes
+	}
+	return err
+}
+
+// Config returns the configuration of the passes.
+func (chain *Chain) Config() Config {
+	return NewConfig(chain.Passes)
+}
+
+// Move moves the pass at index from to index to. The passes in between are shifted.
+func (chain *Chain) Move(from, to int) {
+	if from != to && from >= 0 && to >= 0 && from < len(chain.Passes) && to < len(chain.Passes) {
+		pass := chain.Passes[from]
+		if from < to {
+			copy(chain.Passes[from:to], chain.Passes[from+1:to+1])
+		} else {
+			copy(chain.Passes[to+1:from+1], chain.Passes[to:from])
+		}
+		chain.Passes[to] = pass
+	}
+}
+
+// Resize resizes the buffers. It is meant to be called from the window's size
+// callback.
+func (chain *Chain) Resize(width, height int) error {
+	var err error
+	for i := 0; i < len(chain.buffers) && err == nil; i++ {
+		err = chain.buffers[i].Resize(width, height)
+		if err == nil {
+			err = chain.bloom[i].Resize(bloomSize(width, height))
+		}
+	}
+	return err
+}
+
+// Render applies the enabled passes to texture input and writes the result to
+// target. If target is nil, the result is written to the default framebuffer of
+// size width x height. Depth test and blending are disabled.
+func (chain *Chain) Render(input uint32, target *gfx.Framebuffer, width, height int) {
+	var enabled []*Pass
+	for _, pass := range chain.Passes {
+		if pass.Enabled {
+			enabled = append(enabled, pass)
+		}
+	}
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Disable(gl.BLEND)
+	if len(enabled) == 0 {
+		chain.bindTarget(target, width, height)
+		chain.draw(chain.programs[copyEffect], nil, input, chain.buffers[0])
+	}
+	for i, pass := range enabled {
+		last := i == len(enabled)-1
+		if pass.Effect == Bloom {
+			chain.renderBloom(pass, input)
+			gl.ActiveTexture(gl.TEXTURE1)
+			gl.BindTexture(gl.TEXTURE_2D, chain.bloom[0].Texture(0))
+		}
+		if last {
+			chain.bindTarget(target, width, height)
+		} else {
+			chain.buffers[i%2].Bind()
+		}
+		// the input has the size of the buffers
+		chain.draw(chain.programs[pass.Effect], pass.Params, input, chain.buffers[0])
+		if !last {
+			input = chain.buffers[i%2].Texture(0)
+		}
+	}
+	gl.ActiveTexture(gl.TEXTURE1)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	gl.UseProgram(0)
+}
+
+// Delete deletes programs and buffers.
+func (chain *Chain) Delete() {
+	for _, program := range chain.programs {
+		gl.DeleteProgram(program.id)
+	}
+	chain.programs = nil
+	for i := range chain.buffers {
+		if chain.buffers[i] != nil {
+			chain.buffers[i].Delete()
+			chain.buffers[i] = nil
+		}
+		if chain.bloom[i] != nil {
+			chain.bloom[i].Delete()
+			chain.bloom[i] = nil
+		}
+	}
+	if chain.triangle != nil {
+		chain.triangle.Delete()
+		chain.triangle = nil
+	}
+}
+
+// renderBloom extracts the bright colors of input into the first bloom buffer and
+// blurs them horizontally and vertically.
+func (chain *Chain) renderBloom(pass *Pass, input uint32) {
+	blur := chain.programs[bloomBlurEffect]
+	chain.bloom[0].Bind()
+	chain.draw(chain.programs[bloomExtractEffect], pass.Params, input, chain.buffers[0])
+	for i := 0; i < int(pass.Params["iterations"]); i++ {
+		chain.bloom[1].Bind()
+		gl.UseProgram(blur.id)
+		gl.Uniform2f(blur.uniforms["direction"], 1, 0)
+		chain.draw(blur, pass.Params, chain.bloom[0].Texture(0), chain.bloom[0])
+		chain.bloom[0].Bind()
+		gl.UseProgram(blur.id)
+		gl.Uniform2f(blur.uniforms["direction"], 0, 1)
+		chain.draw(blur, pass.Params, chain.bloom[1].Texture(0), chain.bloom[1])
+	}
+}
+
+// draw draws the full-screen triangle with program, params and input into the
+// bound framebuffer. The texel size is the one of source.
+func (chain *Chain) draw(program *program, params map[string]float32, input uint32, source *gfx.Framebuffer) {
+	gl.UseProgram(program.id)
+	gl.Uniform2f(program.texelSize, 1/float32(source.Width()), 1/float32(source.Height()))
+	for name, value := range params {
+		if location, ok := program.uniforms[name]; ok {
+			gl.Uniform1f(location, value)
+		}
+	}
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, input)
+	chain.triangle.Draw()
+}
+
+func (chain *Chain) bindTarget(target *gfx.Framebuffer, width, height int) {
+	if target != nil {
+		target.Bind()
+	} else {
+		gfx.BindDefault(width, height)
+	}
+}
+
+func newProgram(id uint32, effect string) *program {
+	prog := &program{id: id, texelSize: gfx.UniformLocation(id, "texelSize"), uniforms: make(map[string]int32)}
+	names := []string{"direction"}
+	if effect == bloomExtractEffect || effect == bloomBlurEffect {
+		effect = Bloom
+	}
+	for name := range defaults[effect] {
+		names = append(names, name)
+	}
+	for _, name := range names {
+		if location := gfx.UniformLocation(id, name); location >= 0 {
+			prog.uniforms[name] = location
+		}
+	}
+	gl.UseProgram(id)
+	gl.Uniform1i(gfx.UniformLocation(id, "image"), 0)
+	gl.Uniform1i(gfx.UniformLocation(id, "bloom"), 1)
+	gl.UseProgram(0)
+	return prog
+}
+
+func bufferConfig(width, height int) gfx.FramebufferConfig {
+	return gfx.FramebufferConfig{Width: width, Height: height, Colors: []int32{gl.RGBA16F}}
+}
+
+// bloomSize returns half of width and height, but at least 1.
+func bloomSize(width, height int) (int, int) {
+	return (width + 1) / 2, (height + 1) / 2
+}
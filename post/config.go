@@ -0,0 +1,189 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+// Package post provides a chain of full-screen post-processing passes. The passes
+// can be enabled, disabled and reordered at runtime and the chain can be read from
+// a JSON file:
+//
+//	{"passes": [
+//		{"effect": "bloom", "params": {"threshold": 1.2}},
+//		{"effect": "tonemap", "params": {"exposure": 1.5}},
+//		{"effect": "gamma"},
+//		{"effect": "fxaa"},
+//		{"effect": "pixelate", "disabled": true}
+//	]}
+package post
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+)
+
+// Effect names.
+const (
+	ToneMap   = "tonemap"
+	Gamma     = "gamma"
+	FXAA      = "fxaa"
+	Bloom     = "bloom"
+	Vignette  = "vignette"
+	Chromatic = "chromatic"
+	Grayscale = "grayscale"
+	Sharpen   = "sharpen"
+	Pixelate  = "pixelate"
+)
+
+// defaults are the parameters of all effects with their default values. The names
+// are the names of the uniforms in the shaders.
+var defaults = map[string]map[string]float32{
+	// operator 0 is Reinhard, 1 is ACES (filmic)
+	ToneMap: {"exposure": 1, "operator": 1},
+	Gamma:   {"gamma": 2.2},
+	// FXAA expects gamma corrected colors, i.e. must run after tonemap and gamma
+	FXAA: {"spanMax": 8, "reduceMul": 1.0 / 8.0, "reduceMin": 1.0 / 128.0},
+	// colors brighter than threshold are blurred iterations times at half resolution
+	Bloom:     {"threshold": 1, "intensity": 0.8, "radius": 1, "iterations": 4},
+	Vignette:  {"radius": 0.75, "softness": 0.45, "strength": 1},
+	Chromatic: {"amount": 0.01},
+	// sepia 0 is gray, 1 is sepia; amount blends with the original colors
+	Grayscale: {"sepia": 0, "amount": 1},
+	Sharpen:   {"amount": 0.5},
+	// size of the pixels in screen pixels
+	Pixelate: {"size": 6},
+}
+
+// Pass is one effect of the chain.
+type Pass struct {
+	Effect  string
+	Enabled bool
+	Params  map[string]float32
+}
+
+// PassConfig is the JSON representation of a pass. Missing parameters have their
+// default values.
+type PassConfig struct {
+	Effect   string             `json:"effect"`
+	Disabled bool               `json:"disabled,omitempty"`
+	Params   map[string]float32 `json:"params,omitempty"`
+}
+
+// Config is the JSON representation of a chain.
+type Config struct {
+	Passes []PassConfig `json:"passes"`
+}
+
+// Effects returns the names of all effects in alphabetical order.
+func Effects() []string {
+	names := make([]string, 0, len(defaults))
+	for name := range defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultConfig returns a chain of all effects. The ones changing the look of the
+// image considerably are disabled.
+func DefaultConfig() Config {
+	return Config{Passes: []PassConfig{
+		{Effect: Bloom},
+		{Effect: ToneMap},
+		{Effect: Gamma},
+		{Effect: FXAA},
+		{Effect: Chromatic, Disabled: true},
+		{Effect: Vignette},
+		{Effect: Sharpen, Disabled: true},
+		{Effect: Grayscale, Disabled: true},
+		{Effect: Pixelate, Disabled: true},
+	}}
+}
+
+// NewPass returns a new enabled pass of effect with default parameters.
+func NewPass(effect string) (*Pass, error) {
+	params, ok := defaults[effect]
+	if !ok {
+		return nil, fmt.Errorf("unknown effect %q", effect)
+	}
+	pass := &Pass{Effect: effect, Enabled: true, Params: make(map[string]float32, len(params))}
+	for name, value := range params {
+		pass.Params[name] = value
+	}
+	return pass, nil
+}
+
+// NewPasses returns new passes from the configuration.
+func (config Config) NewPasses() ([]*Pass, error) {
+	passes := make([]*Pass, 0, len(config.Passes))
+	for _, passConfig := range config.Passes {
+		pass, err := NewPass(passConfig.Effect)
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range passConfig.Params {
+			if _, ok := pass.Params[name]; !ok {
+				return nil, fmt.Errorf("effect %q has no parameter %q", pass.Effect, name)
+			}
+			pass.Params[name] = value
+		}
+		pass.Enabled = !passConfig.Disabled
+		passes = append(passes, pass)
+	}
+	return passes, nil
+}
+
+// NewConfig returns the configuration of passes. Only parameters differing from
+// the defaults are stored.
+func NewConfig(passes []*Pass) Config {
+	config := Config{Passes: make([]PassConfig, 0, len(passes))}
+	for _, pass := range passes {
+		passConfig := PassConfig{Effect: pass.Effect, Disabled: !pass.Enabled}
+		for name, value := range pass.Params {
+			if defaults[pass.Effect][name] != value {
+				if passConfig.Params == nil {
+					passConfig.Params = make(map[string]float32)
+				}
+				passConfig.Params[name] = value
+			}
+		}
+		config.Passes = append(config.Passes, passConfig)
+	}
+	return config
+}
+
+// ReadConfig decodes a JSON configuration and validates it.
+func ReadConfig(reader io.Reader) (Config, error) {
+	var config Config
+	err := json.NewDecoder(reader).Decode(&config)
+	if err == nil {
+		_, err = config.NewPasses()
+	}
+	return config, err
+}
+
+// LoadConfig reads the configuration from file path.
+func LoadConfig(path string) (Config, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Config{}, err
+	}
+	defer file.Close()
+	config, err := ReadConfig(file)
+	if err != nil {
+		err = fmt.Errorf("%s: %v", path, err)
+	}
+	return config, err
+}
+
+// Save writes the configuration as indented JSON to file path.
+func (config Config) Save(path string) error {
+	data, err := json.MarshalIndent(config, "", "\t")
+	if err == nil {
+		err = ioutil.WriteFile(path, append(data, '\n'), 0644)
+	}
+	return err
+}
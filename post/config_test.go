@@ -0,0 +1,113 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package post
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		effects string
+		wantErr bool
+	}{
+		{"empty", `{"passes": []}`, "", false},
+		{"order", `{"passes": [{"effect": "gamma"}, {"effect": "fxaa"}, {"effect": "tonemap"}]}`, "gamma fxaa tonemap", false},
+		{"params", `{"passes": [{"effect": "bloom", "params": {"threshold": 2}}]}`, "bloom", false},
+		{"disabled", `{"passes": [{"effect": "pixelate", "disabled": true}]}`, "pixelate", false},
+		{"unknown effect", `{"passes": [{"effect": "blur"}]}`, "", true},
+		{"unknown param", `{"passes": [{"effect": "gamma", "params": {"exposure": 2}}]}`, "", true},
+		{"syntax", `{"passes": [`, "", true},
+	}
+	for _, test := range tests {
+		config, err := ReadConfig(strings.NewReader(test.json))
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: error %v", test.name, err)
+		} else if err == nil {
+			var effects []string
+			for _, pass := range config.Passes {
+				effects = append(effects, pass.Effect)
+			}
+			if got := strings.Join(effects, " "); got != test.effects {
+				t.Errorf("%s: got %q, want %q", test.name, got, test.effects)
+			}
+		}
+	}
+}
+
+func TestNewPasses(t *testing.T) {
+	config, err := ReadConfig(strings.NewReader(`{"passes": [
+		{"effect": "bloom", "params": {"threshold": 2}},
+		{"effect": "vignette", "disabled": true}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	passes, err := config.NewPasses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if passes[0].Params["threshold"] != 2 || passes[0].Params["intensity"] != defaults[Bloom]["intensity"] {
+		t.Errorf("bloom params: %v", passes[0].Params)
+	}
+	if !passes[0].Enabled || passes[1].Enabled {
+		t.Errorf("enabled: %v %v", passes[0].Enabled, passes[1].Enabled)
+	}
+	// parameters of passes must not share the defaults
+	passes[1].Params["radius"] = 0
+	if defaults[Vignette]["radius"] == 0 {
+		t.Error("defaults modified")
+	}
+	round := NewConfig(passes)
+	if len(round.Passes) != 2 || len(round.Passes[0].Params) != 1 || !round.Passes[1].Disabled {
+		t.Errorf("round trip: %+v", round)
+	}
+}
+
+func TestDefaultConfig(t *testing.T) {
+	passes, err := DefaultConfig().NewPasses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(passes) != len(Effects()) {
+		t.Errorf("default chain has %d of %d effects", len(passes), len(Effects()))
+	}
+	for _, effect := range Effects() {
+		if _, ok := fragmentShaders[effect]; !ok {
+			t.Errorf("effect %q has no shader", effect)
+		}
+	}
+}
+
+func TestMove(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to int
+		want     string
+	}{
+		{"forward", 0, 2, "gamma fxaa tonemap vignette"},
+		{"backward", 3, 1, "tonemap vignette gamma fxaa"},
+		{"same", 1, 1, "tonemap gamma fxaa vignette"},
+		{"out of range", 1, 4, "tonemap gamma fxaa vignette"},
+	}
+	for _, test := range tests {
+		chain := &Chain{}
+		for _, effect := range []string{ToneMap, Gamma, FXAA, Vignette} {
+			pass, _ := NewPass(effect)
+			chain.Passes = append(chain.Passes, pass)
+		}
+		chain.Move(test.from, test.to)
+		var effects []string
+		for _, pass := range chain.Passes {
+			effects = append(effects, pass.Effect)
+		}
+		if got := strings.Join(effects, " "); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
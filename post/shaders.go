@@ -0,0 +1,195 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package post
+
+// header is shared by all fragment shaders. The vertex shader is
+// gfx.FullscreenVertexShader.
+const header = `#version 130
+
+in vec2 texCoords;
+uniform sampler2D image;
+uniform vec2 texelSize;
+out vec4 color;
+`
+
+const copyShader = header + `
+void main() {
+	color = texture(image, texCoords);
+}
+`
+
+const toneMapShader = header + `
+uniform float exposure;
+uniform float operator;
+
+void main() {
+	vec4 texel = texture(image, texCoords);
+	vec3 c = texel.rgb * exposure;
+	if (operator < 0.5) {
+		c = c / (1.0 + c);
+	} else {
+		// ACES approximation by Krzysztof Narkowicz
+		c = clamp((c * (2.51 * c + 0.03)) / (c * (2.43 * c + 0.59) + 0.14), 0.0, 1.0);
+	}
+	color = vec4(c, texel.a);
+}
+`
+
+const gammaShader = header + `
+uniform float gamma;
+
+void main() {
+	vec4 texel = texture(image, texCoords);
+	color = vec4(pow(max(texel.rgb, 0.0), vec3(1.0 / gamma)), texel.a);
+}
+`
+
+// fxaaShader is FXAA 3.11 in its reduced "console" form.
+const fxaaShader = header + `
+uniform float spanMax;
+uniform float reduceMul;
+uniform float reduceMin;
+
+void main() {
+	vec3 luma = vec3(0.299, 0.587, 0.114);
+	vec4 texel = texture(image, texCoords);
+	float lumaM = dot(texel.rgb, luma);
+	float lumaNW = dot(texture(image, texCoords + vec2(-1.0, -1.0) * texelSize).rgb, luma);
+	float lumaNE = dot(texture(image, texCoords + vec2(1.0, -1.0) * texelSize).rgb, luma);
+	float lumaSW = dot(texture(image, texCoords + vec2(-1.0, 1.0) * texelSize).rgb, luma);
+	float lumaSE = dot(texture(image, texCoords + vec2(1.0, 1.0) * texelSize).rgb, luma);
+	float lumaMin = min(lumaM, min(min(lumaNW, lumaNE), min(lumaSW, lumaSE)));
+	float lumaMax = max(lumaM, max(max(lumaNW, lumaNE), max(lumaSW, lumaSE)));
+
+	vec2 dir = vec2(-((lumaNW + lumaNE) - (lumaSW + lumaSE)), (lumaNW + lumaSW) - (lumaNE + lumaSE));
+	float dirReduce = max((lumaNW + lumaNE + lumaSW + lumaSE) * 0.25 * reduceMul, reduceMin);
+	float rcpDirMin = 1.0 / (min(abs(dir.x), abs(dir.y)) + dirReduce);
+	dir = clamp(dir * rcpDirMin, vec2(-spanMax), vec2(spanMax)) * texelSize;
+
+	vec3 rgbA = 0.5 * (texture(image, texCoords + dir * (1.0 / 3.0 - 0.5)).rgb +
+		texture(image, texCoords + dir * (2.0 / 3.0 - 0.5)).rgb);
+	vec3 rgbB = rgbA * 0.5 + 0.25 * (texture(image, texCoords - dir * 0.5).rgb +
+		texture(image, texCoords + dir * 0.5).rgb);
+	float lumaB = dot(rgbB, luma);
+	if (lumaB < lumaMin || lumaB > lumaMax) {
+		color = vec4(rgbA, texel.a);
+	} else {
+		color = vec4(rgbB, texel.a);
+	}
+}
+`
+
+const bloomExtractShader = header + `
+uniform float threshold;
+
+void main() {
+	vec3 c = texture(image, texCoords).rgb;
+	float brightness = max(c.r, max(c.g, c.b));
+	color = vec4(c * max(brightness - threshold, 0.0) / max(brightness, 0.0001), 1.0);
+}
+`
+
+// bloomBlurShader is a separable 9 tap Gaussian blur in direction (1, 0) or (0, 1).
+const bloomBlurShader = header + `
+uniform vec2 direction;
+uniform float radius;
+
+void main() {
+	float weights[5] = float[](0.227027, 0.1945946, 0.1216216, 0.054054, 0.016216);
+	vec2 offset = direction * texelSize * radius;
+	vec3 c = texture(image, texCoords).rgb * weights[0];
+	for (int i = 1; i < 5; i++) {
+		c += texture(image, texCoords + offset * float(i)).rgb * weights[i];
+		c += texture(image, texCoords - offset * float(i)).rgb * weights[i];
+	}
+	color = vec4(c, 1.0);
+}
+`
+
+const bloomShader = header + `
+uniform sampler2D bloom;
+uniform float intensity;
+
+void main() {
+	vec4 texel = texture(image, texCoords);
+	color = vec4(texel.rgb + texture(bloom, texCoords).rgb * intensity, texel.a);
+}
+`
+
+const vignetteShader = header + `
+uniform float radius;
+uniform float softness;
+uniform float strength;
+
+void main() {
+	vec4 texel = texture(image, texCoords);
+	float vignette = smoothstep(radius, radius - softness, distance(texCoords, vec2(0.5)));
+	color = vec4(texel.rgb * mix(1.0, vignette, strength), texel.a);
+}
+`
+
+const chromaticShader = header + `
+uniform float amount;
+
+void main() {
+	vec2 offset = (texCoords - 0.5) * amount;
+	vec4 texel = texture(image, texCoords);
+	float r = texture(image, texCoords + offset).r;
+	float b = texture(image, texCoords - offset).b;
+	color = vec4(r, texel.g, b, texel.a);
+}
+`
+
+const grayscaleShader = header + `
+uniform float sepia;
+uniform float amount;
+
+void main() {
+	vec4 texel = texture(image, texCoords);
+	vec3 gray = vec3(dot(texel.rgb, vec3(0.299, 0.587, 0.114)));
+	vec3 brown = vec3(
+		dot(texel.rgb, vec3(0.393, 0.769, 0.189)),
+		dot(texel.rgb, vec3(0.349, 0.686, 0.168)),
+		dot(texel.rgb, vec3(0.272, 0.534, 0.131)));
+	color = vec4(mix(texel.rgb, mix(gray, brown, sepia), amount), texel.a);
+}
+`
+
+const sharpenShader = header + `
+uniform float amount;
+
+void main() {
+	vec4 texel = texture(image, texCoords);
+	vec3 neighbours = texture(image, texCoords + vec2(texelSize.x, 0.0)).rgb +
+		texture(image, texCoords - vec2(texelSize.x, 0.0)).rgb +
+		texture(image, texCoords + vec2(0.0, texelSize.y)).rgb +
+		texture(image, texCoords - vec2(0.0, texelSize.y)).rgb;
+	color = vec4(texel.rgb * (1.0 + 4.0 * amount) - neighbours * amount, texel.a);
+}
+`
+
+const pixelateShader = header + `
+uniform float size;
+
+void main() {
+	vec2 cell = texelSize * max(size, 1.0);
+	color = texture(image, (floor(texCoords / cell) + 0.5) * cell);
+}
+`
+
+// fragmentShaders maps effects to their shader. Bloom has additional shaders for
+// its internal passes.
+var fragmentShaders = map[string]string{
+	ToneMap:   toneMapShader,
+	Gamma:     gammaShader,
+	FXAA:      fxaaShader,
+	Bloom:     bloomShader,
+	Vignette:  vignetteShader,
+	Chromatic: chromaticShader,
+	Grayscale: grayscaleShader,
+	Sharpen:   sharpenShader,
+	Pixelate:  pixelateShader,
+}
@@ -0,0 +1,46 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package render
+
+import (
+	"math"
+)
+
+// Bits of the state identifiers in sort keys. Identifiers exceeding them wrap
+// around, which only makes sorting less effective.
+const (
+	ProgramBits  = 10
+	MaterialBits = 12
+	TextureBits  = 10
+)
+
+// OpaqueKey returns the sort key of an opaque draw: grouped by program, material
+// and texture (in that order) to reduce state changes and sorted front-to-back by
+// view depth within a group to reduce overdraw.
+func OpaqueKey(program, material, texture uint32, depth float32) uint64 {
+	key := uint64(program&(1<<ProgramBits-1)) << (MaterialBits + TextureBits)
+	key |= uint64(material&(1<<MaterialBits-1)) << TextureBits
+	key |= uint64(texture & (1<<TextureBits - 1))
+	return key<<32 | uint64(depthBits(depth))
+}
+
+// TransparentKey returns the sort key of a transparent draw: sorted back-to-front by
+// view depth. Draws of equal depth are grouped by program, material and texture.
+func TransparentKey(program, material, texture uint32, depth float32) uint64 {
+	key := uint64(^depthBits(depth)) << 32
+	key |= uint64(program&(1<<ProgramBits-1)) << (MaterialBits + TextureBits)
+	key |= uint64(material&(1<<MaterialBits-1)) << TextureBits
+	return key | uint64(texture&(1<<TextureBits-1))
+}
+
+// depthBits returns the bits of depth, which increase with depth for positive
+// floats. Depths behind the camera are 0.
+func depthBits(depth float32) uint32 {
+	if depth > 0 {
+		return math.Float32bits(depth)
+	}
+	return 0
+}
@@ -0,0 +1,125 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package render
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/vbsw/opengl-go-example/gfx"
+)
+
+// OITShader declares the function writeOIT, that replaces the output of fragment
+// shaders of transparent materials drawn with OIT. It writes to gl_FragData, so the
+// shader must not declare outputs.
+//
+// Blending is the same for both targets, because OpenGL 3.3 has no blend functions
+// per draw buffer: the first target accumulates the weighted premultiplied colors
+// (rgb, added) and the revealage (alpha, multiplied by 1 - alpha), the second one
+// the weighted alphas (red, added).
+const OITShader = `
+void writeOIT(vec4 color) {
+	// weight falls off with depth (McGuire and Bavoil 2013)
+	float weight = color.a * clamp(3e3 * pow(1.0 - gl_FragCoord.z, 3.0), 1e-2, 3e3);
+	gl_FragData[0] = vec4(color.rgb * color.a * weight, color.a);
+	gl_FragData[1] = vec4(color.a * weight, 0.0, 0.0, 1.0);
+}
+`
+
+// compositeShader blends the average color over the opaque image by the revealage.
+const compositeShader = `#version 130
+
+in vec2 texCoords;
+uniform sampler2D accumulation;
+uniform sampler2D weights;
+out vec4 color;
+
+void main() {
+	vec4 accumulated = texture(accumulation, texCoords);
+	float revealage = accumulated.a;
+	if (revealage > 0.999) {
+		discard;
+	}
+	float weight = texture(weights, texCoords).r;
+	color = vec4(accumulated.rgb / max(weight, 1e-5), 1.0 - revealage);
+}
+`
+
+// OIT is weighted blended order-independent transparency. Transparent draws are
+// accumulated unsorted into two targets and composited over the opaque image.
+// Intersecting transparent geometry is blended plausibly, but colors of layers are
+// only approximated by their depth weights.
+type OIT struct {
+	// accumulation (RGBA16F) and weights (R16F) with a depth renderbuffer
+	Framebuffer *gfx.Framebuffer
+	program     uint32
+	triangle    *gfx.FullscreenTriangle
+}
+
+// NewOIT returns targets of size width x height.
+func NewOIT(width, height int) (*OIT, error) {
+	program, err := gfx.NewProgram(gfx.FullscreenVertexShader, compositeShader)
+	if err != nil {
+		return nil, err
+	}
+	config := gfx.FramebufferConfig{Width: width, Height: height, Colors: []int32{gl.RGBA16F, gl.R16F}, Depth: gfx.DepthRenderbuffer, Filter: gl.NEAREST}
+	framebuffer, err := gfx.NewFramebuffer(config)
+	if err != nil {
+		gl.DeleteProgram(program)
+		return nil, err
+	}
+	gl.UseProgram(program)
+	gl.Uniform1i(gfx.UniformLocation(program, "accumulation"), 0)
+	gl.Uniform1i(gfx.UniformLocation(program, "weights"), 1)
+	gl.UseProgram(0)
+	return &OIT{Framebuffer: framebuffer, program: program, triangle: gfx.NewFullscreenTriangle()}, nil
+}
+
+// Render draws the transparent draws of queue with OIT over target, which contains
+// the opaque image. Target must have the same size and a depth renderbuffer, which is
+// copied for the depth test. The materials of the draws must write with writeOIT.
+// The state of the transparent pass of queue is not used and the cache of queue is
+// invalidated.
+func (oit *OIT) Render(queue *Queue, target *gfx.Framebuffer) {
+	target.Blit(oit.Framebuffer, 0, 0, gl.DEPTH_BUFFER_BIT, gl.NEAREST)
+	oit.Framebuffer.Bind()
+	accumulation := [4]float32{0, 0, 0, 1}
+	weights := [4]float32{0, 0, 0, 0}
+	gl.ClearBufferfv(gl.COLOR, 0, &accumulation[0])
+	gl.ClearBufferfv(gl.COLOR, 1, &weights[0])
+
+	gl.Enable(gl.DEPTH_TEST)
+	gl.DepthFunc(gl.LESS)
+	gl.DepthMask(false)
+	gl.Disable(gl.CULL_FACE)
+	gl.Enable(gl.BLEND)
+	gl.BlendFuncSeparate(gl.ONE, gl.ONE, gl.ZERO, gl.ONE_MINUS_SRC_ALPHA)
+	drawItems(queue.transparent)
+
+	target.Bind()
+	gl.Disable(gl.DEPTH_TEST)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	gl.UseProgram(oit.program)
+	gl.ActiveTexture(gl.TEXTURE1)
+	gl.BindTexture(gl.TEXTURE_2D, oit.Framebuffer.Texture(1))
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, oit.Framebuffer.Texture(0))
+	oit.triangle.Draw()
+	gl.DepthMask(true)
+	if queue.Cache != nil {
+		queue.Cache.Invalidate()
+	}
+}
+
+// Resize resizes the targets.
+func (oit *OIT) Resize(width, height int) error {
+	return oit.Framebuffer.Resize(width, height)
+}
+
+// Delete deletes the targets and the program.
+func (oit *OIT) Delete() {
+	oit.Framebuffer.Delete()
+	oit.triangle.Delete()
+	gl.DeleteProgram(oit.program)
+}
@@ -0,0 +1,222 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+// Package render provides a render queue that sorts draws into an opaque and a
+// transparent pass, and weighted blended order-independent transparency as an
+// alternative transparent pass.
+package render
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/vbsw/opengl-go-example/gfx"
+	"github.com/vbsw/opengl-go-example/scene"
+	"github.com/vbsw/opengl-go-example/vmath"
+	"sort"
+)
+
+// SortInfo is the information about a material used for sorting.
+type SortInfo struct {
+	Program uint32
+	// first texture
+	Texture     uint32
+	Transparent bool
+}
+
+// Sortable is implemented by materials, that provide SortInfo. Draws of other
+// materials are opaque and grouped by material only.
+type Sortable interface {
+	SortInfo() SortInfo
+}
+
+// PassState is the state of a pass.
+type PassState struct {
+	gfx.RenderState
+	Blend bool
+	// blend factors (e.g. gl.SRC_ALPHA and gl.ONE_MINUS_SRC_ALPHA)
+	BlendSrc, BlendDst uint32
+}
+
+// Item is a submitted draw.
+type Item struct {
+	Mesh     scene.Mesh
+	Material scene.Material
+	World    vmath.Mat4
+	// view depth of the origin of the mesh (positive in front of the camera)
+	Depth float32
+	Key   uint64
+}
+
+// Queue sorts draws by key. Opaque draws are drawn first.
+type Queue struct {
+	Opaque      PassState
+	Transparent PassState
+	// optional, sets the pass state if not nil
+	Cache *gfx.StateCache
+	// if false, draws keep the order of submission (for comparison)
+	Sorting     bool
+	opaque      []Item
+	transparent []Item
+	view        vmath.Mat4
+	// small identifiers of programs, materials and textures for the sort keys,
+	// assigned anew every frame
+	programs  map[uint32]uint32
+	materials map[scene.Material]uint32
+	textures  map[uint32]uint32
+}
+
+// OpaquePass returns the default state of the opaque pass: depth test and writes,
+// back faces culled, no blending.
+func OpaquePass() PassState {
+	return PassState{RenderState: gfx.Opaque3D(), BlendSrc: gl.ONE, BlendDst: gl.ZERO}
+}
+
+// TransparentPass returns the default state of the transparent pass: depth test
+// without depth writes, no culling and alpha blending.
+func TransparentPass() PassState {
+	state := gfx.Opaque3D()
+	state.DepthWrite, state.Cull = false, false
+	return PassState{RenderState: state, Blend: true, BlendSrc: gl.SRC_ALPHA, BlendDst: gl.ONE_MINUS_SRC_ALPHA}
+}
+
+// NewQueue returns an empty sorting queue with the default pass states.
+func NewQueue() *Queue {
+	queue := &Queue{Opaque: OpaquePass(), Transparent: TransparentPass(), Sorting: true}
+	queue.programs = make(map[uint32]uint32)
+	queue.materials = make(map[scene.Material]uint32)
+	queue.textures = make(map[uint32]uint32)
+	queue.view = vmath.Ident4()
+	return queue
+}
+
+// Begin removes the draws and identifiers of the previous frame. View is the view
+// matrix of the camera used for the view depth.
+func (queue *Queue) Begin(view vmath.Mat4) {
+	queue.opaque = queue.opaque[:0]
+	queue.transparent = queue.transparent[:0]
+	queue.view = view
+	for program := range queue.programs {
+		delete(queue.programs, program)
+	}
+	for material := range queue.materials {
+		delete(queue.materials, material)
+	}
+	for texture := range queue.textures {
+		delete(queue.textures, texture)
+	}
+}
+
+// Submit adds a draw of mesh with material and world matrix.
+func (queue *Queue) Submit(mesh scene.Mesh, material scene.Material, world vmath.Mat4) {
+	var info SortInfo
+	if sortable, ok := material.(Sortable); ok {
+		info = sortable.SortInfo()
+	}
+	item := Item{Mesh: mesh, Material: material, World: world}
+	item.Depth = -queue.view.TransformPoint(world.Translation())[2]
+	program := queue.id(queue.programs, info.Program)
+	texture := queue.id(queue.textures, info.Texture)
+	materialID, ok := queue.materials[material]
+	if !ok {
+		materialID = uint32(len(queue.materials))
+		queue.materials[material] = materialID
+	}
+	if info.Transparent {
+		item.Key = TransparentKey(program, materialID, texture, item.Depth)
+		queue.transparent = append(queue.transparent, item)
+	} else {
+		item.Key = OpaqueKey(program, materialID, texture, item.Depth)
+		queue.opaque = append(queue.opaque, item)
+	}
+}
+
+// SubmitList adds the items of list.
+func (queue *Queue) SubmitList(list *scene.DrawList) {
+	for i := range list.Items {
+		item := &list.Items[i]
+		queue.Submit(item.Mesh, item.Material, item.World)
+	}
+}
+
+// Sort sorts the opaque and the transparent draws by key, if Sorting is true.
+func (queue *Queue) Sort() {
+	if queue.Sorting {
+		sortItems(queue.opaque)
+		sortItems(queue.transparent)
+	}
+}
+
+// Draw sorts and draws the opaque and then the transparent draws.
+func (queue *Queue) Draw() {
+	queue.Sort()
+	queue.DrawOpaque()
+	queue.DrawTransparent()
+}
+
+// DrawOpaque applies the opaque pass state and draws the opaque draws.
+func (queue *Queue) DrawOpaque() {
+	queue.apply(&queue.Opaque)
+	drawItems(queue.opaque)
+}
+
+// DrawTransparent applies the transparent pass state and draws the transparent
+// draws.
+func (queue *Queue) DrawTransparent() {
+	queue.apply(&queue.Transparent)
+	drawItems(queue.transparent)
+}
+
+// Items returns the opaque and the transparent draws in drawing order (after Sort).
+func (queue *Queue) Items() ([]Item, []Item) {
+	return queue.opaque, queue.transparent
+}
+
+// id returns a small identifier of value (0 for 0).
+func (queue *Queue) id(ids map[uint32]uint32, value uint32) uint32 {
+	if value == 0 {
+		return 0
+	}
+	id, ok := ids[value]
+	if !ok {
+		id = uint32(len(ids) + 1)
+		ids[value] = id
+	}
+	return id
+}
+
+func (queue *Queue) apply(state *PassState) {
+	if queue.Cache != nil {
+		queue.Cache.SetState(state.RenderState)
+		queue.Cache.SetBlend(state.Blend, state.BlendSrc, state.BlendDst)
+	} else {
+		state.RenderState.Apply()
+		if state.Blend {
+			gl.Enable(gl.BLEND)
+			gl.BlendFunc(state.BlendSrc, state.BlendDst)
+		} else {
+			gl.Disable(gl.BLEND)
+		}
+	}
+}
+
+// byKey sorts items by key. Items of equal key keep the order of submission.
+type byKey []Item
+
+func (items byKey) Len() int           { return len(items) }
+func (items byKey) Less(i, j int) bool { return items[i].Key < items[j].Key }
+func (items byKey) Swap(i, j int)      { items[i], items[j] = items[j], items[i] }
+
+func sortItems(items []Item) {
+	sort.Stable(byKey(items))
+}
+
+func drawItems(items []Item) {
+	for i := range items {
+		item := &items[i]
+		if item.Material != nil {
+			item.Material.Apply(item.World)
+		}
+		item.Mesh.Draw()
+	}
+}
@@ -0,0 +1,153 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package render
+
+import (
+	"github.com/vbsw/opengl-go-example/vmath"
+	"strings"
+	"testing"
+)
+
+type testMesh struct {
+	name string
+}
+
+type testMaterial struct {
+	info SortInfo
+}
+
+func (mesh *testMesh) Draw() {
+}
+
+func (material *testMaterial) Apply(model vmath.Mat4) {
+}
+
+func (material *testMaterial) SortInfo() SortInfo {
+	return material.info
+}
+
+func TestKeys(t *testing.T) {
+	tests := []struct {
+		name         string
+		first, later uint64
+	}{
+		{"opaque front to back", OpaqueKey(1, 1, 1, 2), OpaqueKey(1, 1, 1, 10)},
+		{"opaque program before depth", OpaqueKey(1, 5, 5, 100), OpaqueKey(2, 0, 0, 1)},
+		{"opaque material before texture", OpaqueKey(1, 1, 9, 1), OpaqueKey(1, 2, 0, 1)},
+		{"opaque behind camera first", OpaqueKey(1, 1, 1, -5), OpaqueKey(1, 1, 1, 0.001)},
+		{"transparent back to front", TransparentKey(1, 1, 1, 10), TransparentKey(1, 1, 1, 2)},
+		{"transparent depth before program", TransparentKey(9, 9, 9, 10), TransparentKey(1, 1, 1, 9.99)},
+		{"transparent equal depth by program", TransparentKey(1, 9, 9, 3), TransparentKey(2, 0, 0, 3)},
+	}
+	for _, test := range tests {
+		if test.first >= test.later {
+			t.Errorf("%s: %x >= %x", test.name, test.first, test.later)
+		}
+	}
+}
+
+func TestQueue(t *testing.T) {
+	programA := &testMaterial{SortInfo{Program: 10, Texture: 3}}
+	programB := &testMaterial{SortInfo{Program: 20, Texture: 3}}
+	glass := &testMaterial{SortInfo{Program: 10, Transparent: true}}
+	tests := []struct {
+		name        string
+		sorting     bool
+		opaque      string
+		transparent string
+	}{
+		{"sorted", true, "plain a1 a5 a9 b2 b7", "g8 g4 g3"},
+		{"unsorted", false, "b7 a5 plain a9 b2 a1", "g3 g8 g4"},
+	}
+	for _, test := range tests {
+		queue := NewQueue()
+		queue.Sorting = test.sorting
+		// camera at z = 10 looking along -z
+		queue.Begin(vmath.Translate(vmath.Vec3{0, 0, -10}))
+		submit := func(name string, material *testMaterial, depth float32) {
+			world := vmath.Translate(vmath.Vec3{0, 0, 10 - depth})
+			if material == nil {
+				queue.Submit(&testMesh{name}, nil, world)
+			} else {
+				queue.Submit(&testMesh{name}, material, world)
+			}
+		}
+		submit("g3", glass, 3)
+		submit("b7", programB, 7)
+		submit("a5", programA, 5)
+		submit("g8", glass, 8)
+		submit("plain", nil, 1)
+		submit("a9", programA, 9)
+		submit("b2", programB, 2)
+		submit("g4", glass, 4)
+		submit("a1", programA, 1)
+		queue.Sort()
+		opaque, transparent := queue.Items()
+		if got := names(opaque); got != test.opaque {
+			t.Errorf("%s opaque: got %q, want %q", test.name, got, test.opaque)
+		}
+		if got := names(transparent); got != test.transparent {
+			t.Errorf("%s transparent: got %q, want %q", test.name, got, test.transparent)
+		}
+	}
+}
+
+func TestQueueDepth(t *testing.T) {
+	queue := NewQueue()
+	queue.Begin(vmath.LookAt(vmath.Vec3{5, 0, 0}, vmath.Vec3{}, vmath.Vec3{0, 1, 0}))
+	queue.Submit(&testMesh{"origin"}, nil, vmath.Translate(vmath.Vec3{0, 3, 0}))
+	opaque, _ := queue.Items()
+	if !vmath.ApproxEqual(opaque[0].Depth, 5) {
+		t.Errorf("depth %v, want 5", opaque[0].Depth)
+	}
+}
+
+func TestQueueIDs(t *testing.T) {
+	queue := NewQueue()
+	mesh := &testMesh{"mesh"}
+	for frame := 0; frame < 10; frame++ {
+		queue.Begin(vmath.Ident4())
+		// new materials, programs and textures every frame
+		for i := 0; i < 3; i++ {
+			value := uint32(frame*3 + i + 1)
+			queue.Submit(mesh, &testMaterial{SortInfo{Program: value, Texture: value}}, vmath.Ident4())
+		}
+		if len(queue.materials) != 3 || len(queue.programs) != 3 || len(queue.textures) != 3 {
+			t.Fatalf("frame %d: %d materials, %d programs, %d textures", frame, len(queue.materials), len(queue.programs), len(queue.textures))
+		}
+		opaque, _ := queue.Items()
+		if want := OpaqueKey(3, 2, 3, 0); opaque[2].Key != want {
+			t.Errorf("frame %d: key %x, want %x", frame, opaque[2].Key, want)
+		}
+	}
+}
+
+func names(items []Item) string {
+	var names []string
+	for _, item := range items {
+		names = append(names, item.Mesh.(*testMesh).name)
+	}
+	return strings.Join(names, " ")
+}
+
+func BenchmarkQueue(b *testing.B) {
+	materials := []*testMaterial{
+		{SortInfo{Program: 1, Texture: 1}},
+		{SortInfo{Program: 1, Texture: 2}},
+		{SortInfo{Program: 2, Texture: 1}},
+		{SortInfo{Program: 2, Transparent: true}},
+	}
+	mesh := &testMesh{"mesh"}
+	queue := NewQueue()
+	for i := 0; i < b.N; i++ {
+		queue.Begin(vmath.Ident4())
+		for j := 0; j < 1000; j++ {
+			world := vmath.Translate(vmath.Vec3{0, 0, -float32(j*7919%1000) / 10})
+			queue.Submit(mesh, materials[j%len(materials)], world)
+		}
+		queue.Sort()
+	}
+}
@@ -0,0 +1,78 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package scene
+
+import (
+	"github.com/vbsw/opengl-go-example/vmath"
+)
+
+// DrawItem is a mesh to draw with its material and world matrix.
+type DrawItem struct {
+	Node     *Node
+	Mesh     Mesh
+	Material Material
+	World    vmath.Mat4
+}
+
+// LightItem is a light with the world matrix of its node.
+type LightItem struct {
+	Node  *Node
+	Light Light
+	World vmath.Mat4
+}
+
+// DrawList is the result of the traversal of a scene graph.
+type DrawList struct {
+	Items   []DrawItem
+	Lights  []LightItem
+	Cameras []*Node
+}
+
+// Reset clears the lists, but keeps the allocated memory.
+func (list *DrawList) Reset() {
+	list.Items = list.Items[:0]
+	list.Lights = list.Lights[:0]
+	list.Cameras = list.Cameras[:0]
+}
+
+// Collect traverses the visible nodes of root depth-first and appends meshes, lights
+// and cameras to list. A node's mesh is drawn with the nearest material found on the
+// node itself or its ancestors.
+func (list *DrawList) Collect(root *Node) {
+	list.collect(root, nil)
+}
+
+// Draw applies the material of every item and draws its mesh. Materials are only
+// applied, if the item has one.
+func (list *DrawList) Draw() {
+	for i := range list.Items {
+		item := &list.Items[i]
+		if item.Material != nil {
+			item.Material.Apply(item.World)
+		}
+		item.Mesh.Draw()
+	}
+}
+
+func (list *DrawList) collect(node *Node, material Material) {
+	if node.Visible {
+		if node.Material != nil {
+			material = node.Material
+		}
+		if node.Mesh != nil {
+			list.Items = append(list.Items, DrawItem{Node: node, Mesh: node.Mesh, Material: material, World: node.World()})
+		}
+		if node.Light != nil {
+			list.Lights = append(list.Lights, LightItem{Node: node, Light: node.Light, World: node.World()})
+		}
+		if node.Camera != nil {
+			list.Cameras = append(list.Cameras, node)
+		}
+		for _, child := range node.children {
+			list.collect(child, material)
+		}
+	}
+}
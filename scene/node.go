@@ -0,0 +1,234 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+// Package scene provides a scene graph of nodes with a transform hierarchy.
+package scene
+
+import (
+	"errors"
+	"github.com/vbsw/opengl-go-example/vmath"
+)
+
+// Mesh is attached to a node to draw geometry.
+type Mesh interface {
+	// Draw issues the draw call(s) of the mesh.
+	Draw()
+}
+
+// Material is attached to a node to set the state for drawing its mesh.
+type Material interface {
+	// Apply sets program, textures and uniforms. Model is the world matrix of the node.
+	Apply(model vmath.Mat4)
+}
+
+// Camera is attached to a node to view the scene. The view matrix is the inverse
+// of the world matrix of the node, the camera looks along the negative z axis.
+type Camera interface {
+	Projection() vmath.Mat4
+}
+
+// Light is attached to a node to illuminate the scene. Its position and direction are
+// taken from the world matrix of the node.
+type Light interface{}
+
+// Node is an element of the scene graph with a local transform relative to its parent.
+type Node struct {
+	Name string
+	// nodes that are not visible are skipped with all their children
+	Visible  bool
+	Mesh     Mesh
+	Material Material
+	Camera   Camera
+	Light    Light
+	position vmath.Vec3
+	rotation vmath.Quat
+	scale    vmath.Vec3
+	parent   *Node
+	children []*Node
+	local    vmath.Mat4
+	world    vmath.Mat4
+	// local matrix needs to be recomputed
+	localDirty bool
+	// world matrix needs to be recomputed
+	worldDirty bool
+}
+
+// NewNode returns a new visible instance of Node with identity transform.
+func NewNode(name string) *Node {
+	node := new(Node)
+	node.Name = name
+	node.Visible = true
+	node.rotation = vmath.QuatIdent()
+	node.scale = vmath.Vec3{1, 1, 1}
+	node.local = vmath.Ident4()
+	node.world = vmath.Ident4()
+	return node
+}
+
+// Position returns the local translation.
+func (node *Node) Position() vmath.Vec3 {
+	return node.position
+}
+
+// Rotation returns the local rotation.
+func (node *Node) Rotation() vmath.Quat {
+	return node.rotation
+}
+
+// Scale returns the local scale.
+func (node *Node) Scale() vmath.Vec3 {
+	return node.scale
+}
+
+// SetPosition sets the local translation.
+func (node *Node) SetPosition(position vmath.Vec3) {
+	node.position = position
+	node.invalidateLocal()
+}
+
+// SetRotation sets the local rotation.
+func (node *Node) SetRotation(rotation vmath.Quat) {
+	node.rotation = rotation
+	node.invalidateLocal()
+}
+
+// SetScale sets the local scale.
+func (node *Node) SetScale(scale vmath.Vec3) {
+	node.scale = scale
+	node.invalidateLocal()
+}
+
+// Translate moves the node by offset in parent space.
+func (node *Node) Translate(offset vmath.Vec3) {
+	node.SetPosition(node.position.Add(offset))
+}
+
+// Rotate applies rotation after the current local rotation.
+func (node *Node) Rotate(rotation vmath.Quat) {
+	node.SetRotation(rotation.Mul(node.rotation).Normalize())
+}
+
+// LookAt rotates the node, so that its negative z axis points to target (both in parent space).
+func (node *Node) LookAt(target, up vmath.Vec3) {
+	inv, ok := vmath.LookAt(node.position, target, up).Inverse()
+	if ok {
+		node.SetRotation(vmath.QuatFromMat4(inv))
+	}
+}
+
+// Local returns the local matrix (translation * rotation * scale).
+func (node *Node) Local() vmath.Mat4 {
+	if node.localDirty {
+		translation := vmath.Translate(node.position)
+		scale := vmath.Scale(node.scale)
+		node.local = translation.Mul(node.rotation.Mat4()).Mul(scale)
+		node.localDirty = false
+	}
+	return node.local
+}
+
+// World returns the world matrix (parent's world matrix * local matrix).
+// It is cached and only recomputed, if the node or one of its parents has changed.
+func (node *Node) World() vmath.Mat4 {
+	if node.worldDirty {
+		if node.parent != nil {
+			node.world = node.parent.World().Mul(node.Local())
+		} else {
+			node.world = node.Local()
+		}
+		node.worldDirty = false
+	}
+	return node.world
+}
+
+// WorldPosition returns the position of the node in world space.
+func (node *Node) WorldPosition() vmath.Vec3 {
+	return node.World().Translation()
+}
+
+// View returns the inverse of the world matrix, i.e. the view matrix of a camera attached to node.
+func (node *Node) View() vmath.Mat4 {
+	view, _ := node.World().Inverse()
+	return view
+}
+
+// Parent returns the parent node or nil.
+func (node *Node) Parent() *Node {
+	return node.parent
+}
+
+// Children returns the child nodes. The returned slice must not be modified.
+func (node *Node) Children() []*Node {
+	return node.children
+}
+
+// Add appends child to the children of node. If child has a parent, it is removed from it.
+// Adding node itself or one of its ancestors would create a cycle and returns an error.
+func (node *Node) Add(child *Node) error {
+	for ancestor := node; ancestor != nil; ancestor = ancestor.parent {
+		if ancestor == child {
+			return errors.New("scene: node " + child.Name + " can't be added to itself or its descendant " + node.Name)
+		}
+	}
+	if child.parent != nil {
+		child.parent.Remove(child)
+	}
+	child.parent = node
+	node.children = append(node.children, child)
+	child.invalidateWorld()
+	return nil
+}
+
+// Remove removes child from the children of node.
+func (node *Node) Remove(child *Node) {
+	for i, c := range node.children {
+		if c == child {
+			copy(node.children[i:], node.children[i+1:])
+			node.children[len(node.children)-1] = nil
+			node.children = node.children[:len(node.children)-1]
+			child.parent = nil
+			child.invalidateWorld()
+			break
+		}
+	}
+}
+
+// Find returns the first node in the subtree of node (including node) with name or nil.
+func (node *Node) Find(name string) *Node {
+	if node.Name == name {
+		return node
+	}
+	for _, child := range node.children {
+		if found := child.Find(name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// Walk calls visit for node and all its descendants in depth-first order. If visit returns
+// false, the children of the visited node are skipped.
+func (node *Node) Walk(visit func(node *Node) bool) {
+	if visit(node) {
+		for _, child := range node.children {
+			child.Walk(visit)
+		}
+	}
+}
+
+func (node *Node) invalidateLocal() {
+	node.localDirty = true
+	node.invalidateWorld()
+}
+
+func (node *Node) invalidateWorld() {
+	// if world is already dirty, all descendants are dirty, too
+	if !node.worldDirty {
+		node.worldDirty = true
+		for _, child := range node.children {
+			child.invalidateWorld()
+		}
+	}
+}
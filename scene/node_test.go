@@ -0,0 +1,131 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package scene
+
+import (
+	"github.com/vbsw/opengl-go-example/vmath"
+	"math"
+	"testing"
+)
+
+type testMesh struct {
+	draws int
+}
+
+type testMaterial struct {
+	models []vmath.Mat4
+}
+
+func (mesh *testMesh) Draw() {
+	mesh.draws++
+}
+
+func (material *testMaterial) Apply(model vmath.Mat4) {
+	material.models = append(material.models, model)
+}
+
+func TestWorldMatrix(t *testing.T) {
+	root := NewNode("root")
+	child := NewNode("child")
+	grandchild := NewNode("grandchild")
+	root.Add(child)
+	child.Add(grandchild)
+	root.SetPosition(vmath.Vec3{1, 0, 0})
+	child.SetRotation(vmath.QuatAxisAngle(vmath.Vec3{0, 0, 1}, math.Pi/2))
+	grandchild.SetPosition(vmath.Vec3{1, 0, 0})
+	if got, want := grandchild.WorldPosition(), (vmath.Vec3{1, 1, 0}); !got.ApproxEqual(want) {
+		t.Errorf("world position %v, want %v", got, want)
+	}
+	// cached world matrix must be invalidated by changes of ancestors
+	root.SetScale(vmath.Vec3{2, 2, 2})
+	if got, want := grandchild.WorldPosition(), (vmath.Vec3{1, 2, 0}); !got.ApproxEqual(want) {
+		t.Errorf("after scale: world position %v, want %v", got, want)
+	}
+	child.Remove(grandchild)
+	if got, want := grandchild.WorldPosition(), (vmath.Vec3{1, 0, 0}); !got.ApproxEqual(want) {
+		t.Errorf("after remove: world position %v, want %v", got, want)
+	}
+}
+
+func TestReparent(t *testing.T) {
+	a, b, c := NewNode("a"), NewNode("b"), NewNode("c")
+	a.Add(c)
+	b.Add(c)
+	if len(a.Children()) != 0 || len(b.Children()) != 1 || c.Parent() != b {
+		t.Errorf("children of a %d, children of b %d", len(a.Children()), len(b.Children()))
+	}
+	if b.Find("c") != c || a.Find("c") != nil {
+		t.Errorf("Find failed")
+	}
+}
+
+func TestAddCycle(t *testing.T) {
+	a, b, c := NewNode("a"), NewNode("b"), NewNode("c")
+	a.Add(b)
+	b.Add(c)
+	tests := []struct {
+		name          string
+		parent, child *Node
+	}{
+		{"itself", a, a},
+		{"parent", b, a},
+		{"grandparent", c, a},
+	}
+	for _, test := range tests {
+		if err := test.parent.Add(test.child); err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+	if a.Parent() != nil || len(a.Children()) != 1 || b.Parent() != a || c.Parent() != b || len(c.Children()) != 0 {
+		t.Errorf("tree changed")
+	}
+	// moving a descendant up is no cycle
+	if err := a.Add(c); err != nil || c.Parent() != a || len(b.Children()) != 0 {
+		t.Error(err)
+	}
+}
+
+func TestLookAt(t *testing.T) {
+	node := NewNode("camera")
+	node.SetPosition(vmath.Vec3{0, 0, 5})
+	node.LookAt(vmath.Vec3{5, 0, 5}, vmath.Vec3{0, 1, 0})
+	forward := node.World().TransformDir(vmath.Vec3{0, 0, -1})
+	if !forward.ApproxEqual(vmath.Vec3{1, 0, 0}) {
+		t.Errorf("forward %v", forward)
+	}
+	if p := node.View().TransformPoint(vmath.Vec3{0, 0, 5}); !p.ApproxEqual(vmath.Vec3{}) {
+		t.Errorf("view of camera position %v", p)
+	}
+}
+
+func TestDrawList(t *testing.T) {
+	var list DrawList
+	mesh := new(testMesh)
+	material := new(testMaterial)
+	root := NewNode("root")
+	root.Material = material
+	visible, hidden, light := NewNode("visible"), NewNode("hidden"), NewNode("light")
+	visible.Mesh, hidden.Mesh, light.Light = mesh, mesh, "sun"
+	visible.SetPosition(vmath.Vec3{0, 3, 0})
+	hidden.Visible = false
+	hidden.Add(NewNode("hidden child"))
+	hidden.Children()[0].Mesh = mesh
+	root.Add(visible)
+	root.Add(hidden)
+	root.Add(light)
+	list.Collect(root)
+	if len(list.Items) != 1 || len(list.Lights) != 1 {
+		t.Fatalf("items %d, lights %d", len(list.Items), len(list.Lights))
+	}
+	list.Draw()
+	if mesh.draws != 1 || len(material.models) != 1 || material.models[0].Translation() != (vmath.Vec3{0, 3, 0}) {
+		t.Errorf("draws %d, models %v", mesh.draws, material.models)
+	}
+	list.Reset()
+	if len(list.Items) != 0 {
+		t.Errorf("Reset: %d items", len(list.Items))
+	}
+}
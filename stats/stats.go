@@ -0,0 +1,208 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+// Package stats collects frame timing statistics.
+package stats
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultCapacity is the number of frames of one minute at 60 frames per second.
+// Fewer than 1000 frames make the 0.1% low the slowest frame.
+const DefaultCapacity = 3600
+
+// Frame is for storing the timing of one frame in seconds.
+type Frame struct {
+	// time spent on CPU from start of frame until buffer swap
+	CPU float64 `json:"cpu"`
+	// time spent in buffer swap
+	Swap float64 `json:"swap"`
+	// time from start of previous frame to start of this frame
+	Interval float64 `json:"interval"`
+}
+
+// Metric is for storing the statistics of one timing value in seconds.
+type Metric struct {
+	Average float64 `json:"average"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+	// average of the slowest 1% of frames (at least the slowest frame)
+	Low1 float64 `json:"low1"`
+	// average of the slowest 0.1% of frames (at least the slowest frame, so it equals
+	// Max with fewer than 1000 frames)
+	Low01 float64 `json:"low01"`
+}
+
+// Summary is for storing the statistics of all frames.
+type Summary struct {
+	Frames   int    `json:"frames"`
+	CPU      Metric `json:"cpu"`
+	Swap     Metric `json:"swap"`
+	Interval Metric `json:"interval"`
+	// frames per second derived from Interval
+	FPS      float64 `json:"fps"`
+	Low1FPS  float64 `json:"low1Fps"`
+	Low01FPS float64 `json:"low01Fps"`
+}
+
+// Stats is a ring buffer of frame timings.
+type Stats struct {
+	frames []Frame
+	next   int
+	full   bool
+}
+
+// New returns a new instance of Stats, that stores the timings of the last capacity
+// frames. Capacity less than 1 is set to 1.
+func New(capacity int) *Stats {
+	stats := new(Stats)
+	if capacity < 1 {
+		capacity = 1
+	}
+	stats.frames = make([]Frame, capacity)
+	return stats
+}
+
+// Add stores frame. If buffer is full, the oldest frame is overwritten.
+func (stats *Stats) Add(frame Frame) {
+	stats.frames[stats.next] = frame
+	stats.next++
+	if stats.next == len(stats.frames) {
+		stats.next = 0
+		stats.full = true
+	}
+}
+
+// Len returns the number of stored frames.
+func (stats *Stats) Len() int {
+	if stats.full {
+		return len(stats.frames)
+	}
+	return stats.next
+}
+
+// Cap returns the maximum number of stored frames.
+func (stats *Stats) Cap() int {
+	return len(stats.frames)
+}
+
+// Frames returns the stored frames, oldest first.
+func (stats *Stats) Frames() []Frame {
+	frames := make([]Frame, 0, stats.Len())
+	if stats.full {
+		frames = append(frames, stats.frames[stats.next:]...)
+	}
+	return append(frames, stats.frames[:stats.next]...)
+}
+
+// Summary returns the statistics of the stored frames.
+func (stats *Stats) Summary() Summary {
+	var summary Summary
+	frames := stats.Frames()
+	values := make([]float64, len(frames))
+	summary.Frames = len(frames)
+	summary.CPU = newMetric(frames, values, func(frame *Frame) float64 { return frame.CPU })
+	summary.Swap = newMetric(frames, values, func(frame *Frame) float64 { return frame.Swap })
+	summary.Interval = newMetric(frames, values, func(frame *Frame) float64 { return frame.Interval })
+	summary.FPS = perSecond(summary.Interval.Average)
+	summary.Low1FPS = perSecond(summary.Interval.Low1)
+	summary.Low01FPS = perSecond(summary.Interval.Low01)
+	return summary
+}
+
+// String returns a short description of summary, e.g. for the window title.
+func (summary Summary) String() string {
+	return fmt.Sprintf("%.1f fps, %.2f ms (cpu %.2f ms, swap %.2f ms), 1%% low %.1f fps, 0.1%% low %.1f fps",
+		summary.FPS, summary.Interval.Average*1000, summary.CPU.Average*1000, summary.Swap.Average*1000, summary.Low1FPS, summary.Low01FPS)
+}
+
+// Save writes stored frames and summary to file. If file has extension ".csv", CSV is written,
+// otherwise JSON.
+func (stats *Stats) Save(path string) error {
+	file, err := os.Create(path)
+	if err == nil {
+		if strings.ToLower(filepath.Ext(path)) == ".csv" {
+			err = stats.WriteCSV(file)
+		} else {
+			err = stats.WriteJSON(file)
+		}
+		errClose := file.Close()
+		if err == nil {
+			err = errClose
+		}
+	}
+	return err
+}
+
+// WriteJSON writes summary and stored frames as JSON to w.
+func (stats *Stats) WriteJSON(w io.Writer) error {
+	data := struct {
+		Summary Summary `json:"summary"`
+		Frames  []Frame `json:"frames"`
+	}{stats.Summary(), stats.Frames()}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(&data)
+}
+
+// WriteCSV writes stored frames as CSV to w. Values are in milliseconds.
+func (stats *Stats) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"frame", "cpu_ms", "swap_ms", "interval_ms"})
+	for i, frame := range stats.Frames() {
+		writer.Write([]string{strconv.Itoa(i), formatMillis(frame.CPU), formatMillis(frame.Swap), formatMillis(frame.Interval)})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func newMetric(frames []Frame, values []float64, value func(frame *Frame) float64) Metric {
+	var metric Metric
+	if len(frames) > 0 {
+		var sum float64
+		for i := range frames {
+			values[i] = value(&frames[i])
+			sum += values[i]
+		}
+		// slowest first
+		sort.Sort(sort.Reverse(sort.Float64Slice(values)))
+		metric.Average = sum / float64(len(values))
+		metric.Max = values[0]
+		metric.Min = values[len(values)-1]
+		metric.Low1 = averageOfSlowest(values, 0.01)
+		metric.Low01 = averageOfSlowest(values, 0.001)
+	}
+	return metric
+}
+
+func averageOfSlowest(sorted []float64, fraction float64) float64 {
+	var sum float64
+	n := int(math.Ceil(float64(len(sorted)) * fraction))
+	for _, value := range sorted[:n] {
+		sum += value
+	}
+	return sum / float64(n)
+}
+
+func perSecond(seconds float64) float64 {
+	if seconds > 0 {
+		return 1 / seconds
+	}
+	return 0
+}
+
+func formatMillis(seconds float64) string {
+	return strconv.FormatFloat(seconds*1000, 'f', 3, 64)
+}
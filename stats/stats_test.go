@@ -0,0 +1,127 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package stats
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestMetric(t *testing.T) {
+	tests := []struct {
+		name      string
+		intervals []float64
+		metric    Metric
+	}{
+		{"empty", nil, Metric{}},
+		{"one", []float64{0.02}, Metric{0.02, 0.02, 0.02, 0.02, 0.02}},
+		// the lows are at least the slowest frame
+		{"few", []float64{0.01, 0.03, 0.02}, Metric{0.02, 0.01, 0.03, 0.03, 0.03}},
+		// 1% of 200 frames are the 2 slowest
+		{"1% low", append(repeat(0.01, 198), 0.05, 0.03), Metric{0.0103, 0.01, 0.05, 0.04, 0.05}},
+		// 0.1% of 2000 frames are the 2 slowest, 1% are 20
+		{"0.1% low", append(repeat(0.01, 1998), 0.1, 0.06), Metric{0.01007, 0.01, 0.1, 0.017, 0.08}},
+	}
+	for _, test := range tests {
+		stats := New(len(test.intervals) + 1)
+		for _, interval := range test.intervals {
+			stats.Add(Frame{Interval: interval})
+		}
+		metric := stats.Summary().Interval
+		if !approxEqual(metric.Average, test.metric.Average) || !approxEqual(metric.Min, test.metric.Min) || !approxEqual(metric.Max, test.metric.Max) ||
+			!approxEqual(metric.Low1, test.metric.Low1) || !approxEqual(metric.Low01, test.metric.Low01) {
+			t.Errorf("%s: %+v, want %+v", test.name, metric, test.metric)
+		}
+	}
+}
+
+func TestFPS(t *testing.T) {
+	stats := New(100)
+	for i := 0; i < 99; i++ {
+		stats.Add(Frame{Interval: 0.01})
+	}
+	stats.Add(Frame{Interval: 0.1})
+	summary := stats.Summary()
+	if summary.Frames != 100 || !approxEqual(summary.FPS, 1/0.0109) || !approxEqual(summary.Low1FPS, 10) || !approxEqual(summary.Low01FPS, 10) {
+		t.Errorf("%+v", summary)
+	}
+	if empty := New(10).Summary(); empty.FPS != 0 || empty.Low1FPS != 0 {
+		t.Errorf("%+v", empty)
+	}
+}
+
+func TestWraparound(t *testing.T) {
+	stats := New(3)
+	tests := []struct {
+		add    float64
+		frames []float64
+	}{
+		{1, []float64{1}},
+		{2, []float64{1, 2}},
+		{3, []float64{1, 2, 3}},
+		{4, []float64{2, 3, 4}},
+		{5, []float64{3, 4, 5}},
+		{6, []float64{4, 5, 6}},
+		{7, []float64{5, 6, 7}},
+	}
+	for _, test := range tests {
+		stats.Add(Frame{CPU: test.add})
+		frames := stats.Frames()
+		if stats.Len() != len(test.frames) || len(frames) != len(test.frames) || stats.Cap() != 3 {
+			t.Fatalf("after %g: %d frames, want %d", test.add, len(frames), len(test.frames))
+		}
+		for i, frame := range frames {
+			if frame.CPU != test.frames[i] {
+				t.Errorf("after %g: frame %d is %g, want %g", test.add, i, frame.CPU, test.frames[i])
+			}
+		}
+	}
+	if max := stats.Summary().CPU.Max; max != 7 {
+		t.Error(max)
+	}
+}
+
+func TestZeroCapacity(t *testing.T) {
+	for _, capacity := range []int{0, -1} {
+		stats := New(capacity)
+		stats.Add(Frame{CPU: 1})
+		stats.Add(Frame{CPU: 2})
+		if stats.Cap() != 1 || stats.Len() != 1 || stats.Frames()[0].CPU != 2 {
+			t.Errorf("capacity %d: %d of %d frames", capacity, stats.Len(), stats.Cap())
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	stats := New(2)
+	stats.Add(Frame{CPU: 0.001, Swap: 0.002, Interval: 0.0165})
+	var buffer bytes.Buffer
+	if err := stats.WriteCSV(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	want := "frame,cpu_ms,swap_ms,interval_ms\n0,1.000,2.000,16.500\n"
+	if got := buffer.String(); got != want {
+		t.Errorf("%q, want %q", got, want)
+	}
+	buffer.Reset()
+	if err := stats.WriteJSON(&buffer); err != nil || !strings.Contains(buffer.String(), `"frames": 1`) {
+		t.Error(err, buffer.String())
+	}
+}
+
+func repeat(value float64, n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = value
+	}
+	return values
+}
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
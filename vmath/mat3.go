@@ -0,0 +1,102 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package vmath
+
+// Mat3 is a 3x3 matrix in column-major order (element at row r and column c is m[c*3+r]).
+type Mat3 [9]float32
+
+// Ident3 returns the identity matrix.
+func Ident3() Mat3 {
+	return Mat3{1, 0, 0, 0, 1, 0, 0, 0, 1}
+}
+
+// Mat3FromRows returns a matrix from values in row-major order.
+func Mat3FromRows(m00, m01, m02, m10, m11, m12, m20, m21, m22 float32) Mat3 {
+	return Mat3{m00, m10, m20, m01, m11, m21, m02, m12, m22}
+}
+
+// At returns the element at row and column.
+func (m Mat3) At(row, column int) float32 {
+	return m[column*3+row]
+}
+
+// Col returns the column with index column.
+func (m Mat3) Col(column int) Vec3 {
+	return Vec3{m[column*3], m[column*3+1], m[column*3+2]}
+}
+
+// Row returns the row with index row.
+func (m Mat3) Row(row int) Vec3 {
+	return Vec3{m[row], m[row+3], m[row+6]}
+}
+
+// Mul returns m * n.
+func (m Mat3) Mul(n Mat3) Mat3 {
+	var result Mat3
+	for c := 0; c < 3; c++ {
+		for r := 0; r < 3; r++ {
+			result[c*3+r] = m[r]*n[c*3] + m[3+r]*n[c*3+1] + m[6+r]*n[c*3+2]
+		}
+	}
+	return result
+}
+
+// MulVec3 returns m * v.
+func (m Mat3) MulVec3(v Vec3) Vec3 {
+	return Vec3{
+		m[0]*v[0] + m[3]*v[1] + m[6]*v[2],
+		m[1]*v[0] + m[4]*v[1] + m[7]*v[2],
+		m[2]*v[0] + m[5]*v[1] + m[8]*v[2]}
+}
+
+// Transpose returns the transpose of m.
+func (m Mat3) Transpose() Mat3 {
+	return Mat3{m[0], m[3], m[6], m[1], m[4], m[7], m[2], m[5], m[8]}
+}
+
+// Det returns the determinant of m.
+func (m Mat3) Det() float32 {
+	return m[0]*(m[4]*m[8]-m[7]*m[5]) - m[3]*(m[1]*m[8]-m[7]*m[2]) + m[6]*(m[1]*m[5]-m[4]*m[2])
+}
+
+// Inverse returns the inverse of m. If m is not invertible, false is returned.
+func (m Mat3) Inverse() (Mat3, bool) {
+	det := m.Det()
+	if det == 0 {
+		return Mat3{}, false
+	}
+	invDet := 1 / det
+	return Mat3{
+		(m[4]*m[8] - m[7]*m[5]) * invDet,
+		(m[7]*m[2] - m[1]*m[8]) * invDet,
+		(m[1]*m[5] - m[4]*m[2]) * invDet,
+		(m[6]*m[5] - m[3]*m[8]) * invDet,
+		(m[0]*m[8] - m[6]*m[2]) * invDet,
+		(m[3]*m[2] - m[0]*m[5]) * invDet,
+		(m[3]*m[7] - m[6]*m[4]) * invDet,
+		(m[6]*m[1] - m[0]*m[7]) * invDet,
+		(m[0]*m[4] - m[3]*m[1]) * invDet}, true
+}
+
+// Mat4 returns m extended to a 4x4 matrix.
+func (m Mat3) Mat4() Mat4 {
+	return Mat4{m[0], m[1], m[2], 0, m[3], m[4], m[5], 0, m[6], m[7], m[8], 0, 0, 0, 0, 1}
+}
+
+// ApproxEqual returns true, if all elements of m and n are approximately equal.
+func (m Mat3) ApproxEqual(n Mat3) bool {
+	for i := range m {
+		if !ApproxEqual(m[i], n[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Ptr returns a pointer to the first element, e.g. for gl.UniformMatrix3fv.
+func (m *Mat3) Ptr() *float32 {
+	return &m[0]
+}
@@ -0,0 +1,244 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package vmath
+
+import (
+	"math"
+)
+
+// Mat4 is a 4x4 matrix in column-major order (element at row r and column c is m[c*4+r]).
+type Mat4 [16]float32
+
+// Ident4 returns the identity matrix.
+func Ident4() Mat4 {
+	return Mat4{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1}
+}
+
+// Mat4FromRows returns a matrix from values in row-major order.
+func Mat4FromRows(rows [16]float32) Mat4 {
+	return Mat4(rows).Transpose()
+}
+
+// Translate returns a translation matrix.
+func Translate(v Vec3) Mat4 {
+	return Mat4{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, v[0], v[1], v[2], 1}
+}
+
+// Scale returns a scaling matrix.
+func Scale(v Vec3) Mat4 {
+	return Mat4{v[0], 0, 0, 0, 0, v[1], 0, 0, 0, 0, v[2], 0, 0, 0, 0, 1}
+}
+
+// RotateX returns a rotation matrix around the x axis (angle in radians).
+func RotateX(angle float32) Mat4 {
+	sin, cos := sincos(angle)
+	return Mat4{1, 0, 0, 0, 0, cos, sin, 0, 0, -sin, cos, 0, 0, 0, 0, 1}
+}
+
+// RotateY returns a rotation matrix around the y axis (angle in radians).
+func RotateY(angle float32) Mat4 {
+	sin, cos := sincos(angle)
+	return Mat4{cos, 0, -sin, 0, 0, 1, 0, 0, sin, 0, cos, 0, 0, 0, 0, 1}
+}
+
+// RotateZ returns a rotation matrix around the z axis (angle in radians).
+func RotateZ(angle float32) Mat4 {
+	sin, cos := sincos(angle)
+	return Mat4{cos, sin, 0, 0, -sin, cos, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1}
+}
+
+// Rotate returns a rotation matrix around axis (angle in radians).
+func Rotate(axis Vec3, angle float32) Mat4 {
+	a := axis.Normalize()
+	sin, cos := sincos(angle)
+	t := 1 - cos
+	return Mat4{
+		t*a[0]*a[0] + cos, t*a[0]*a[1] + sin*a[2], t*a[0]*a[2] - sin*a[1], 0,
+		t*a[0]*a[1] - sin*a[2], t*a[1]*a[1] + cos, t*a[1]*a[2] + sin*a[0], 0,
+		t*a[0]*a[2] + sin*a[1], t*a[1]*a[2] - sin*a[0], t*a[2]*a[2] + cos, 0,
+		0, 0, 0, 1}
+}
+
+// Perspective returns a perspective projection matrix. Fovy is the vertical field of
+// view in radians. Depth is mapped to -1 (near) to 1 (far).
+func Perspective(fovy, aspect, near, far float32) Mat4 {
+	f := float32(1 / math.Tan(float64(fovy)/2))
+	return Mat4{
+		f / aspect, 0, 0, 0,
+		0, f, 0, 0,
+		0, 0, (far + near) / (near - far), -1,
+		0, 0, 2 * far * near / (near - far), 0}
+}
+
+// Ortho returns an orthographic projection matrix. Depth is mapped to -1 (near) to 1 (far).
+func Ortho(left, right, bottom, top, near, far float32) Mat4 {
+	return Mat4{
+		2 / (right - left), 0, 0, 0,
+		0, 2 / (top - bottom), 0, 0,
+		0, 0, -2 / (far - near), 0,
+		-(right + left) / (right - left), -(top + bottom) / (top - bottom), -(far + near) / (far - near), 1}
+}
+
+// Ortho2D returns an orthographic projection matrix with near -1 and far 1.
+func Ortho2D(left, right, bottom, top float32) Mat4 {
+	return Ortho(left, right, bottom, top, -1, 1)
+}
+
+// LookAt returns a view matrix of a camera at eye looking at center.
+func LookAt(eye, center, up Vec3) Mat4 {
+	f := center.Sub(eye).Normalize()
+	s := f.Cross(up).Normalize()
+	u := s.Cross(f)
+	return Mat4{
+		s[0], u[0], -f[0], 0,
+		s[1], u[1], -f[1], 0,
+		s[2], u[2], -f[2], 0,
+		-s.Dot(eye), -u.Dot(eye), f.Dot(eye), 1}
+}
+
+// At returns the element at row and column.
+func (m Mat4) At(row, column int) float32 {
+	return m[column*4+row]
+}
+
+// Set sets the element at row and column.
+func (m *Mat4) Set(row, column int, value float32) {
+	m[column*4+row] = value
+}
+
+// Col returns the column with index column.
+func (m Mat4) Col(column int) Vec4 {
+	return Vec4{m[column*4], m[column*4+1], m[column*4+2], m[column*4+3]}
+}
+
+// Row returns the row with index row.
+func (m Mat4) Row(row int) Vec4 {
+	return Vec4{m[row], m[row+4], m[row+8], m[row+12]}
+}
+
+// Mul returns m * n.
+func (m Mat4) Mul(n Mat4) Mat4 {
+	var result Mat4
+	for c := 0; c < 4; c++ {
+		n0, n1, n2, n3 := n[c*4], n[c*4+1], n[c*4+2], n[c*4+3]
+		result[c*4] = m[0]*n0 + m[4]*n1 + m[8]*n2 + m[12]*n3
+		result[c*4+1] = m[1]*n0 + m[5]*n1 + m[9]*n2 + m[13]*n3
+		result[c*4+2] = m[2]*n0 + m[6]*n1 + m[10]*n2 + m[14]*n3
+		result[c*4+3] = m[3]*n0 + m[7]*n1 + m[11]*n2 + m[15]*n3
+	}
+	return result
+}
+
+// MulVec4 returns m * v.
+func (m Mat4) MulVec4(v Vec4) Vec4 {
+	return Vec4{
+		m[0]*v[0] + m[4]*v[1] + m[8]*v[2] + m[12]*v[3],
+		m[1]*v[0] + m[5]*v[1] + m[9]*v[2] + m[13]*v[3],
+		m[2]*v[0] + m[6]*v[1] + m[10]*v[2] + m[14]*v[3],
+		m[3]*v[0] + m[7]*v[1] + m[11]*v[2] + m[15]*v[3]}
+}
+
+// TransformPoint returns point p transformed by m (w = 1) after perspective division.
+func (m Mat4) TransformPoint(p Vec3) Vec3 {
+	v := m.MulVec4(p.Vec4(1))
+	if v[3] != 0 && v[3] != 1 {
+		return v.Vec3().Scale(1 / v[3])
+	}
+	return v.Vec3()
+}
+
+// TransformDir returns direction d transformed by m (w = 0).
+func (m Mat4) TransformDir(d Vec3) Vec3 {
+	return m.MulVec4(d.Vec4(0)).Vec3()
+}
+
+// Transpose returns the transpose of m.
+func (m Mat4) Transpose() Mat4 {
+	return Mat4{
+		m[0], m[4], m[8], m[12],
+		m[1], m[5], m[9], m[13],
+		m[2], m[6], m[10], m[14],
+		m[3], m[7], m[11], m[15]}
+}
+
+// Det returns the determinant of m.
+func (m Mat4) Det() float32 {
+	inv := m.cofactors0()
+	return m[0]*inv[0] + m[1]*inv[4] + m[2]*inv[8] + m[3]*inv[12]
+}
+
+// Inverse returns the inverse of m. If m is not invertible, false is returned.
+func (m Mat4) Inverse() (Mat4, bool) {
+	var inv Mat4
+	c := m.cofactors0()
+	det := m[0]*c[0] + m[1]*c[4] + m[2]*c[8] + m[3]*c[12]
+	if det == 0 {
+		return inv, false
+	}
+	inv[0], inv[4], inv[8], inv[12] = c[0], c[4], c[8], c[12]
+	inv[1] = -m[1]*m[10]*m[15] + m[1]*m[11]*m[14] + m[9]*m[2]*m[15] - m[9]*m[3]*m[14] - m[13]*m[2]*m[11] + m[13]*m[3]*m[10]
+	inv[5] = m[0]*m[10]*m[15] - m[0]*m[11]*m[14] - m[8]*m[2]*m[15] + m[8]*m[3]*m[14] + m[12]*m[2]*m[11] - m[12]*m[3]*m[10]
+	inv[9] = -m[0]*m[9]*m[15] + m[0]*m[11]*m[13] + m[8]*m[1]*m[15] - m[8]*m[3]*m[13] - m[12]*m[1]*m[11] + m[12]*m[3]*m[9]
+	inv[13] = m[0]*m[9]*m[14] - m[0]*m[10]*m[13] - m[8]*m[1]*m[14] + m[8]*m[2]*m[13] + m[12]*m[1]*m[10] - m[12]*m[2]*m[9]
+	inv[2] = m[1]*m[6]*m[15] - m[1]*m[7]*m[14] - m[5]*m[2]*m[15] + m[5]*m[3]*m[14] + m[13]*m[2]*m[7] - m[13]*m[3]*m[6]
+	inv[6] = -m[0]*m[6]*m[15] + m[0]*m[7]*m[14] + m[4]*m[2]*m[15] - m[4]*m[3]*m[14] - m[12]*m[2]*m[7] + m[12]*m[3]*m[6]
+	inv[10] = m[0]*m[5]*m[15] - m[0]*m[7]*m[13] - m[4]*m[1]*m[15] + m[4]*m[3]*m[13] + m[12]*m[1]*m[7] - m[12]*m[3]*m[5]
+	inv[14] = -m[0]*m[5]*m[14] + m[0]*m[6]*m[13] + m[4]*m[1]*m[14] - m[4]*m[2]*m[13] - m[12]*m[1]*m[6] + m[12]*m[2]*m[5]
+	inv[3] = -m[1]*m[6]*m[11] + m[1]*m[7]*m[10] + m[5]*m[2]*m[11] - m[5]*m[3]*m[10] - m[9]*m[2]*m[7] + m[9]*m[3]*m[6]
+	inv[7] = m[0]*m[6]*m[11] - m[0]*m[7]*m[10] - m[4]*m[2]*m[11] + m[4]*m[3]*m[10] + m[8]*m[2]*m[7] - m[8]*m[3]*m[6]
+	inv[11] = -m[0]*m[5]*m[11] + m[0]*m[7]*m[9] + m[4]*m[1]*m[11] - m[4]*m[3]*m[9] - m[8]*m[1]*m[7] + m[8]*m[3]*m[5]
+	inv[15] = m[0]*m[5]*m[10] - m[0]*m[6]*m[9] - m[4]*m[1]*m[10] + m[4]*m[2]*m[9] + m[8]*m[1]*m[6] - m[8]*m[2]*m[5]
+	invDet := 1 / det
+	for i := range inv {
+		inv[i] *= invDet
+	}
+	return inv, true
+}
+
+// Mat3 returns the upper left 3x3 matrix of m.
+func (m Mat4) Mat3() Mat3 {
+	return Mat3{m[0], m[1], m[2], m[4], m[5], m[6], m[8], m[9], m[10]}
+}
+
+// NormalMatrix returns the inverse transpose of the upper left 3x3 matrix of m. It transforms
+// normals, if m contains non-uniform scaling.
+func (m Mat4) NormalMatrix() Mat3 {
+	inv, ok := m.Mat3().Inverse()
+	if ok {
+		return inv.Transpose()
+	}
+	return m.Mat3()
+}
+
+// Translation returns the translation part of m.
+func (m Mat4) Translation() Vec3 {
+	return Vec3{m[12], m[13], m[14]}
+}
+
+// ApproxEqual returns true, if all elements of m and n are approximately equal.
+func (m Mat4) ApproxEqual(n Mat4) bool {
+	for i := range m {
+		if !ApproxEqual(m[i], n[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Ptr returns a pointer to the first element, e.g. for gl.UniformMatrix4fv.
+func (m *Mat4) Ptr() *float32 {
+	return &m[0]
+}
+
+// cofactors0 returns the cofactors of the first row (stored at index 0, 4, 8 and 12).
+func (m *Mat4) cofactors0() Mat4 {
+	var c Mat4
+	c[0] = m[5]*m[10]*m[15] - m[5]*m[11]*m[14] - m[9]*m[6]*m[15] + m[9]*m[7]*m[14] + m[13]*m[6]*m[11] - m[13]*m[7]*m[10]
+	c[4] = -m[4]*m[10]*m[15] + m[4]*m[11]*m[14] + m[8]*m[6]*m[15] - m[8]*m[7]*m[14] - m[12]*m[6]*m[11] + m[12]*m[7]*m[10]
+	c[8] = m[4]*m[9]*m[15] - m[4]*m[11]*m[13] - m[8]*m[5]*m[15] + m[8]*m[7]*m[13] + m[12]*m[5]*m[11] - m[12]*m[7]*m[9]
+	c[12] = -m[4]*m[9]*m[14] + m[4]*m[10]*m[13] + m[8]*m[5]*m[14] - m[8]*m[6]*m[13] - m[12]*m[5]*m[10] + m[12]*m[6]*m[9]
+	return c
+}
@@ -0,0 +1,261 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package vmath
+
+import (
+	"math"
+	"testing"
+)
+
+var testMat4 = Mat4FromRows([16]float32{
+	2, 0, 1, 3,
+	1, 3, 0, 1,
+	0, 1, 4, 2,
+	0, 0, 0, 1})
+
+func TestMat4Layout(t *testing.T) {
+	m := Translate(Vec3{1, 2, 3})
+	// column-major: translation in elements 12, 13 and 14
+	if m[12] != 1 || m[13] != 2 || m[14] != 3 {
+		t.Errorf("translation not in last column: %v", m)
+	}
+	if m.At(0, 3) != 1 || m.At(1, 3) != 2 || m.At(2, 3) != 3 {
+		t.Errorf("At: %v", m)
+	}
+	if (m.Row(1) != Vec4{0, 1, 0, 2}) || (m.Col(3) != Vec4{1, 2, 3, 1}) {
+		t.Errorf("row %v, col %v", m.Row(1), m.Col(3))
+	}
+	if ptr := m.Ptr(); *ptr != 1 {
+		t.Errorf("Ptr: %v", *ptr)
+	}
+	var n Mat4
+	n.Set(2, 1, 5)
+	if n[6] != 5 {
+		t.Errorf("Set: %v", n)
+	}
+}
+
+func TestMat4TransformPoint(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Mat4
+		p    Vec3
+		want Vec3
+	}{
+		{"identity", Ident4(), Vec3{1, 2, 3}, Vec3{1, 2, 3}},
+		{"translate", Translate(Vec3{1, 2, 3}), Vec3{1, 1, 1}, Vec3{2, 3, 4}},
+		{"scale", Scale(Vec3{2, 3, 4}), Vec3{1, 1, 1}, Vec3{2, 3, 4}},
+		{"rotate x", RotateX(math.Pi / 2), Vec3{0, 1, 0}, Vec3{0, 0, 1}},
+		{"rotate y", RotateY(math.Pi / 2), Vec3{0, 0, 1}, Vec3{1, 0, 0}},
+		{"rotate z", RotateZ(math.Pi / 2), Vec3{1, 0, 0}, Vec3{0, 1, 0}},
+		{"rotate axis", Rotate(Vec3{0, 0, 2}, math.Pi/2), Vec3{1, 0, 0}, Vec3{0, 1, 0}},
+		{"rotate diagonal", Rotate(Vec3{1, 1, 1}, 2*math.Pi/3), Vec3{1, 0, 0}, Vec3{0, 1, 0}},
+		{"translate after rotate", Translate(Vec3{1, 0, 0}).Mul(RotateZ(math.Pi)), Vec3{1, 0, 0}, Vec3{0, 0, 0}},
+		{"rotate after translate", RotateZ(math.Pi).Mul(Translate(Vec3{1, 0, 0})), Vec3{1, 0, 0}, Vec3{-2, 0, 0}},
+	}
+	for _, test := range tests {
+		if got := test.m.TransformPoint(test.p); !got.ApproxEqual(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+	if dir := Translate(Vec3{5, 5, 5}).TransformDir(Vec3{1, 0, 0}); !dir.ApproxEqual(Vec3{1, 0, 0}) {
+		t.Errorf("direction must not be translated: %v", dir)
+	}
+}
+
+func TestMat4Mul(t *testing.T) {
+	a := Mat4FromRows([16]float32{
+		1, 2, 3, 4,
+		5, 6, 7, 8,
+		9, 10, 11, 12,
+		13, 14, 15, 16})
+	b := Mat4FromRows([16]float32{
+		1, 0, 0, 1,
+		0, 2, 0, 0,
+		0, 0, 3, 0,
+		1, 0, 0, 1})
+	want := Mat4FromRows([16]float32{
+		5, 4, 9, 5,
+		13, 12, 21, 13,
+		21, 20, 33, 21,
+		29, 28, 45, 29})
+	tests := []struct {
+		name string
+		got  Mat4
+		want Mat4
+	}{
+		{"product", a.Mul(b), want},
+		{"identity left", Ident4().Mul(a), a},
+		{"identity right", a.Mul(Ident4()), a},
+		{"transpose", a.Transpose().Transpose(), a},
+		{"transpose product", a.Mul(b).Transpose(), b.Transpose().Mul(a.Transpose())},
+		{"from rows", Mat4FromRows(a), a.Transpose()},
+	}
+	for _, test := range tests {
+		if !test.got.ApproxEqual(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, test.got, test.want)
+		}
+	}
+	v := a.MulVec4(Vec4{1, 0, 0, 1})
+	if !v.ApproxEqual(Vec4{5, 13, 21, 29}) {
+		t.Errorf("MulVec4: %v", v)
+	}
+}
+
+func TestMat4Inverse(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Mat4
+	}{
+		{"identity", Ident4()},
+		{"translate", Translate(Vec3{1, -2, 3})},
+		{"scale", Scale(Vec3{2, 4, 0.5})},
+		{"rotate", Rotate(Vec3{1, 2, 3}, 0.7)},
+		{"general", testMat4},
+		{"perspective", Perspective(1, 1.5, 0.1, 100)},
+		{"look at", LookAt(Vec3{1, 2, 3}, Vec3{0, 0, 0}, Vec3{0, 1, 0})},
+	}
+	for _, test := range tests {
+		inv, ok := test.m.Inverse()
+		if !ok {
+			t.Errorf("%s: not invertible", test.name)
+		} else if product := test.m.Mul(inv); !product.ApproxEqual(Ident4()) {
+			t.Errorf("%s: m * inverse = %v", test.name, product)
+		}
+	}
+	if _, ok := Scale(Vec3{1, 0, 1}).Inverse(); ok {
+		t.Error("singular matrix must not be invertible")
+	}
+	if det := testMat4.Det(); !ApproxEqual(det, 25) {
+		t.Errorf("det: got %v, want 25", det)
+	}
+}
+
+func TestProjection(t *testing.T) {
+	perspective := Perspective(math.Pi/2, 2, 1, 10)
+	ortho := Ortho(-2, 2, -1, 1, 1, 10)
+	tests := []struct {
+		name string
+		m    Mat4
+		p    Vec3
+		want Vec3
+	}{
+		{"perspective near", perspective, Vec3{0, 0, -1}, Vec3{0, 0, -1}},
+		{"perspective far", perspective, Vec3{0, 0, -10}, Vec3{0, 0, 1}},
+		{"perspective corner", perspective, Vec3{2, 1, -1}, Vec3{1, 1, -1}},
+		{"ortho near", ortho, Vec3{-2, -1, -1}, Vec3{-1, -1, -1}},
+		{"ortho far", ortho, Vec3{2, 1, -10}, Vec3{1, 1, 1}},
+		{"ortho 2d", Ortho2D(0, 300, 300, 0), Vec3{300, 0, 0}, Vec3{1, 1, 0}},
+	}
+	for _, test := range tests {
+		if got := test.m.TransformPoint(test.p); !got.ApproxEqual(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestLookAt(t *testing.T) {
+	tests := []struct {
+		name            string
+		eye, center, up Vec3
+		point, want     Vec3
+	}{
+		{"default", Vec3{0, 0, 0}, Vec3{0, 0, -1}, Vec3{0, 1, 0}, Vec3{1, 2, -3}, Vec3{1, 2, -3}},
+		{"moved back", Vec3{0, 0, 5}, Vec3{0, 0, 0}, Vec3{0, 1, 0}, Vec3{0, 0, 0}, Vec3{0, 0, -5}},
+		{"from right", Vec3{5, 0, 0}, Vec3{0, 0, 0}, Vec3{0, 1, 0}, Vec3{0, 0, 1}, Vec3{-1, 0, -5}},
+		{"from top", Vec3{0, 5, 0}, Vec3{0, 0, 0}, Vec3{0, 0, -1}, Vec3{0, 0, -1}, Vec3{0, 1, -5}},
+	}
+	for _, test := range tests {
+		view := LookAt(test.eye, test.center, test.up)
+		if got := view.TransformPoint(test.point); !got.ApproxEqual(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestMat3(t *testing.T) {
+	m := Mat3FromRows(
+		2, 0, 1,
+		1, 3, 0,
+		0, 1, 4)
+	if det := m.Det(); !ApproxEqual(det, 25) {
+		t.Errorf("det: got %v, want 25", det)
+	}
+	inv, ok := m.Inverse()
+	if !ok || !m.Mul(inv).ApproxEqual(Ident3()) {
+		t.Errorf("inverse: %v", inv)
+	}
+	if _, ok := (Mat3{}).Inverse(); ok {
+		t.Error("zero matrix must not be invertible")
+	}
+	tests := []struct {
+		name string
+		got  Vec3
+		want Vec3
+	}{
+		{"mul vec3", m.MulVec3(Vec3{1, 1, 1}), Vec3{3, 4, 5}},
+		{"row", m.Row(2), Vec3{0, 1, 4}},
+		{"col", m.Col(2), Vec3{1, 0, 4}},
+		{"transpose", m.Transpose().Row(2), Vec3{1, 0, 4}},
+		{"mat4 round trip", m.Mat4().Mat3().Col(0), m.Col(0)},
+	}
+	for _, test := range tests {
+		if !test.got.ApproxEqual(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, test.got, test.want)
+		}
+	}
+	if m.At(0, 2) != 1 {
+		t.Errorf("At: %v", m.At(0, 2))
+	}
+}
+
+func TestNormalMatrix(t *testing.T) {
+	model := Scale(Vec3{2, 1, 1})
+	// normal of plane x = y
+	normal := model.NormalMatrix().MulVec3(Vec3{1, -1, 0}).Normalize()
+	tangent := model.TransformDir(Vec3{1, 1, 0})
+	if dot := normal.Dot(tangent); !ApproxEqual(dot, 0) {
+		t.Errorf("normal not perpendicular to transformed surface: %v", dot)
+	}
+}
+
+func BenchmarkMat4Mul(b *testing.B) {
+	m := testMat4
+	n := Rotate(Vec3{1, 2, 3}, 0.5)
+	for i := 0; i < b.N; i++ {
+		m = m.Mul(n)
+	}
+}
+
+func BenchmarkMat4MulVec4(b *testing.B) {
+	m := testMat4
+	v := Vec4{1, 2, 3, 1}
+	for i := 0; i < b.N; i++ {
+		v = m.MulVec4(v)
+	}
+}
+
+func BenchmarkMat4Inverse(b *testing.B) {
+	m := testMat4
+	for i := 0; i < b.N; i++ {
+		m, _ = m.Inverse()
+	}
+}
+
+func BenchmarkMat4Transpose(b *testing.B) {
+	m := testMat4
+	for i := 0; i < b.N; i++ {
+		m = m.Transpose()
+	}
+}
+
+func BenchmarkLookAt(b *testing.B) {
+	var m Mat4
+	for i := 0; i < b.N; i++ {
+		m = LookAt(Vec3{1, 2, float32(i)}, Vec3{}, Vec3{0, 1, 0})
+	}
+	_ = m
+}
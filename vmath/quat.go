@@ -0,0 +1,145 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package vmath
+
+import (
+	"math"
+)
+
+// Quat is a quaternion (x, y, z, w), w is the scalar part. Unit quaternions represent rotations.
+type Quat [4]float32
+
+// QuatIdent returns the identity quaternion (no rotation).
+func QuatIdent() Quat {
+	return Quat{0, 0, 0, 1}
+}
+
+// QuatAxisAngle returns the rotation around axis (angle in radians).
+func QuatAxisAngle(axis Vec3, angle float32) Quat {
+	sin, cos := sincos(angle / 2)
+	a := axis.Normalize().Scale(sin)
+	return Quat{a[0], a[1], a[2], cos}
+}
+
+// QuatEuler returns the rotation of yaw around y axis, then pitch around x axis and
+// then roll around z axis (angles in radians, applied right to left: yaw * pitch * roll).
+func QuatEuler(pitch, yaw, roll float32) Quat {
+	qx := QuatAxisAngle(Vec3{1, 0, 0}, pitch)
+	qy := QuatAxisAngle(Vec3{0, 1, 0}, yaw)
+	qz := QuatAxisAngle(Vec3{0, 0, 1}, roll)
+	return qy.Mul(qx).Mul(qz)
+}
+
+// QuatFromMat4 returns the rotation of the upper left 3x3 matrix of m (must not contain scaling).
+func QuatFromMat4(m Mat4) Quat {
+	var q Quat
+	trace := m[0] + m[5] + m[10]
+	if trace > 0 {
+		s := sqrt(trace+1) * 2
+		q = Quat{(m[6] - m[9]) / s, (m[8] - m[2]) / s, (m[1] - m[4]) / s, s / 4}
+	} else if m[0] > m[5] && m[0] > m[10] {
+		s := sqrt(1+m[0]-m[5]-m[10]) * 2
+		q = Quat{s / 4, (m[4] + m[1]) / s, (m[8] + m[2]) / s, (m[6] - m[9]) / s}
+	} else if m[5] > m[10] {
+		s := sqrt(1+m[5]-m[0]-m[10]) * 2
+		q = Quat{(m[4] + m[1]) / s, s / 4, (m[9] + m[6]) / s, (m[8] - m[2]) / s}
+	} else {
+		s := sqrt(1+m[10]-m[0]-m[5]) * 2
+		q = Quat{(m[8] + m[2]) / s, (m[9] + m[6]) / s, s / 4, (m[1] - m[4]) / s}
+	}
+	return q.Normalize()
+}
+
+// Mul returns q * r (rotation r followed by rotation q).
+func (q Quat) Mul(r Quat) Quat {
+	return Quat{
+		q[3]*r[0] + q[0]*r[3] + q[1]*r[2] - q[2]*r[1],
+		q[3]*r[1] - q[0]*r[2] + q[1]*r[3] + q[2]*r[0],
+		q[3]*r[2] + q[0]*r[1] - q[1]*r[0] + q[2]*r[3],
+		q[3]*r[3] - q[0]*r[0] - q[1]*r[1] - q[2]*r[2]}
+}
+
+// Scale returns q * s.
+func (q Quat) Scale(s float32) Quat {
+	return Quat{q[0] * s, q[1] * s, q[2] * s, q[3] * s}
+}
+
+// Dot returns the dot product of q and r.
+func (q Quat) Dot(r Quat) float32 {
+	return q[0]*r[0] + q[1]*r[1] + q[2]*r[2] + q[3]*r[3]
+}
+
+// Len returns the length of q.
+func (q Quat) Len() float32 {
+	return sqrt(q.Dot(q))
+}
+
+// Normalize returns q with length 1. Zero quaternion is returned as identity.
+func (q Quat) Normalize() Quat {
+	if length := q.Len(); length > 0 {
+		return q.Scale(1 / length)
+	}
+	return QuatIdent()
+}
+
+// Conjugate returns the conjugate of q. For unit quaternions it is the inverse.
+func (q Quat) Conjugate() Quat {
+	return Quat{-q[0], -q[1], -q[2], q[3]}
+}
+
+// Inverse returns the inverse of q.
+func (q Quat) Inverse() Quat {
+	lenSq := q.Dot(q)
+	if lenSq > 0 {
+		return q.Conjugate().Scale(1 / lenSq)
+	}
+	return q
+}
+
+// Rotate returns v rotated by q (q must be a unit quaternion).
+func (q Quat) Rotate(v Vec3) Vec3 {
+	u := Vec3{q[0], q[1], q[2]}
+	t := u.Cross(v).Scale(2)
+	return v.Add(t.Scale(q[3])).Add(u.Cross(t))
+}
+
+// Mat4 returns the rotation matrix of q (q must be a unit quaternion).
+func (q Quat) Mat4() Mat4 {
+	xx, yy, zz := q[0]*q[0], q[1]*q[1], q[2]*q[2]
+	xy, xz, yz := q[0]*q[1], q[0]*q[2], q[1]*q[2]
+	wx, wy, wz := q[3]*q[0], q[3]*q[1], q[3]*q[2]
+	return Mat4{
+		1 - 2*(yy+zz), 2 * (xy + wz), 2 * (xz - wy), 0,
+		2 * (xy - wz), 1 - 2*(xx+zz), 2 * (yz + wx), 0,
+		2 * (xz + wy), 2 * (yz - wx), 1 - 2*(xx+yy), 0,
+		0, 0, 0, 1}
+}
+
+// Slerp returns the spherical linear interpolation between q and r along the shortest path.
+func (q Quat) Slerp(r Quat, t float32) Quat {
+	cos := q.Dot(r)
+	if cos < 0 {
+		r = r.Scale(-1)
+		cos = -cos
+	}
+	if cos > 1-Epsilon {
+		// nearly identical, linear interpolation avoids division by zero
+		return Quat{Lerp(q[0], r[0], t), Lerp(q[1], r[1], t), Lerp(q[2], r[2], t), Lerp(q[3], r[3], t)}.Normalize()
+	}
+	angle := math.Acos(float64(cos))
+	sin := math.Sin(angle)
+	a := float32(math.Sin((1-float64(t))*angle) / sin)
+	b := float32(math.Sin(float64(t)*angle) / sin)
+	return Quat{q[0]*a + r[0]*b, q[1]*a + r[1]*b, q[2]*a + r[2]*b, q[3]*a + r[3]*b}
+}
+
+// ApproxEqual returns true, if q and r represent approximately the same rotation.
+func (q Quat) ApproxEqual(r Quat) bool {
+	if q.Dot(r) < 0 {
+		r = r.Scale(-1)
+	}
+	return ApproxEqual(q[0], r[0]) && ApproxEqual(q[1], r[1]) && ApproxEqual(q[2], r[2]) && ApproxEqual(q[3], r[3])
+}
@@ -0,0 +1,112 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package vmath
+
+import (
+	"math"
+	"testing"
+)
+
+func TestQuatRotate(t *testing.T) {
+	tests := []struct {
+		name string
+		q    Quat
+		v    Vec3
+		want Vec3
+	}{
+		{"identity", QuatIdent(), Vec3{1, 2, 3}, Vec3{1, 2, 3}},
+		{"x axis", QuatAxisAngle(Vec3{1, 0, 0}, math.Pi/2), Vec3{0, 1, 0}, Vec3{0, 0, 1}},
+		{"y axis", QuatAxisAngle(Vec3{0, 1, 0}, math.Pi/2), Vec3{0, 0, 1}, Vec3{1, 0, 0}},
+		{"z axis", QuatAxisAngle(Vec3{0, 0, 1}, math.Pi/2), Vec3{1, 0, 0}, Vec3{0, 1, 0}},
+		{"product", QuatAxisAngle(Vec3{0, 0, 1}, math.Pi/2).Mul(QuatAxisAngle(Vec3{1, 0, 0}, math.Pi/2)), Vec3{0, 1, 0}, Vec3{0, 0, 1}},
+		{"euler yaw", QuatEuler(0, math.Pi/2, 0), Vec3{0, 0, 1}, Vec3{1, 0, 0}},
+		{"euler pitch", QuatEuler(math.Pi/2, 0, 0), Vec3{0, 1, 0}, Vec3{0, 0, 1}},
+		{"inverse", QuatAxisAngle(Vec3{1, 1, 0}, 1).Inverse().Mul(QuatAxisAngle(Vec3{1, 1, 0}, 1)), Vec3{1, 2, 3}, Vec3{1, 2, 3}},
+	}
+	for _, test := range tests {
+		if got := test.q.Rotate(test.v); !got.ApproxEqual(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+		if got := test.q.Mat4().TransformPoint(test.v); !got.ApproxEqual(test.want) {
+			t.Errorf("%s (matrix): got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestQuatMat4(t *testing.T) {
+	tests := []struct {
+		name  string
+		axis  Vec3
+		angle float32
+	}{
+		{"x", Vec3{1, 0, 0}, 0.3},
+		{"y", Vec3{0, 1, 0}, 2},
+		{"z", Vec3{0, 0, 1}, -1},
+		{"diagonal", Vec3{1, 2, 3}, 3},
+		{"half turn", Vec3{0, 1, 0}, math.Pi},
+	}
+	for _, test := range tests {
+		q := QuatAxisAngle(test.axis, test.angle)
+		m := Rotate(test.axis, test.angle)
+		if !q.Mat4().ApproxEqual(m) {
+			t.Errorf("%s: got %v, want %v", test.name, q.Mat4(), m)
+		}
+		if got := QuatFromMat4(m); !got.ApproxEqual(q) {
+			t.Errorf("%s: QuatFromMat4 got %v, want %v", test.name, got, q)
+		}
+	}
+}
+
+func TestQuatSlerp(t *testing.T) {
+	a := QuatIdent()
+	b := QuatAxisAngle(Vec3{0, 0, 1}, math.Pi/2)
+	tests := []struct {
+		name string
+		got  Quat
+		want Quat
+	}{
+		{"start", a.Slerp(b, 0), a},
+		{"end", a.Slerp(b, 1), b},
+		{"middle", a.Slerp(b, 0.5), QuatAxisAngle(Vec3{0, 0, 1}, math.Pi/4)},
+		{"shortest path", a.Slerp(b.Scale(-1), 0.5), QuatAxisAngle(Vec3{0, 0, 1}, math.Pi/4)},
+		{"nearly equal", a.Slerp(QuatAxisAngle(Vec3{1, 0, 0}, 1e-4), 0.5), QuatAxisAngle(Vec3{1, 0, 0}, 0.5e-4)},
+	}
+	for _, test := range tests {
+		if !test.got.ApproxEqual(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, test.got, test.want)
+		}
+	}
+	if length := (Quat{1, 2, 3, 4}).Normalize().Len(); !ApproxEqual(length, 1) {
+		t.Errorf("normalize: %v", length)
+	}
+	if (Quat{}).Normalize() != QuatIdent() {
+		t.Error("normalized zero quaternion must be identity")
+	}
+}
+
+func BenchmarkQuatMul(b *testing.B) {
+	q := QuatAxisAngle(Vec3{1, 2, 3}, 0.5)
+	r := QuatAxisAngle(Vec3{3, 2, 1}, 0.1)
+	for i := 0; i < b.N; i++ {
+		q = q.Mul(r)
+	}
+}
+
+func BenchmarkQuatRotate(b *testing.B) {
+	q := QuatAxisAngle(Vec3{1, 2, 3}, 0.5)
+	v := Vec3{1, 0, 0}
+	for i := 0; i < b.N; i++ {
+		v = q.Rotate(v)
+	}
+}
+
+func BenchmarkQuatSlerp(b *testing.B) {
+	q := QuatAxisAngle(Vec3{1, 2, 3}, 0.5)
+	r := QuatAxisAngle(Vec3{3, 2, 1}, 2)
+	for i := 0; i < b.N; i++ {
+		q.Slerp(r, float32(i%100)/100)
+	}
+}
@@ -0,0 +1,189 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package vmath
+
+// Vec2 is a two-dimensional vector (x, y).
+type Vec2 [2]float32
+
+// Vec3 is a three-dimensional vector (x, y, z).
+type Vec3 [3]float32
+
+// Vec4 is a four-dimensional vector (x, y, z, w).
+type Vec4 [4]float32
+
+// Add returns v + u.
+func (v Vec2) Add(u Vec2) Vec2 {
+	return Vec2{v[0] + u[0], v[1] + u[1]}
+}
+
+// Sub returns v - u.
+func (v Vec2) Sub(u Vec2) Vec2 {
+	return Vec2{v[0] - u[0], v[1] - u[1]}
+}
+
+// Mul returns the component-wise product of v and u.
+func (v Vec2) Mul(u Vec2) Vec2 {
+	return Vec2{v[0] * u[0], v[1] * u[1]}
+}
+
+// Scale returns v * s.
+func (v Vec2) Scale(s float32) Vec2 {
+	return Vec2{v[0] * s, v[1] * s}
+}
+
+// Dot returns the dot product of v and u.
+func (v Vec2) Dot(u Vec2) float32 {
+	return v[0]*u[0] + v[1]*u[1]
+}
+
+// Len returns the length of v.
+func (v Vec2) Len() float32 {
+	return sqrt(v.Dot(v))
+}
+
+// Normalize returns v with length 1. Zero vector is returned unchanged.
+func (v Vec2) Normalize() Vec2 {
+	if length := v.Len(); length > 0 {
+		return v.Scale(1 / length)
+	}
+	return v
+}
+
+// Lerp returns the linear interpolation between v and u.
+func (v Vec2) Lerp(u Vec2, t float32) Vec2 {
+	return Vec2{Lerp(v[0], u[0], t), Lerp(v[1], u[1], t)}
+}
+
+// ApproxEqual returns true, if all components of v and u are approximately equal.
+func (v Vec2) ApproxEqual(u Vec2) bool {
+	return ApproxEqual(v[0], u[0]) && ApproxEqual(v[1], u[1])
+}
+
+// Vec3 returns v extended by z.
+func (v Vec2) Vec3(z float32) Vec3 {
+	return Vec3{v[0], v[1], z}
+}
+
+// Add returns v + u.
+func (v Vec3) Add(u Vec3) Vec3 {
+	return Vec3{v[0] + u[0], v[1] + u[1], v[2] + u[2]}
+}
+
+// Sub returns v - u.
+func (v Vec3) Sub(u Vec3) Vec3 {
+	return Vec3{v[0] - u[0], v[1] - u[1], v[2] - u[2]}
+}
+
+// Mul returns the component-wise product of v and u.
+func (v Vec3) Mul(u Vec3) Vec3 {
+	return Vec3{v[0] * u[0], v[1] * u[1], v[2] * u[2]}
+}
+
+// Scale returns v * s.
+func (v Vec3) Scale(s float32) Vec3 {
+	return Vec3{v[0] * s, v[1] * s, v[2] * s}
+}
+
+// Neg returns -v.
+func (v Vec3) Neg() Vec3 {
+	return Vec3{-v[0], -v[1], -v[2]}
+}
+
+// Dot returns the dot product of v and u.
+func (v Vec3) Dot(u Vec3) float32 {
+	return v[0]*u[0] + v[1]*u[1] + v[2]*u[2]
+}
+
+// Cross returns the cross product of v and u.
+func (v Vec3) Cross(u Vec3) Vec3 {
+	return Vec3{v[1]*u[2] - v[2]*u[1], v[2]*u[0] - v[0]*u[2], v[0]*u[1] - v[1]*u[0]}
+}
+
+// Len returns the length of v.
+func (v Vec3) Len() float32 {
+	return sqrt(v.Dot(v))
+}
+
+// Normalize returns v with length 1. Zero vector is returned unchanged.
+func (v Vec3) Normalize() Vec3 {
+	if length := v.Len(); length > 0 {
+		return v.Scale(1 / length)
+	}
+	return v
+}
+
+// Lerp returns the linear interpolation between v and u.
+func (v Vec3) Lerp(u Vec3, t float32) Vec3 {
+	return Vec3{Lerp(v[0], u[0], t), Lerp(v[1], u[1], t), Lerp(v[2], u[2], t)}
+}
+
+// ApproxEqual returns true, if all components of v and u are approximately equal.
+func (v Vec3) ApproxEqual(u Vec3) bool {
+	return ApproxEqual(v[0], u[0]) && ApproxEqual(v[1], u[1]) && ApproxEqual(v[2], u[2])
+}
+
+// Vec2 returns x and y of v.
+func (v Vec3) Vec2() Vec2 {
+	return Vec2{v[0], v[1]}
+}
+
+// Vec4 returns v extended by w.
+func (v Vec3) Vec4(w float32) Vec4 {
+	return Vec4{v[0], v[1], v[2], w}
+}
+
+// Add returns v + u.
+func (v Vec4) Add(u Vec4) Vec4 {
+	return Vec4{v[0] + u[0], v[1] + u[1], v[2] + u[2], v[3] + u[3]}
+}
+
+// Sub returns v - u.
+func (v Vec4) Sub(u Vec4) Vec4 {
+	return Vec4{v[0] - u[0], v[1] - u[1], v[2] - u[2], v[3] - u[3]}
+}
+
+// Mul returns the component-wise product of v and u.
+func (v Vec4) Mul(u Vec4) Vec4 {
+	return Vec4{v[0] * u[0], v[1] * u[1], v[2] * u[2], v[3] * u[3]}
+}
+
+// Scale returns v * s.
+func (v Vec4) Scale(s float32) Vec4 {
+	return Vec4{v[0] * s, v[1] * s, v[2] * s, v[3] * s}
+}
+
+// Dot returns the dot product of v and u.
+func (v Vec4) Dot(u Vec4) float32 {
+	return v[0]*u[0] + v[1]*u[1] + v[2]*u[2] + v[3]*u[3]
+}
+
+// Len returns the length of v.
+func (v Vec4) Len() float32 {
+	return sqrt(v.Dot(v))
+}
+
+// Normalize returns v with length 1. Zero vector is returned unchanged.
+func (v Vec4) Normalize() Vec4 {
+	if length := v.Len(); length > 0 {
+		return v.Scale(1 / length)
+	}
+	return v
+}
+
+// Lerp returns the linear interpolation between v and u.
+func (v Vec4) Lerp(u Vec4, t float32) Vec4 {
+	return Vec4{Lerp(v[0], u[0], t), Lerp(v[1], u[1], t), Lerp(v[2], u[2], t), Lerp(v[3], u[3], t)}
+}
+
+// ApproxEqual returns true, if all components of v and u are approximately equal.
+func (v Vec4) ApproxEqual(u Vec4) bool {
+	return ApproxEqual(v[0], u[0]) && ApproxEqual(v[1], u[1]) && ApproxEqual(v[2], u[2]) && ApproxEqual(v[3], u[3])
+}
+
+// Vec3 returns x, y and z of v.
+func (v Vec4) Vec3() Vec3 {
+	return Vec3{v[0], v[1], v[2]}
+}
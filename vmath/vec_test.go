@@ -0,0 +1,140 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+package vmath
+
+import (
+	"math"
+	"testing"
+)
+
+func TestScalars(t *testing.T) {
+	tests := []struct {
+		name string
+		got  float32
+		want float32
+	}{
+		{"radians 180", Radians(180), math.Pi},
+		{"degrees pi/2", Degrees(math.Pi / 2), 90},
+		{"clamp below", Clamp(-2, -1, 1), -1},
+		{"clamp above", Clamp(2, -1, 1), 1},
+		{"clamp inside", Clamp(0.5, -1, 1), 0.5},
+		{"lerp", Lerp(2, 4, 0.25), 2.5},
+	}
+	for _, test := range tests {
+		if !ApproxEqual(test.got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestApproxEqual(t *testing.T) {
+	tests := []struct {
+		a, b float32
+		want bool
+	}{
+		{1, 1, true},
+		{1, 1 + Epsilon/2, true},
+		{1, 1.001, false},
+		{100000, 100000.5, true},
+		{0, -Epsilon / 2, true},
+	}
+	for _, test := range tests {
+		if got := ApproxEqual(test.a, test.b); got != test.want {
+			t.Errorf("ApproxEqual(%v, %v): got %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestVec2(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Vec2
+		want Vec2
+	}{
+		{"add", Vec2{1, 2}.Add(Vec2{3, 4}), Vec2{4, 6}},
+		{"sub", Vec2{1, 2}.Sub(Vec2{3, 5}), Vec2{-2, -3}},
+		{"mul", Vec2{1, 2}.Mul(Vec2{3, 4}), Vec2{3, 8}},
+		{"scale", Vec2{1, -2}.Scale(3), Vec2{3, -6}},
+		{"normalize", Vec2{3, 4}.Normalize(), Vec2{0.6, 0.8}},
+		{"normalize zero", Vec2{}.Normalize(), Vec2{}},
+		{"lerp", Vec2{0, 0}.Lerp(Vec2{2, 4}, 0.5), Vec2{1, 2}},
+	}
+	for _, test := range tests {
+		if !test.got.ApproxEqual(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, test.got, test.want)
+		}
+	}
+	if dot := (Vec2{1, 2}).Dot(Vec2{3, 4}); dot != 11 {
+		t.Errorf("dot: got %v, want 11", dot)
+	}
+	if length := (Vec2{3, 4}).Len(); length != 5 {
+		t.Errorf("len: got %v, want 5", length)
+	}
+}
+
+func TestVec3(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Vec3
+		want Vec3
+	}{
+		{"add", Vec3{1, 2, 3}.Add(Vec3{4, 5, 6}), Vec3{5, 7, 9}},
+		{"sub", Vec3{1, 2, 3}.Sub(Vec3{4, 5, 6}), Vec3{-3, -3, -3}},
+		{"mul", Vec3{1, 2, 3}.Mul(Vec3{4, 5, 6}), Vec3{4, 10, 18}},
+		{"scale", Vec3{1, 2, 3}.Scale(-2), Vec3{-2, -4, -6}},
+		{"neg", Vec3{1, -2, 3}.Neg(), Vec3{-1, 2, -3}},
+		{"cross x y", Vec3{1, 0, 0}.Cross(Vec3{0, 1, 0}), Vec3{0, 0, 1}},
+		{"cross y x", Vec3{0, 1, 0}.Cross(Vec3{1, 0, 0}), Vec3{0, 0, -1}},
+		{"cross", Vec3{1, 2, 3}.Cross(Vec3{4, 5, 6}), Vec3{-3, 6, -3}},
+		{"normalize", Vec3{0, 3, 4}.Normalize(), Vec3{0, 0.6, 0.8}},
+		{"normalize zero", Vec3{}.Normalize(), Vec3{}},
+		{"lerp", Vec3{1, 1, 1}.Lerp(Vec3{3, 5, 7}, 0.5), Vec3{2, 3, 4}},
+		{"vec4", Vec4{1, 2, 3, 4}.Vec3(), Vec3{1, 2, 3}},
+		{"vec2", Vec2{1, 2}.Vec3(3), Vec3{1, 2, 3}},
+	}
+	for _, test := range tests {
+		if !test.got.ApproxEqual(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, test.got, test.want)
+		}
+	}
+	if dot := (Vec3{1, 2, 3}).Dot(Vec3{4, 5, 6}); dot != 32 {
+		t.Errorf("dot: got %v, want 32", dot)
+	}
+	if length := (Vec3{2, 3, 6}).Len(); length != 7 {
+		t.Errorf("len: got %v, want 7", length)
+	}
+}
+
+func TestVec4(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Vec4
+		want Vec4
+	}{
+		{"add", Vec4{1, 2, 3, 4}.Add(Vec4{4, 3, 2, 1}), Vec4{5, 5, 5, 5}},
+		{"sub", Vec4{1, 2, 3, 4}.Sub(Vec4{1, 1, 1, 1}), Vec4{0, 1, 2, 3}},
+		{"mul", Vec4{1, 2, 3, 4}.Mul(Vec4{2, 2, 2, 2}), Vec4{2, 4, 6, 8}},
+		{"scale", Vec4{1, 2, 3, 4}.Scale(0.5), Vec4{0.5, 1, 1.5, 2}},
+		{"normalize", Vec4{1, 1, 1, 1}.Normalize(), Vec4{0.5, 0.5, 0.5, 0.5}},
+		{"lerp", Vec4{}.Lerp(Vec4{4, 4, 4, 4}, 0.25), Vec4{1, 1, 1, 1}},
+		{"vec3", Vec3{1, 2, 3}.Vec4(1), Vec4{1, 2, 3, 1}},
+	}
+	for _, test := range tests {
+		if !test.got.ApproxEqual(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, test.got, test.want)
+		}
+	}
+	if dot := (Vec4{1, 2, 3, 4}).Dot(Vec4{1, 2, 3, 4}); dot != 30 {
+		t.Errorf("dot: got %v, want 30", dot)
+	}
+}
+
+func BenchmarkVec3Cross(b *testing.B) {
+	v, u := Vec3{1, 2, 3}, Vec3{4, 5, 6}
+	for i := 0; i < b.N; i++ {
+		v = v.Cross(u).Normalize()
+	}
+}
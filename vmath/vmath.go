@@ -0,0 +1,77 @@
+//          Copyright 2020, Vitali Baumtrok.
+// Distributed under the Boost Software License, Version 1.0.
+//     (See accompanying file LICENSE or copy at
+//        http://www.boost.org/LICENSE_1_0.txt)
+
+// Package vmath provides float32 vectors, matrices and quaternions for OpenGL.
+//
+// Matrices are stored in column-major order, i.e. the layout gl.UniformMatrix4fv and
+// gl.UniformMatrix3fv expect with transpose set to false. Vectors are column vectors,
+// transformations are applied right to left (projection * view * model * vector).
+package vmath
+
+import (
+	"math"
+)
+
+// Epsilon is the tolerance used by ApproxEqual.
+const Epsilon = 1e-5
+
+// Radians converts degrees to radians.
+func Radians(degrees float32) float32 {
+	return degrees * math.Pi / 180
+}
+
+// Degrees converts radians to degrees.
+func Degrees(radians float32) float32 {
+	return radians * 180 / math.Pi
+}
+
+// Clamp returns value limited to range min to max.
+func Clamp(value, min, max float32) float32 {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
+
+// Lerp returns the linear interpolation between a and b.
+func Lerp(a, b, t float32) float32 {
+	return a + (b-a)*t
+}
+
+// ApproxEqual returns true, if a and b differ by at most Epsilon (relative for
+// large values).
+func ApproxEqual(a, b float32) bool {
+	diff := abs(a - b)
+	if diff <= Epsilon {
+		return true
+	}
+	return diff <= Epsilon*max(abs(a), abs(b))
+}
+
+func abs(value float32) float32 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+func max(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func sqrt(value float32) float32 {
+	return float32(math.Sqrt(float64(value)))
+}
+
+func sincos(angle float32) (float32, float32) {
+	sin, cos := math.Sincos(float64(angle))
+	return float32(sin), float32(cos)
+}